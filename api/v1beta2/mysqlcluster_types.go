@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"time"
 
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/robfig/cron/v3"
@@ -60,6 +63,15 @@ type MySQLClusterSpec struct {
 	// +optional
 	ReplicationSourceSecretName *string `json:"replicationSourceSecretName,omitempty"`
 
+	// ReplicationSource specifies another MySQLCluster as the replication source.
+	// If this field is given, the `MySQLCluster` works as an intermediate primary
+	// that follows the current primary of the source cluster.
+	// MOCO uses the credentials of the source cluster, so no Secret needs to be prepared.
+	// This field and `replicationSourceSecretName` are mutually exclusive.
+	// +nullable
+	// +optional
+	ReplicationSource *ReplicationSourceSpec `json:"replicationSource,omitempty"`
+
 	// Collectors is the list of collector flag names of mysqld_exporter.
 	// If this field is not empty, MOCO adds mysqld_exporter as a sidecar to collect
 	// and export mysqld metrics in Prometheus format.
@@ -153,6 +165,472 @@ type MySQLClusterSpec struct {
 	// Offline sets the cluster offline, releasing compute resources. Data is not removed.
 	// +optional
 	Offline bool `json:"offline,omitempty"`
+
+	// FailoverPolicy specifies how MOCO handles the failure of the primary instance.
+	// If "Automatic", MOCO fails over to the most advanced replica as soon as the primary is determined to be failed.
+	// If "Manual", MOCO sets the `FailoverPending` condition and waits for an operator to approve the failover
+	// with `kubectl moco failover` or the `moco.cybozu.com/approve-failover` annotation.
+	// The default is "Automatic".
+	// +kubebuilder:default=Automatic
+	// +optional
+	FailoverPolicy FailoverPolicy `json:"failoverPolicy,omitempty"`
+
+	// FencingStrategies is the list of the ways to fence the old primary instance
+	// before a failover promotes another instance.  The strategies are applied in the given order.
+	// A fencing failure is recorded as an Event and does not stop the failover.
+	// If empty, "RemoveRoleLabel" and "SuperReadOnly" are applied.
+	// +optional
+	FencingStrategies []FencingStrategy `json:"fencingStrategies,omitempty"`
+
+	// Clustering configures the timeouts of the clustering operations such as switchover and failover,
+	// and the interval to check the cluster.
+	// +optional
+	Clustering *ClusteringSpec `json:"clustering,omitempty"`
+
+	// SwitchoverHooks are called before and after a switchover.
+	// Hooks are not called on failover.
+	// +optional
+	SwitchoverHooks *SwitchoverHooks `json:"switchoverHooks,omitempty"`
+
+	// MaintenanceWindows restricts when voluntary disruptive operations are performed.
+	// Planned switchovers requested by the demote annotation, rolling updates of the Pods,
+	// and volume resizes are delayed until one of the windows opens.
+	// Failover and switchovers of deleted Pods are not restricted.
+	// If empty, the operations are performed at any time.
+	// +optional
+	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
+
+	// Promotion configures how MOCO chooses the new primary instance on switchover and failover.
+	// +optional
+	Promotion *PromotionSpec `json:"promotion,omitempty"`
+
+	// DelayedReplicas is a list of instances that apply transactions with a delay
+	// to protect data from operational mistakes such as an accidental `DROP TABLE`.
+	// Delayed replicas replicate asynchronously and are never promoted to the primary.
+	// At least one replica other than the current primary must be promotable and not delayed.
+	// +optional
+	DelayedReplicas []DelayedReplica `json:"delayedReplicas,omitempty"`
+
+	// SemiSync configures the semi-synchronous replication between the primary and replicas.
+	// +optional
+	SemiSync *SemiSyncSpec `json:"semiSync,omitempty"`
+
+	// ReadPoolReplicas is a list of the ordinals of instances that replicate asynchronously
+	// to serve read-only queries, e.g. instances in a remote region.
+	// They do not take part in the semi-synchronous replication and are never promoted to the primary.
+	// +optional
+	ReadPoolReplicas []int32 `json:"readPoolReplicas,omitempty"`
+
+	// ErrantReplicaPolicy specifies how MOCO handles replicas that have errant transactions.
+	// If "Keep", MOCO stops the replication of errant replicas and keeps their data as is.
+	// If "Reclone", MOCO discards the data of errant replicas and re-clones them from the primary.
+	// The discarded errant transactions are recorded in `status.errantReplicaHistory` and an Event.
+	// Regardless of this field, `moco.cybozu.com/reclone-errant: "true"` annotation of a Pod
+	// makes MOCO re-clone the instance once.
+	// The default is "Keep".
+	// +kubebuilder:default=Keep
+	// +optional
+	ErrantReplicaPolicy ErrantReplicaPolicy `json:"errantReplicaPolicy,omitempty"`
+
+	// DeletePVCsOnScaleIn controls whether to delete the PersistentVolumeClaims of
+	// the instances removed by decreasing `replicas`.
+	// If false, the PersistentVolumeClaims are retained.  The default is false.
+	// +optional
+	DeletePVCsOnScaleIn bool `json:"deletePVCsOnScaleIn,omitempty"`
+
+	// ReplicationMode is how the instances replicate data.
+	// If "SemiSync", MOCO configures GTID-based semi-synchronous replication from the primary to the replicas.
+	// If "GroupReplication", MOCO forms a single-primary group of MySQL Group Replication
+	// and makes the primary elected by the group the primary of the cluster.
+	// This field cannot be changed after the cluster is created.
+	// The default is "SemiSync".
+	// +kubebuilder:default=SemiSync
+	// +optional
+	ReplicationMode ReplicationMode `json:"replicationMode,omitempty"`
+}
+
+// ReplicationMode is the mode of the replication between the instances.
+// +kubebuilder:validation:Enum=SemiSync;GroupReplication
+type ReplicationMode string
+
+const (
+	// ReplicationModeSemiSync configures semi-synchronous replication.
+	ReplicationModeSemiSync ReplicationMode = "SemiSync"
+
+	// ReplicationModeGroupReplication configures single-primary Group Replication.
+	ReplicationModeGroupReplication ReplicationMode = "GroupReplication"
+)
+
+// MaxGroupReplicationMembers is the maximum number of the members of a group of Group Replication.
+const MaxGroupReplicationMembers = 9
+
+// FailoverPolicy is the policy of failover.
+// +kubebuilder:validation:Enum=Automatic;Manual
+type FailoverPolicy string
+
+const (
+	// FailoverPolicyAutomatic makes MOCO fail over without human intervention.
+	FailoverPolicyAutomatic FailoverPolicy = "Automatic"
+
+	// FailoverPolicyManual makes MOCO wait for an approval before failing over.
+	FailoverPolicyManual FailoverPolicy = "Manual"
+)
+
+// FencingStrategy is a way to fence the old primary instance on failover.
+// +kubebuilder:validation:Enum=RemoveRoleLabel;DeletePod;NetworkPolicy;SuperReadOnly
+type FencingStrategy string
+
+const (
+	// FencingRemoveRoleLabel removes the role label from the Pod to remove it from the primary Service.
+	FencingRemoveRoleLabel FencingStrategy = "RemoveRoleLabel"
+
+	// FencingDeletePod deletes the Pod.
+	FencingDeletePod FencingStrategy = "DeletePod"
+
+	// FencingNetworkPolicy creates a NetworkPolicy that denies the traffic to the Pod except for MOCO.
+	// The NetworkPolicy is deleted when the cluster becomes healthy again.
+	FencingNetworkPolicy FencingStrategy = "NetworkPolicy"
+
+	// FencingSuperReadOnly sets `super_read_only=1` if the instance is reachable.
+	FencingSuperReadOnly FencingStrategy = "SuperReadOnly"
+)
+
+// PromotionSpec configures the preference of instances to be promoted to the primary.
+// The preference is used only to choose one of the instances that are equally up-to-date;
+// an instance that lacks some transactions is never preferred over more advanced ones.
+type PromotionSpec struct {
+	// Priorities is a list of promotion priorities indexed by the ordinal of instances.
+	// An instance with a higher priority is preferred.  The priority of an instance not in the list is 0.
+	// `moco.cybozu.com/promotion-priority` annotation of a Pod overrides this.
+	// +optional
+	Priorities []int32 `json:"priorities,omitempty"`
+
+	// NeverPromote is a list of the ordinals of instances that must never be promoted to the primary,
+	// e.g. instances dedicated to backups or analytics.
+	// `moco.cybozu.com/never-promote: "true"` annotation of a Pod has the same effect.
+	// +optional
+	NeverPromote []int32 `json:"neverPromote,omitempty"`
+
+	// PreferredZones is a list of zones in the descending order of preference.
+	// The zone of an instance is taken from `topology.kubernetes.io/zone` label of the Node where the Pod runs.
+	// +optional
+	PreferredZones []string `json:"preferredZones,omitempty"`
+}
+
+// ErrantReplicaPolicy is the policy for replicas having errant transactions.
+// +kubebuilder:validation:Enum=Keep;Reclone
+type ErrantReplicaPolicy string
+
+const (
+	// ErrantReplicaPolicyKeep makes MOCO keep the data of errant replicas.
+	ErrantReplicaPolicyKeep ErrantReplicaPolicy = "Keep"
+
+	// ErrantReplicaPolicyReclone makes MOCO re-clone errant replicas from the primary.
+	ErrantReplicaPolicyReclone ErrantReplicaPolicy = "Reclone"
+)
+
+// ReplicationSourceSpec specifies the replication source of an intermediate primary.
+type ReplicationSourceSpec struct {
+	// ClusterRef refers to the MySQLCluster to replicate data from.
+	// The source cluster must be managed by the same MOCO and must not be the cluster itself.
+	ClusterRef ClusterReference `json:"clusterRef"`
+}
+
+// ClusterReference refers to a MySQLCluster.
+type ClusterReference struct {
+	// Namespace is the namespace of the MySQLCluster.
+	// If empty, the namespace of the referring MySQLCluster is used.
+	// A MySQLCluster in another namespace must allow it by
+	// `moco.cybozu.com/replication-allowed-namespaces` annotation.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name is the name of the MySQLCluster.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+}
+
+// SemiSyncSpec configures the semi-synchronous replication.
+type SemiSyncSpec struct {
+	// WaitForReplicaCount is the number of replicas that must acknowledge a transaction
+	// before the primary commits it.  This is set to `rpl_semi_sync_master_wait_for_slave_count`.
+	// If not set, it is the half of the instances taking part in the semi-synchronous replication.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	WaitForReplicaCount *int32 `json:"waitForReplicaCount,omitempty"`
+
+	// TimeoutSeconds is the time for the primary to wait for the acknowledgements.
+	// When the timeout happens, the primary falls back to the asynchronous replication,
+	// and transactions committed since then may be lost by a failover.
+	// This is set to `rpl_semi_sync_master_timeout`.  The default is 86400 (1 day).
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// ClusteringSpec configures the timeouts of the clustering operations.
+// The defaults are used for the omitted fields.
+type ClusteringSpec struct {
+	// GTIDWaitTimeoutSeconds is the time to wait for the new primary to execute
+	// all the transactions on switchover and failover.  The default is 50.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	GTIDWaitTimeoutSeconds *int32 `json:"gtidWaitTimeoutSeconds,omitempty"`
+
+	// ReadOnlyTimeoutSeconds is the time to wait for the primary to become read-only
+	// on switchover before killing the connections of the clients.
+	// This must be less than the grace period of the switchover on Pod deletion, that is 20 seconds.
+	// The default is 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ReadOnlyTimeoutSeconds *int32 `json:"readOnlyTimeoutSeconds,omitempty"`
+
+	// RoleChangeWait is the time to wait for the role change of Pods to be propagated
+	// before making the primary writable.  The default is "300ms".
+	// +optional
+	RoleChangeWait *metav1.Duration `json:"roleChangeWait,omitempty"`
+
+	// CloneRestartWait is the time to wait for mysqld to restart after a clone.
+	// The default is "3s".
+	// +optional
+	CloneRestartWait *metav1.Duration `json:"cloneRestartWait,omitempty"`
+
+	// CheckInterval is the interval to check and maintain the cluster.
+	// `moco.cybozu.com/check-interval` annotation of MySQLCluster overrides this.
+	// The default is the value of `--check-interval` flag of moco-controller.
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+
+	// AdaptiveCheckInterval makes MOCO check the cluster more frequently while it is
+	// Degraded, Failed, or Incomplete and right after the primary is changed,
+	// and less frequently while it stays Healthy.
+	// +optional
+	AdaptiveCheckInterval bool `json:"adaptiveCheckInterval,omitempty"`
+
+	// ConnectionDrain configures how the client connections are closed
+	// on switchover and role changes of the instances.
+	// +optional
+	ConnectionDrain *ConnectionDrainSpec `json:"connectionDrain,omitempty"`
+
+	// SwitchoverCheck configures the safety check before a switchover.
+	// +optional
+	SwitchoverCheck *SwitchoverCheckSpec `json:"switchoverCheck,omitempty"`
+
+	// FenceSplitBrain makes MOCO set `super_read_only=1` on the writable instances
+	// other than the primary as soon as they are found.
+	// +optional
+	FenceSplitBrain bool `json:"fenceSplitBrain,omitempty"`
+
+	// RoleChangeLimit limits how often MOCO changes the primary by switchover and failover.
+	// When a limit is hit, MOCO holds the role changes until an operator acknowledges.
+	// +optional
+	RoleChangeLimit *RoleChangeLimitSpec `json:"roleChangeLimit,omitempty"`
+}
+
+// ConnectionDrainSpec configures the draining of the client connections.
+type ConnectionDrainSpec struct {
+	// GracePeriod is the time to wait for the client sessions to become idle
+	// before killing them.  A session is idle if it is sleeping without an open transaction.
+	// If not set, the connections are killed immediately.
+	// +optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+
+	// ProtectedUsers is the list of MySQL users whose connections are not killed while
+	// draining the connections of the primary before a switchover.  They are killed with
+	// the others if the primary cannot be made read-only in `readOnlyTimeoutSeconds`.
+	// +optional
+	ProtectedUsers []string `json:"protectedUsers,omitempty"`
+
+	// KillFirstUsers is the list of MySQL users whose connections are killed
+	// at the beginning of draining without waiting for them to become idle.
+	// +optional
+	KillFirstUsers []string `json:"killFirstUsers,omitempty"`
+}
+
+// SwitchoverCheckPolicy is the policy for a switchover found to be unsafe.
+// +kubebuilder:validation:Enum=Postpone;Warn;Ignore
+type SwitchoverCheckPolicy string
+
+const (
+	// SwitchoverCheckPostpone postpones the switchover until it becomes safe.
+	// Switchovers for Pod deletion and scale-in are not postponed.
+	SwitchoverCheckPostpone = SwitchoverCheckPolicy("Postpone")
+
+	// SwitchoverCheckWarn records an event and proceeds with the switchover.
+	SwitchoverCheckWarn = SwitchoverCheckPolicy("Warn")
+
+	// SwitchoverCheckIgnore skips the check.
+	SwitchoverCheckIgnore = SwitchoverCheckPolicy("Ignore")
+)
+
+// SwitchoverCheckSpec configures the safety check before a switchover.
+// A switchover is unsafe if the primary has a long-running transaction or DDL
+// that would be rolled back, or if the candidate lags far behind the primary.
+type SwitchoverCheckSpec struct {
+	// Policy is the policy for an unsafe switchover.
+	// +kubebuilder:default=Postpone
+	// +optional
+	Policy SwitchoverCheckPolicy `json:"policy,omitempty"`
+
+	// MaxTransactionAge makes the switchover unsafe if a transaction or a DDL statement
+	// has been running on the primary for this duration or longer.  The default is "30s".
+	// +optional
+	MaxTransactionAge *metav1.Duration `json:"maxTransactionAge,omitempty"`
+
+	// MaxReplicationLag makes the switchover unsafe if the candidate lags behind
+	// the primary by more than this duration.  The default is "10s".
+	// +optional
+	MaxReplicationLag *metav1.Duration `json:"maxReplicationLag,omitempty"`
+}
+
+// RoleChangeLimitSpec limits how often MOCO changes the primary.
+//
+// When the limit is hit, automatic switchovers and failovers are held until an operator
+// acknowledges it.  Switchovers requested by `moco.cybozu.com/demote` annotation and
+// failovers approved by `moco.cybozu.com/approve-failover` annotation are manual, so they
+// are neither counted nor held.  In GroupReplication mode, the primary changes elected by
+// the group are neither counted nor held.
+type RoleChangeLimitSpec struct {
+	// MaxRoleChanges is the maximum number of automatic switchovers and failovers in `window`.
+	// If not set, the number is not limited.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	MaxRoleChanges int32 `json:"maxRoleChanges,omitempty"`
+
+	// Window is the period in which the role changes are counted.  The default is "1h".
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// MinFailoverInterval is the minimum interval between failovers.
+	// If not set, the interval is not limited.
+	// +optional
+	MinFailoverInterval *metav1.Duration `json:"minFailoverInterval,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period of time in which voluntary disruptive operations are allowed.
+type MaintenanceWindow struct {
+	// Schedule specifies when the window opens.
+	// See https://pkg.go.dev/github.com/robfig/cron/v3#hdr-CRON_Expression_Format for the field format.
+	// The time zone is UTC unless `CRON_TZ=` is specified.
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// SwitchoverHooks is the list of hooks called before and after a switchover.
+type SwitchoverHooks struct {
+	// Pre is the list of hooks called in order before the primary is made read-only.
+	// The total timeout of the pre hooks, `readOnlyTimeoutSeconds`, and the grace period
+	// of the connection drain must be shorter than the switchover on Pod deletion, that is 20 seconds.
+	// +optional
+	Pre []SwitchoverHook `json:"pre,omitempty"`
+
+	// Post is the list of hooks called in order in the background after the new primary is decided.
+	// If the switchover is abandoned after the pre hooks are called, the post hooks are called
+	// with "abort" phase.
+	// +optional
+	Post []SwitchoverHook `json:"post,omitempty"`
+}
+
+// DefaultHookTimeoutSeconds is the default value of `timeoutSeconds` of a switchover hook.
+const DefaultHookTimeoutSeconds = 5
+
+// HookFailurePolicy is the policy for a failure of a hook.
+// +kubebuilder:validation:Enum=Abort;Continue
+type HookFailurePolicy string
+
+const (
+	// HookFailureAbort aborts the switchover if a pre hook fails.
+	// If a post hook fails, the remaining post hooks are not called.
+	HookFailureAbort = HookFailurePolicy("Abort")
+
+	// HookFailureContinue ignores a failure of the hook.
+	HookFailureContinue = HookFailurePolicy("Continue")
+)
+
+// SwitchoverHook is a hook called on switchover.
+// Exactly one of `http` or `job` must be specified.
+type SwitchoverHook struct {
+	// Name is the name of the hook.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=20
+	// +kubebuilder:validation:Pattern="^[a-z0-9]([-a-z0-9]*[a-z0-9])?$"
+	Name string `json:"name"`
+
+	// HTTP calls an HTTP endpoint.
+	// +optional
+	HTTP *HTTPHook `json:"http,omitempty"`
+
+	// Job runs a Job in the namespace of the cluster.
+	// +optional
+	Job *JobHook `json:"job,omitempty"`
+
+	// TimeoutSeconds is the time to wait for the hook to finish.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=5
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailurePolicy is the policy for a failure of the hook.
+	// +kubebuilder:default=Abort
+	// +optional
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// HTTPHook is a hook that sends a POST request to an HTTP endpoint.
+// The request body is a JSON object having "namespace", "name", "phase",
+// "primary", and "nextPrimary" fields.  The hook succeeds if the response status is 2xx.
+//
+// The request is sent from moco-controller, so the URL can reach any endpoint reachable
+// from moco-controller.  Only trusted users should be allowed to edit MySQLCluster.
+// The loopback and link-local addresses are refused not to expose moco-controller itself
+// and the metadata services of the cloud providers.
+type HTTPHook struct {
+	// URL is the URL of the endpoint.
+	// +kubebuilder:validation:Pattern="^https?://"
+	URL string `json:"url"`
+}
+
+// JobHook is a hook that runs a Job.  The hook succeeds if the Job completes.
+// The Job is given the environment variables MOCO_CLUSTER_NAMESPACE, MOCO_CLUSTER_NAME,
+// MOCO_HOOK_PHASE, MOCO_PRIMARY_INDEX, and MOCO_NEXT_PRIMARY_INDEX.
+// The Pod of the Job never runs with the ServiceAccount of the mysqld Pods.
+type JobHook struct {
+	// Image is the container image to run.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Command is the command to run.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args is the arguments to the command.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Env is the list of additional environment variables.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// ServiceAccountName is the name of the ServiceAccount to run the Job.
+	// If empty, the default ServiceAccount of the namespace is used.
+	// It must not be the ServiceAccount of the mysqld Pods, "moco-<cluster name>".
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+}
+
+// DelayedReplica specifies an instance that applies transactions with a delay.
+type DelayedReplica struct {
+	// Index is the ordinal of the instance.
+	// +kubebuilder:validation:Minimum=0
+	Index int32 `json:"index"`
+
+	// DelaySeconds is the delay for the instance to apply transactions.
+	// This is set to `SOURCE_DELAY` of the replication.
+	// +kubebuilder:validation:Minimum=1
+	DelaySeconds int32 `json:"delaySeconds"`
 }
 
 //nolint:gocyclo,unparam
@@ -177,6 +655,10 @@ func (s MySQLClusterSpec) validateCreate() (admission.Warnings, field.ErrorList)
 		}
 	}
 
+	if s.ReplicationSourceSecretName != nil && s.ReplicationSource != nil {
+		allErrs = append(allErrs, field.Forbidden(p.Child("replicationSource"), "replicationSource and replicationSourceSecretName are mutually exclusive"))
+	}
+
 	pp = p.Child("serverIDBase")
 	if s.ServerIDBase <= 0 {
 		allErrs = append(allErrs, field.Invalid(pp, s.ServerIDBase, "serverIDBase must be a positive integer"))
@@ -203,6 +685,204 @@ func (s MySQLClusterSpec) validateCreate() (admission.Warnings, field.ErrorList)
 		allErrs = append(allErrs, field.Invalid(pp, s.Replicas, "replicas must be a positive integer"))
 	}
 
+	if s.Promotion != nil {
+		pp = p.Child("promotion", "neverPromote")
+		for i, idx := range s.Promotion.NeverPromote {
+			if idx < 0 || idx >= s.Replicas {
+				allErrs = append(allErrs, field.Invalid(pp.Index(i), idx, "must be an ordinal of an instance"))
+			}
+		}
+		if s.Replicas > 0 && len(s.Promotion.NeverPromote) > 0 {
+			never := make(map[int32]bool)
+			for _, idx := range s.Promotion.NeverPromote {
+				never[idx] = true
+			}
+			if len(never) >= int(s.Replicas) {
+				allErrs = append(allErrs, field.Invalid(pp, s.Promotion.NeverPromote, "at least one instance must be promotable"))
+			}
+		}
+	}
+
+	pp = p.Child("delayedReplicas")
+	delayed := make(map[int32]bool)
+	for i, d := range s.DelayedReplicas {
+		if d.Index < 0 || d.Index >= s.Replicas {
+			allErrs = append(allErrs, field.Invalid(pp.Index(i).Child("index"), d.Index, "must be an ordinal of an instance"))
+		}
+		if delayed[d.Index] {
+			allErrs = append(allErrs, field.Duplicate(pp.Index(i).Child("index"), d.Index))
+		}
+		delayed[d.Index] = true
+	}
+	if len(delayed) > 0 && len(delayed) > int(s.Replicas)-2 {
+		allErrs = append(allErrs, field.Invalid(pp, len(delayed), "at least two instances must not be delayed"))
+	}
+
+	pp = p.Child("readPoolReplicas")
+	readPool := make(map[int32]bool)
+	for i, idx := range s.ReadPoolReplicas {
+		if idx < 0 || idx >= s.Replicas {
+			allErrs = append(allErrs, field.Invalid(pp.Index(i), idx, "must be an ordinal of an instance"))
+		}
+		if readPool[idx] {
+			allErrs = append(allErrs, field.Duplicate(pp.Index(i), idx))
+		}
+		if delayed[idx] {
+			allErrs = append(allErrs, field.Invalid(pp.Index(i), idx, "must not be a delayed replica"))
+		}
+		readPool[idx] = true
+	}
+	if len(readPool) > 0 && len(delayed)+len(readPool) > int(s.Replicas)-2 {
+		allErrs = append(allErrs, field.Invalid(pp, len(readPool), "at least two instances must take part in the semi-synchronous replication"))
+	}
+
+	if s.SemiSync != nil && s.SemiSync.WaitForReplicaCount != nil {
+		pp = p.Child("semiSync", "waitForReplicaCount")
+		semiSyncReplicas := int(s.Replicas) - 1 - len(delayed) - len(readPool)
+		if int(*s.SemiSync.WaitForReplicaCount) > semiSyncReplicas {
+			allErrs = append(allErrs, field.Invalid(pp, *s.SemiSync.WaitForReplicaCount, fmt.Sprintf("must not exceed the number of semi-synchronous replicas (%d)", semiSyncReplicas)))
+		}
+	}
+
+	pp = p.Child("fencingStrategies")
+	fencing := make(map[FencingStrategy]bool)
+	for i, fs := range s.FencingStrategies {
+		if fencing[fs] {
+			allErrs = append(allErrs, field.Duplicate(pp.Index(i), fs))
+		}
+		fencing[fs] = true
+	}
+
+	if c := s.Clustering; c != nil {
+		pp = p.Child("clustering")
+		if c.ReadOnlyTimeoutSeconds != nil {
+			preStopSeconds, _ := strconv.Atoi(constants.PreStopSeconds)
+			if int(*c.ReadOnlyTimeoutSeconds) >= preStopSeconds {
+				allErrs = append(allErrs, field.Invalid(pp.Child("readOnlyTimeoutSeconds"), *c.ReadOnlyTimeoutSeconds, fmt.Sprintf("must be less than %d", preStopSeconds)))
+			}
+		}
+		if c.RoleChangeWait != nil && (c.RoleChangeWait.Duration <= 0 || c.RoleChangeWait.Duration > time.Minute) {
+			allErrs = append(allErrs, field.Invalid(pp.Child("roleChangeWait"), c.RoleChangeWait.Duration.String(), "must be positive and not longer than 1m"))
+		}
+		if c.CloneRestartWait != nil && (c.CloneRestartWait.Duration <= 0 || c.CloneRestartWait.Duration > time.Minute) {
+			allErrs = append(allErrs, field.Invalid(pp.Child("cloneRestartWait"), c.CloneRestartWait.Duration.String(), "must be positive and not longer than 1m"))
+		}
+		if c.CheckInterval != nil && c.CheckInterval.Duration < time.Second {
+			allErrs = append(allErrs, field.Invalid(pp.Child("checkInterval"), c.CheckInterval.Duration.String(), "must not be shorter than 1s"))
+		}
+		if d := c.ConnectionDrain; d != nil {
+			ppp := pp.Child("connectionDrain")
+			if d.GracePeriod != nil {
+				// the draining and making the primary read-only should finish before the switchover on Pod deletion times out.
+				preStopSeconds, _ := strconv.Atoi(constants.PreStopSeconds)
+				readOnlyTimeout := preStopSeconds / 2
+				if c.ReadOnlyTimeoutSeconds != nil {
+					readOnlyTimeout = int(*c.ReadOnlyTimeoutSeconds)
+				}
+				limit := time.Duration(preStopSeconds-readOnlyTimeout) * time.Second
+				if d.GracePeriod.Duration <= 0 || d.GracePeriod.Duration >= limit {
+					allErrs = append(allErrs, field.Invalid(ppp.Child("gracePeriod"), d.GracePeriod.Duration.String(), fmt.Sprintf("must be positive and shorter than %s", limit)))
+				}
+			}
+			protected := make(map[string]bool)
+			for _, u := range d.ProtectedUsers {
+				protected[u] = true
+			}
+			for i, u := range d.KillFirstUsers {
+				if protected[u] {
+					allErrs = append(allErrs, field.Invalid(ppp.Child("killFirstUsers").Index(i), u, "must not be in protectedUsers"))
+				}
+			}
+		}
+		if sc := c.SwitchoverCheck; sc != nil {
+			ppp := pp.Child("switchoverCheck")
+			if sc.MaxTransactionAge != nil && sc.MaxTransactionAge.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(ppp.Child("maxTransactionAge"), sc.MaxTransactionAge.Duration.String(), "must be positive"))
+			}
+			if sc.MaxReplicationLag != nil && sc.MaxReplicationLag.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(ppp.Child("maxReplicationLag"), sc.MaxReplicationLag.Duration.String(), "must be positive"))
+			}
+		}
+		if l := c.RoleChangeLimit; l != nil {
+			ppp := pp.Child("roleChangeLimit")
+			if l.Window != nil && l.Window.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(ppp.Child("window"), l.Window.Duration.String(), "must be positive"))
+			}
+			if l.MinFailoverInterval != nil && l.MinFailoverInterval.Duration <= 0 {
+				allErrs = append(allErrs, field.Invalid(ppp.Child("minFailoverInterval"), l.MinFailoverInterval.Duration.String(), "must be positive"))
+			}
+		}
+	}
+
+	if h := s.SwitchoverHooks; h != nil {
+		for _, phase := range []struct {
+			name  string
+			hooks []SwitchoverHook
+		}{{"pre", h.Pre}, {"post", h.Post}} {
+			pp = p.Child("switchoverHooks", phase.name)
+			hooks := phase.hooks
+			names := make(map[string]bool)
+			for i, hook := range hooks {
+				if names[hook.Name] {
+					allErrs = append(allErrs, field.Duplicate(pp.Index(i).Child("name"), hook.Name))
+				}
+				names[hook.Name] = true
+				if (hook.HTTP == nil) == (hook.Job == nil) {
+					allErrs = append(allErrs, field.Invalid(pp.Index(i), hook.Name, "exactly one of http or job must be specified"))
+				}
+			}
+		}
+
+		// the pre hooks, making the primary read-only, and draining connections should finish
+		// before the switchover on Pod deletion times out.
+		if len(h.Pre) > 0 {
+			var total time.Duration
+			for _, hook := range h.Pre {
+				timeout := hook.TimeoutSeconds
+				if timeout == 0 {
+					timeout = DefaultHookTimeoutSeconds
+				}
+				total += time.Duration(timeout) * time.Second
+			}
+			if budget := s.preSwitchoverHookBudget(); total >= budget {
+				allErrs = append(allErrs, field.Invalid(p.Child("switchoverHooks", "pre"), total.String(),
+					fmt.Sprintf("the total timeout must be shorter than %s, that is %ss minus the read-only timeout and the drain grace period", budget, constants.PreStopSeconds)))
+			}
+		}
+	}
+
+	if s.ReplicationMode == ReplicationModeGroupReplication {
+		pp = p.Child("replicationMode")
+		if s.Replicas > MaxGroupReplicationMembers {
+			allErrs = append(allErrs, field.Invalid(p.Child("replicas"), s.Replicas, fmt.Sprintf("must not exceed %d in GroupReplication mode", MaxGroupReplicationMembers)))
+		}
+		if s.ReplicationSourceSecretName != nil || s.ReplicationSource != nil {
+			allErrs = append(allErrs, field.Forbidden(pp, "GroupReplication mode cannot replicate data from an external source"))
+		}
+		if len(s.DelayedReplicas) > 0 {
+			allErrs = append(allErrs, field.Forbidden(p.Child("delayedReplicas"), "not supported in GroupReplication mode"))
+		}
+		if len(s.ReadPoolReplicas) > 0 {
+			allErrs = append(allErrs, field.Forbidden(p.Child("readPoolReplicas"), "not supported in GroupReplication mode"))
+		}
+		if s.SemiSync != nil {
+			allErrs = append(allErrs, field.Forbidden(p.Child("semiSync"), "not supported in GroupReplication mode"))
+		}
+		if s.FailoverPolicy == FailoverPolicyManual {
+			allErrs = append(allErrs, field.Forbidden(p.Child("failoverPolicy"), "the group elects the new primary automatically in GroupReplication mode"))
+		}
+	}
+
+	for i, w := range s.MaintenanceWindows {
+		pp = p.Child("maintenanceWindows").Index(i)
+		if _, err := cron.ParseStandard(w.Schedule); err != nil {
+			allErrs = append(allErrs, field.Invalid(pp.Child("schedule"), w.Schedule, err.Error()))
+		}
+		if w.Duration.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(pp.Child("duration"), w.Duration.Duration.String(), "must be positive"))
+		}
+	}
+
 	p = p.Child("podTemplate", "spec")
 
 	pp = p.Child("containers")
@@ -283,10 +963,6 @@ func (s MySQLClusterSpec) validateUpdate(ctx context.Context, apiReader client.R
 	var allErrs field.ErrorList
 	p := field.NewPath("spec")
 
-	if s.Replicas < old.Replicas {
-		p := p.Child("replicas")
-		allErrs = append(allErrs, field.Forbidden(p, "decreasing replicas is not supported yet"))
-	}
 	if s.ReplicationSourceSecretName != nil {
 		p := p.Child("replicationSourceSecretName")
 		if old.ReplicationSourceSecretName == nil {
@@ -295,6 +971,17 @@ func (s MySQLClusterSpec) validateUpdate(ctx context.Context, apiReader client.R
 			allErrs = append(allErrs, field.Forbidden(p, "replication source secret name cannot be modified"))
 		}
 	}
+	if s.ReplicationSource != nil {
+		p := p.Child("replicationSource")
+		if old.ReplicationSource == nil {
+			allErrs = append(allErrs, field.Forbidden(p, "replication can be initiated only with new clusters"))
+		} else if !equality.Semantic.DeepEqual(s.ReplicationSource, old.ReplicationSource) {
+			allErrs = append(allErrs, field.Forbidden(p, "replication source cannot be modified"))
+		}
+	}
+	if replicationMode(s.ReplicationMode) != replicationMode(old.ReplicationMode) {
+		allErrs = append(allErrs, field.Forbidden(p.Child("replicationMode"), "not editable"))
+	}
 	if !equality.Semantic.DeepEqual(s.Restore, old.Restore) {
 		p := p.Child("restore")
 		allErrs = append(allErrs, field.Forbidden(p, "not editable"))
@@ -333,6 +1020,62 @@ func (s MySQLClusterSpec) validateUpdate(ctx context.Context, apiReader client.R
 	return warns, append(allErrs, errs...)
 }
 
+// validatePromotable validates that a replica that is not delayed can be promoted
+// when the instance `primary` is the primary.
+func (s MySQLClusterSpec) validatePromotable(primary int) field.ErrorList {
+	if len(s.DelayedReplicas) == 0 {
+		return nil
+	}
+	for i := 0; i < int(s.Replicas); i++ {
+		if i != primary && s.isPromotable(i) {
+			return nil
+		}
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "delayedReplicas"), len(s.DelayedReplicas),
+		fmt.Sprintf("at least one replica other than the primary instance %d must be promotable and not delayed", primary))}
+}
+
+// preSwitchoverHookBudget returns the time left for the pre-switchover hooks before the primary
+// Pod being deleted is killed, that is, PreStopSeconds minus the timeout to make the primary
+// read-only and the grace period of the connection drain.
+func (s MySQLClusterSpec) preSwitchoverHookBudget() time.Duration {
+	preStopSeconds, _ := strconv.Atoi(constants.PreStopSeconds)
+	readOnlyTimeout := time.Duration(preStopSeconds/2) * time.Second
+	var gracePeriod time.Duration
+	if c := s.Clustering; c != nil {
+		if c.ReadOnlyTimeoutSeconds != nil {
+			readOnlyTimeout = time.Duration(*c.ReadOnlyTimeoutSeconds) * time.Second
+		}
+		if c.ConnectionDrain != nil && c.ConnectionDrain.GracePeriod != nil {
+			gracePeriod = c.ConnectionDrain.GracePeriod.Duration
+		}
+	}
+	return time.Duration(preStopSeconds)*time.Second - readOnlyTimeout - gracePeriod
+}
+
+func (s MySQLClusterSpec) isPromotable(index int) bool {
+	for _, d := range s.DelayedReplicas {
+		if int(d.Index) == index && d.DelaySeconds > 0 {
+			return false
+		}
+	}
+	if slices.Contains(s.ReadPoolReplicas, int32(index)) {
+		return false
+	}
+	if s.Promotion != nil && slices.Contains(s.Promotion.NeverPromote, int32(index)) {
+		return false
+	}
+	return true
+}
+
+// replicationMode returns the replication mode taking the default into account.
+func replicationMode(m ReplicationMode) ReplicationMode {
+	if m == "" {
+		return ReplicationModeSemiSync
+	}
+	return m
+}
+
 func (s MySQLClusterSpec) validateVolumeExpansionSupported(ctx context.Context, apiReader client.Reader, targetIndices []int) field.ErrorList {
 	var allErrs field.ErrorList
 	p := field.NewPath("spec").Child("volumeClaimTemplates")
@@ -662,6 +1405,30 @@ type MySQLClusterStatus struct {
 	// Initially, this is zero.
 	CurrentPrimaryIndex int `json:"currentPrimaryIndex"`
 
+	// State is the state of the cluster decided by MOCO, such as Healthy, Degraded, or Failed.
+	// Consult docs/clustering.md for the list of the states.
+	// +optional
+	State string `json:"state,omitempty"`
+
+	// StateHistory is the list of the recent transitions of `state`.
+	// Only the latest 10 records are kept.
+	// +optional
+	StateHistory []ClusterStateRecord `json:"stateHistory,omitempty"`
+
+	// SwitchoverHooks is the pre-switchover hooks called for the ongoing switchover.
+	// +optional
+	SwitchoverHooks *SwitchoverHooksStatus `json:"switchoverHooks,omitempty"`
+
+	// FencedPrimary is the old primary fenced for the ongoing failover.
+	// +optional
+	FencedPrimary *FencedPrimaryStatus `json:"fencedPrimary,omitempty"`
+
+	// RoleChangeHistory is the list of the recent switchovers and failovers.
+	// Only the latest 10 records are kept.  The records are cleared when
+	// an operator acknowledges the hold of the role changes.
+	// +optional
+	RoleChangeHistory []RoleChangeRecord `json:"roleChangeHistory,omitempty"`
+
 	// SyncedReplicas is the number of synced instances including the primary.
 	// +optional
 	SyncedReplicas int `json:"syncedReplicas,omitempty"`
@@ -674,6 +1441,15 @@ type MySQLClusterStatus struct {
 	// +optional
 	ErrantReplicaList []int `json:"errantReplicaList,omitempty"`
 
+	// ErrantReplicaHistory is the list of errant transactions discarded by re-cloning errant replicas.
+	// Only the latest record is kept for each instance.
+	// +optional
+	ErrantReplicaHistory []ErrantReplicaRecord `json:"errantReplicaHistory,omitempty"`
+
+	// Instances is the list of the status of mysqld instances observed by MOCO.
+	// +optional
+	Instances []InstanceStatus `json:"instances,omitempty"`
+
 	// Backup is the status of the last successful backup.
 	// +optional
 	Backup BackupStatus `json:"backup"`
@@ -686,21 +1462,287 @@ type MySQLClusterStatus struct {
 	// +optional
 	Cloned bool `json:"cloned,omitempty"`
 
+	// Promotion is the progress of the planned promotion of the intermediate primary.
+	// +optional
+	Promotion *PromotionStatus `json:"promotion,omitempty"`
+
+	// Plan is what MOCO is going to do for the cluster based on the latest status of the instances.
+	// +optional
+	Plan *OperationPlan `json:"plan,omitempty"`
+
 	// ReconcileInfo represents version information for reconciler.
 	// +optional
 	ReconcileInfo ReconcileInfo `json:"reconcileInfo"`
 }
 
 const (
-	ConditionInitialized          string = "Initialized"
-	ConditionAvailable            string = "Available"
-	ConditionHealthy              string = "Healthy"
-	ConditionStatefulSetReady     string = "StatefulSetReady"
-	ConditionReconcileSuccess     string = "ReconcileSuccess"
-	ConditionReconciliationActive string = "ReconciliationActive"
-	ConditionClusteringActive     string = "ClusteringActive"
+	ConditionInitialized                 string = "Initialized"
+	ConditionAvailable                   string = "Available"
+	ConditionHealthy                     string = "Healthy"
+	ConditionStatefulSetReady            string = "StatefulSetReady"
+	ConditionReconcileSuccess            string = "ReconcileSuccess"
+	ConditionReconciliationActive        string = "ReconciliationActive"
+	ConditionClusteringActive            string = "ClusteringActive"
+	ConditionFailoverPending             string = "FailoverPending"
+	ConditionScaleInReady                string = "ScaleInReady"
+	ConditionWaitingForMaintenanceWindow string = "WaitingForMaintenanceWindow"
+	ConditionSwitchoverPostponed         string = "SwitchoverPostponed"
+	ConditionSplitBrain                  string = "SplitBrain"
+	ConditionRoleChangeHeld              string = "RoleChangeHeld"
+	ConditionRollingUpdateInterrupted    string = "RollingUpdateInterrupted"
 )
 
+// ErrantReplicaRecord is a record of an errant replica that was re-cloned.
+type ErrantReplicaRecord struct {
+	// Index is the ordinal of the instance.
+	Index int `json:"index"`
+
+	// ErrantGTIDSet is the GTID set of the discarded errant transactions.
+	ErrantGTIDSet string `json:"errantGTIDSet"`
+
+	// Time is the time when the instance was reset to be re-cloned.
+	Time metav1.Time `json:"time"`
+}
+
+// InstanceStatus is the status of a mysqld instance observed by MOCO.
+type InstanceStatus struct {
+	// Index is the ordinal of the instance.
+	Index int `json:"index"`
+
+	// Reachable indicates if MOCO could get the status of the instance.
+	// The other fields except `index` are not set if this is false.
+	Reachable bool `json:"reachable"`
+
+	// Role is the role of the instance, either "primary" or "replica".
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// ServerUUID is the value of `server_uuid`.
+	// +optional
+	ServerUUID string `json:"serverUUID,omitempty"`
+
+	// ExecutedGTIDSet is the value of `gtid_executed`.
+	// +optional
+	ExecutedGTIDSet string `json:"executedGTIDSet,omitempty"`
+
+	// ReadOnly is the value of `read_only`.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// SuperReadOnly is the value of `super_read_only`.
+	// +optional
+	SuperReadOnly bool `json:"superReadOnly,omitempty"`
+
+	// Errant indicates if the instance has errant transactions.
+	// +optional
+	Errant bool `json:"errant,omitempty"`
+
+	// SemiSyncSourceEnabled is the value of `rpl_semi_sync_master_enabled`.
+	// +optional
+	SemiSyncSourceEnabled bool `json:"semiSyncSourceEnabled,omitempty"`
+
+	// SemiSyncReplicaEnabled is the value of `rpl_semi_sync_slave_enabled`.
+	// +optional
+	SemiSyncReplicaEnabled bool `json:"semiSyncReplicaEnabled,omitempty"`
+
+	// Replication is the status of the replication.  This is not set if the instance is not a replica.
+	// +optional
+	Replication *InstanceReplicationStatus `json:"replication,omitempty"`
+
+	// CloneState is the state of the last clone operation, e.g. "In Progress" or "Completed".
+	// +optional
+	CloneState string `json:"cloneState,omitempty"`
+
+	// GroupMemberState is the state of the instance as a member of the group, e.g. "ONLINE" or "RECOVERING".
+	// This is set only in GroupReplication mode.
+	// +optional
+	GroupMemberState string `json:"groupMemberState,omitempty"`
+}
+
+// InstanceReplicationStatus is the replication status of a mysqld instance
+// taken from `SHOW REPLICA STATUS`.
+type InstanceReplicationStatus struct {
+	// SourceHost is the host name of the replication source.
+	SourceHost string `json:"sourceHost"`
+
+	// IOThreadRunning is the value of `Replica_IO_Running`.
+	IOThreadRunning string `json:"ioThreadRunning"`
+
+	// SQLThreadRunning is the value of `Replica_SQL_Running`.
+	SQLThreadRunning string `json:"sqlThreadRunning"`
+
+	// SecondsBehindSource is the value of `Seconds_Behind_Source`.
+	// This is not set if the value is NULL.
+	// +optional
+	SecondsBehindSource *int64 `json:"secondsBehindSource,omitempty"`
+
+	// LastIOErrno is the value of `Last_IO_Errno`.
+	// +optional
+	LastIOErrno int `json:"lastIOErrno,omitempty"`
+
+	// LastIOError is the value of `Last_IO_Error`.
+	// +optional
+	LastIOError string `json:"lastIOError,omitempty"`
+
+	// LastSQLErrno is the value of `Last_SQL_Errno`.
+	// +optional
+	LastSQLErrno int `json:"lastSQLErrno,omitempty"`
+
+	// LastSQLError is the value of `Last_SQL_Error`.
+	// +optional
+	LastSQLError string `json:"lastSQLError,omitempty"`
+}
+
+// ClusterStateRecord is a record of a transition of the cluster state.
+type ClusterStateRecord struct {
+	// State is the state the cluster has entered.
+	State string `json:"state"`
+
+	// Time is the time when the cluster entered the state.
+	Time metav1.Time `json:"time"`
+}
+
+// SwitchoverHooksStatus records the pre-switchover hooks called for an ongoing switchover
+// so that they are not called again when the switchover is retried.
+type SwitchoverHooksStatus struct {
+	// Primary is the index of the primary to be switched over.
+	Primary int `json:"primary"`
+
+	// NextPrimary is the index of the new primary.
+	NextPrimary int `json:"nextPrimary"`
+
+	// CalledPreHooks is the list of the names of the pre hooks that have been called.
+	// +optional
+	CalledPreHooks []string `json:"calledPreHooks,omitempty"`
+}
+
+// FencedPrimaryStatus records the old primary fenced for an ongoing failover
+// so that it is not fenced again when the failover is retried.
+// This is cleared when the failover completes or the cluster is no longer failed.
+type FencedPrimaryStatus struct {
+	// Index is the index of the fenced old primary.
+	Index int `json:"index"`
+
+	// Generation is `metadata.generation` of MySQLCluster when the old primary was fenced.
+	// The old primary is fenced again if the spec has been changed since then.
+	Generation int64 `json:"generation"`
+
+	// Time is the time when the old primary was fenced.
+	Time metav1.Time `json:"time"`
+}
+
+// RoleChangeType is the type of a role change.
+type RoleChangeType string
+
+const (
+	RoleChangeSwitchover = RoleChangeType("Switchover")
+	RoleChangeFailover   = RoleChangeType("Failover")
+)
+
+// RoleChangeRecord is a record of a change of the primary instance.
+type RoleChangeRecord struct {
+	// Type is either Switchover or Failover.
+	Type RoleChangeType `json:"type"`
+
+	// From is the index of the old primary.
+	From int `json:"from"`
+
+	// To is the index of the new primary.
+	To int `json:"to"`
+
+	// Manual is true if the switchover was requested by `moco.cybozu.com/demote` annotation
+	// or the failover was approved by `moco.cybozu.com/approve-failover` annotation.
+	// Manual role changes are not counted by the role change limit.
+	// +optional
+	Manual bool `json:"manual,omitempty"`
+
+	// Time is the time when the primary was changed.
+	Time metav1.Time `json:"time"`
+}
+
+// OperationPlan is the list of operations MOCO is going to perform for the cluster.
+// It is decided without side effects and is useful to know what MOCO would do
+// for a degraded or failed cluster.
+type OperationPlan struct {
+	// State is the state of the cluster decided by MOCO.
+	State string `json:"state"`
+
+	// Primary is the index of the current primary instance.
+	Primary int `json:"primary"`
+
+	// Candidates is the list of the indices of the replicas that can be promoted by a switchover.
+	// +optional
+	Candidates []int `json:"candidates,omitempty"`
+
+	// Errants is the list of the indices of the replicas that have errant transactions.
+	// +optional
+	Errants []int `json:"errants,omitempty"`
+
+	// NextPrimary is the index of the instance to be promoted by a switchover or a failover.
+	// +optional
+	NextPrimary *int `json:"nextPrimary,omitempty"`
+
+	// Steps is the list of the operations in the order MOCO performs them.
+	// +optional
+	Steps []string `json:"steps,omitempty"`
+
+	// Time is the time when the plan was changed.
+	Time metav1.Time `json:"time"`
+}
+
+// PromotionPhase is the phase of the planned promotion of an intermediate primary.
+// +kubebuilder:validation:Enum=FencingSource;CatchingUp;Detaching;Completed;Cancelled
+type PromotionPhase string
+
+const (
+	// PromotionPhaseFencingSource is the phase to make the replication source read-only.
+	PromotionPhaseFencingSource PromotionPhase = "FencingSource"
+	// PromotionPhaseCatchingUp is the phase to execute all the retrieved transactions.
+	PromotionPhaseCatchingUp PromotionPhase = "CatchingUp"
+	// PromotionPhaseDetaching is the phase to stop the replication and make the cluster writable.
+	PromotionPhaseDetaching PromotionPhase = "Detaching"
+	// PromotionPhaseCompleted means the promotion has been completed.
+	PromotionPhaseCompleted PromotionPhase = "Completed"
+	// PromotionPhaseCancelled means the promotion was cancelled by removing `moco.cybozu.com/promote` annotation.
+	PromotionPhaseCancelled PromotionPhase = "Cancelled"
+)
+
+// PromotionStatus represents the progress of the planned promotion of an intermediate primary.
+type PromotionStatus struct {
+	// Phase is the current phase of the promotion.
+	Phase PromotionPhase `json:"phase"`
+
+	// StartTime is the time when the promotion was started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is the time when the promotion was completed or cancelled.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// StoppedSourceCluster is the source cluster whose clustering MOCO has stopped
+	// by `moco.cybozu.com/clustering-stopped` annotation.
+	// MOCO removes the annotation when the promotion is completed or cancelled.
+	// +optional
+	StoppedSourceCluster *ClusterReference `json:"stoppedSourceCluster,omitempty"`
+
+	// History is the list of the phases the promotion has entered.
+	// +optional
+	History []PromotionPhaseRecord `json:"history,omitempty"`
+}
+
+// PromotionPhaseRecord is a record of a phase of the promotion.
+type PromotionPhaseRecord struct {
+	// Phase is the phase entered.
+	Phase PromotionPhase `json:"phase"`
+
+	// Time is the time when the phase was entered.
+	Time metav1.Time `json:"time"`
+
+	// Message describes what has been done before entering the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
 // BackupStatus represents the status of the last successful backup.
 type BackupStatus struct {
 	// The time of the backup.  This is used to generate object keys of backup files in a bucket.
@@ -756,6 +1798,7 @@ type ReconcileInfo struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:storageversion
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
 // +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type=='Available')].status"
 // +kubebuilder:printcolumn:name="Healthy",type="string",JSONPath=".status.conditions[?(@.type=='Healthy')].status"
 // +kubebuilder:printcolumn:name="Primary",type="integer",JSONPath=".status.currentPrimaryIndex"
@@ -861,6 +1904,74 @@ func (r *MySQLCluster) RestoreRoleName() string {
 	return fmt.Sprintf("moco-restore-%s", r.Name)
 }
 
+// FencingNetworkPolicyName returns the name of the NetworkPolicy to fence the instance.
+func (r *MySQLCluster) FencingNetworkPolicyName(index int) string {
+	return fmt.Sprintf("%s-fence", r.PodName(index))
+}
+
+// HasReplicationSource returns true if the cluster replicates data from an external source,
+// i.e. the primary instance is an intermediate primary.
+func (r *MySQLCluster) HasReplicationSource() bool {
+	return r.Spec.ReplicationSourceSecretName != nil || r.Spec.ReplicationSource != nil
+}
+
+// IsGroupReplication returns true if the cluster is in GroupReplication mode.
+func (r *MySQLCluster) IsGroupReplication() bool {
+	return r.Spec.ReplicationMode == ReplicationModeGroupReplication
+}
+
+// IsPromotable returns false if the spec forbids the instance to be promoted to the primary,
+// that is, the instance is a delayed replica, a read pool replica, or listed in `spec.promotion.neverPromote`.
+// `moco.cybozu.com/never-promote` annotation of the Pod is not considered.
+func (r *MySQLCluster) IsPromotable(index int) bool {
+	return r.Spec.isPromotable(index)
+}
+
+// PreSwitchoverHookBudget returns the time left for the pre-switchover hooks in the switchover
+// of the primary Pod being deleted.  The webhook rejects the pre hooks whose total timeout exceeds this.
+func (r *MySQLCluster) PreSwitchoverHookBudget() time.Duration {
+	return r.Spec.preSwitchoverHookBudget()
+}
+
+// InMaintenanceWindow returns true if `now` is in one of the maintenance windows
+// or no window is configured.  Otherwise, it also returns the time when the next window opens.
+func (r *MySQLCluster) InMaintenanceWindow(now time.Time) (bool, time.Time) {
+	if len(r.Spec.MaintenanceWindows) == 0 {
+		return true, time.Time{}
+	}
+
+	now = now.UTC()
+	var next time.Time
+	for _, w := range r.Spec.MaintenanceWindows {
+		sched, err := cron.ParseStandard(w.Schedule)
+		if err != nil {
+			continue
+		}
+		// the first start after `now - duration` is in the window if it is not after `now`.
+		if start := sched.Next(now.Add(-w.Duration.Duration)); !start.IsZero() && !start.After(now) {
+			return true, time.Time{}
+		}
+		if t := sched.Next(now); !t.IsZero() && (next.IsZero() || t.Before(next)) {
+			next = t
+		}
+	}
+	return false, next
+}
+
+// ReplicationSourceCluster returns the namespaced name of the source MySQLCluster.
+// It returns false if `spec.replicationSource` is not set.
+func (r *MySQLCluster) ReplicationSourceCluster() (types.NamespacedName, bool) {
+	if r.Spec.ReplicationSource == nil {
+		return types.NamespacedName{}, false
+	}
+	ref := r.Spec.ReplicationSource.ClusterRef
+	ns := ref.Namespace
+	if ns == "" {
+		ns = r.Namespace
+	}
+	return types.NamespacedName{Namespace: ns, Name: ref.Name}, true
+}
+
 //+kubebuilder:object:root=true
 
 // MySQLClusterList contains a list of MySQLCluster
@@ -9,6 +9,7 @@ import (
 	"github.com/cybozu-go/moco/pkg/constants"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -66,6 +67,9 @@ func (a *mySQLClusterAdmission) ValidateCreate(ctx context.Context, cluster *MyS
 
 	warns, createErrs := cluster.Spec.validateCreate()
 	errs = append(errs, createErrs...)
+	errs = append(errs, cluster.Spec.validatePromotable(cluster.Status.CurrentPrimaryIndex)...)
+	errs = append(errs, cluster.validateReplicationSource()...)
+	errs = append(errs, cluster.validateJobHooks()...)
 	if len(errs) == 0 {
 		return warns, nil
 	}
@@ -75,6 +79,10 @@ func (a *mySQLClusterAdmission) ValidateCreate(ctx context.Context, cluster *MyS
 
 func (a *mySQLClusterAdmission) ValidateUpdate(ctx context.Context, oldCluster, newCluster *MySQLCluster) (admission.Warnings, error) {
 	warns, errs := newCluster.Spec.validateUpdate(ctx, a.client, oldCluster.Spec)
+	// the status is not updated through this webhook, so the old one is the current.
+	errs = append(errs, newCluster.Spec.validatePromotable(oldCluster.Status.CurrentPrimaryIndex)...)
+	errs = append(errs, newCluster.validateReplicationSource()...)
+	errs = append(errs, newCluster.validateJobHooks()...)
 	if len(errs) == 0 {
 		return warns, nil
 	}
@@ -82,6 +90,37 @@ func (a *mySQLClusterAdmission) ValidateUpdate(ctx context.Context, oldCluster,
 	return warns, apierrors.NewInvalid(schema.GroupKind{Group: GroupVersion.Group, Kind: "MySQLCluster"}, newCluster.Name, errs)
 }
 
+// validateReplicationSource validates that the cluster does not replicate data from itself.
+func (r *MySQLCluster) validateReplicationSource() field.ErrorList {
+	src, ok := r.ReplicationSourceCluster()
+	if !ok || src != (types.NamespacedName{Namespace: r.Namespace, Name: r.Name}) {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "replicationSource", "clusterRef"), src.String(), "must not refer to the cluster itself")}
+}
+
+// validateJobHooks validates that the Job hooks do not run with the ServiceAccount of the mysqld Pods.
+func (r *MySQLCluster) validateJobHooks() field.ErrorList {
+	h := r.Spec.SwitchoverHooks
+	if h == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+	for _, phase := range []struct {
+		name  string
+		hooks []SwitchoverHook
+	}{{"pre", h.Pre}, {"post", h.Post}} {
+		for i, hook := range phase.hooks {
+			if hook.Job != nil && hook.Job.ServiceAccountName == r.PrefixedName() {
+				errs = append(errs, field.Invalid(field.NewPath("spec", "switchoverHooks", phase.name).Index(i).Child("job", "serviceAccountName"),
+					hook.Job.ServiceAccountName, "must not be the ServiceAccount of the mysqld Pods"))
+			}
+		}
+	}
+	return errs
+}
+
 func (a *mySQLClusterAdmission) ValidateDelete(ctx context.Context, _ *MySQLCluster) (admission.Warnings, error) {
 	return nil, nil
 }
@@ -2,6 +2,7 @@ package v1beta2_test
 
 import (
 	"context"
+	"time"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
 	"github.com/cybozu-go/moco/pkg/constants"
@@ -294,7 +295,7 @@ var _ = Describe("MySQLCluster Webhook", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
-	It("should deny decreasing replicas", func() {
+	It("should allow decreasing replicas", func() {
 		r := makeMySQLCluster()
 		r.Spec.Replicas = 3
 		err := k8sClient.Create(ctx, r)
@@ -302,6 +303,18 @@ var _ = Describe("MySQLCluster Webhook", func() {
 
 		r.Spec.Replicas = 1
 		err = k8sClient.Update(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny decreasing replicas below the delayed replicas", func() {
+		r := makeMySQLCluster()
+		r.Spec.Replicas = 5
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 4, DelaySeconds: 3600}}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Spec.Replicas = 3
+		err = k8sClient.Update(ctx, r)
 		Expect(err).To(HaveOccurred())
 	})
 
@@ -316,6 +329,100 @@ var _ = Describe("MySQLCluster Webhook", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("should deny invalid neverPromote", func() {
+		r := makeMySQLCluster()
+		r.Spec.Replicas = 3
+		r.Spec.Promotion = &mocov1beta2.PromotionSpec{NeverPromote: []int32{3}}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Promotion.NeverPromote = []int32{0, 1, 2}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Promotion.NeverPromote = []int32{2}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny invalid delayedReplicas", func() {
+		r := makeMySQLCluster()
+		r.Spec.Replicas = 3
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 3, DelaySeconds: 3600}}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 1, DelaySeconds: 3600}, {Index: 2, DelaySeconds: 3600}}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 0}}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 3600}}
+		r.Spec.Promotion = &mocov1beta2.PromotionSpec{NeverPromote: []int32{1}}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+		r.Spec.Promotion = nil
+
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 3600}}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny delayedReplicas leaving no promotable replica other than the current primary", func() {
+		r := makeMySQLCluster()
+		r.Spec.Replicas = 3
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 3600}}
+		r.Spec.Promotion = &mocov1beta2.PromotionSpec{NeverPromote: []int32{0}}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Status.CurrentPrimaryIndex = 1
+		err = k8sClient.Status().Update(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Spec.MaxDelaySeconds = new(0)
+		err = k8sClient.Update(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Promotion = nil
+		err = k8sClient.Update(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny invalid readPoolReplicas and semiSync", func() {
+		r := makeMySQLCluster()
+		r.Spec.Replicas = 5
+		r.Spec.ReadPoolReplicas = []int32{5}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReadPoolReplicas = []int32{2, 3, 4}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReadPoolReplicas = []int32{4}
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 4, DelaySeconds: 3600}}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReadPoolReplicas = []int32{4}
+		r.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 3, DelaySeconds: 3600}}
+		r.Spec.SemiSync = &mocov1beta2.SemiSyncSpec{WaitForReplicaCount: new(int32(3))}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SemiSync = &mocov1beta2.SemiSyncSpec{TimeoutSeconds: new(int32(0))}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SemiSync = &mocov1beta2.SemiSyncSpec{WaitForReplicaCount: new(int32(2)), TimeoutSeconds: new(int32(10))}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 	It("should deny adding replication source secret", func() {
 		r := makeMySQLCluster()
 		err := k8sClient.Create(ctx, r)
@@ -337,6 +444,347 @@ var _ = Describe("MySQLCluster Webhook", func() {
 		Expect(err).To(HaveOccurred())
 	})
 
+	It("should deny replication source with replication source secret", func() {
+		r := makeMySQLCluster()
+		r.Spec.ReplicationSourceSecretName = new("foo")
+		r.Spec.ReplicationSource = &mocov1beta2.ReplicationSourceSpec{
+			ClusterRef: mocov1beta2.ClusterReference{Name: "source"},
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should deny replication source referring to the cluster itself", func() {
+		r := makeMySQLCluster()
+		r.Spec.ReplicationSource = &mocov1beta2.ReplicationSourceSpec{
+			ClusterRef: mocov1beta2.ClusterReference{Name: r.Name},
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReplicationSource.ClusterRef.Namespace = r.Namespace
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReplicationSource.ClusterRef.Namespace = "other"
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny adding replication source", func() {
+		r := makeMySQLCluster()
+		err := k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Spec.ReplicationSource = &mocov1beta2.ReplicationSourceSpec{
+			ClusterRef: mocov1beta2.ClusterReference{Name: "source"},
+		}
+		err = k8sClient.Update(ctx, r)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should deny changing replication source but allow removing it", func() {
+		r := makeMySQLCluster()
+		r.Spec.ReplicationSource = &mocov1beta2.ReplicationSourceSpec{
+			ClusterRef: mocov1beta2.ClusterReference{Name: "source"},
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Spec.ReplicationSource.ClusterRef.Namespace = "other"
+		err = k8sClient.Update(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.ReplicationSource = nil
+		err = k8sClient.Update(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should deny duplicated fencing strategies", func() {
+		r := makeMySQLCluster()
+		r.Spec.FencingStrategies = []mocov1beta2.FencingStrategy{
+			mocov1beta2.FencingRemoveRoleLabel,
+			mocov1beta2.FencingNetworkPolicy,
+			mocov1beta2.FencingRemoveRoleLabel,
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.FencingStrategies = []mocov1beta2.FencingStrategy{
+			mocov1beta2.FencingNetworkPolicy,
+			mocov1beta2.FencingDeletePod,
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate clustering timeouts", func() {
+		r := makeMySQLCluster()
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			ReadOnlyTimeoutSeconds: new(int32(20)),
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			RoleChangeWait: &metav1.Duration{Duration: 2 * time.Minute},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			CloneRestartWait: &metav1.Duration{Duration: -time.Second},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			CheckInterval: &metav1.Duration{Duration: 500 * time.Millisecond},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			ReadOnlyTimeoutSeconds: new(int32(15)),
+			ConnectionDrain: &mocov1beta2.ConnectionDrainSpec{
+				GracePeriod: &metav1.Duration{Duration: 5 * time.Second},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			ConnectionDrain: &mocov1beta2.ConnectionDrainSpec{
+				ProtectedUsers: []string{"foo"},
+				KillFirstUsers: []string{"foo"},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			SwitchoverCheck: &mocov1beta2.SwitchoverCheckSpec{
+				MaxTransactionAge: &metav1.Duration{Duration: 0},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			SwitchoverCheck: &mocov1beta2.SwitchoverCheckSpec{
+				MaxReplicationLag: &metav1.Duration{Duration: -time.Second},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			SwitchoverCheck: &mocov1beta2.SwitchoverCheckSpec{
+				Policy: "Abort",
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			RoleChangeLimit: &mocov1beta2.RoleChangeLimitSpec{
+				MaxRoleChanges: 11,
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			RoleChangeLimit: &mocov1beta2.RoleChangeLimitSpec{
+				Window: &metav1.Duration{Duration: 0},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			RoleChangeLimit: &mocov1beta2.RoleChangeLimitSpec{
+				MinFailoverInterval: &metav1.Duration{Duration: -time.Minute},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			GTIDWaitTimeoutSeconds: new(int32(120)),
+			ReadOnlyTimeoutSeconds: new(int32(5)),
+			RoleChangeWait:         &metav1.Duration{Duration: time.Second},
+			CloneRestartWait:       &metav1.Duration{Duration: 10 * time.Second},
+			CheckInterval:          &metav1.Duration{Duration: 10 * time.Second},
+			AdaptiveCheckInterval:  true,
+			ConnectionDrain: &mocov1beta2.ConnectionDrainSpec{
+				GracePeriod:    &metav1.Duration{Duration: 10 * time.Second},
+				ProtectedUsers: []string{"foo"},
+				KillFirstUsers: []string{"bar"},
+			},
+			SwitchoverCheck: &mocov1beta2.SwitchoverCheckSpec{
+				Policy:            mocov1beta2.SwitchoverCheckWarn,
+				MaxTransactionAge: &metav1.Duration{Duration: time.Minute},
+				MaxReplicationLag: &metav1.Duration{Duration: 5 * time.Second},
+			},
+			FenceSplitBrain: true,
+			RoleChangeLimit: &mocov1beta2.RoleChangeLimitSpec{
+				MaxRoleChanges:      3,
+				Window:              &metav1.Duration{Duration: time.Hour},
+				MinFailoverInterval: &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate switchover hooks", func() {
+		r := makeMySQLCluster()
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{{Name: "none"}},
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{{
+				Name: "both",
+				HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"},
+				Job:  &mocov1beta2.JobHook{Image: "ghcr.io/cybozu/ubuntu:22.04"},
+			}},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Post: []mocov1beta2.SwitchoverHook{
+				{Name: "dup", HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"}},
+				{Name: "dup", HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"}},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{
+				{Name: "first", HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"}, TimeoutSeconds: 5},
+				{Name: "second", HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"}, TimeoutSeconds: 5},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			ReadOnlyTimeoutSeconds: new(int32(5)),
+			ConnectionDrain: &mocov1beta2.ConnectionDrainSpec{
+				GracePeriod: &metav1.Duration{Duration: 10 * time.Second},
+			},
+		}
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{
+				{Name: "pause", HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"}, TimeoutSeconds: 5},
+			},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+		r.Spec.Clustering = nil
+
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Post: []mocov1beta2.SwitchoverHook{{
+				Name: "mysqld",
+				Job:  &mocov1beta2.JobHook{Image: "ghcr.io/cybozu/ubuntu:22.04", ServiceAccountName: "moco-" + r.Name},
+			}},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{{
+				Name: "pause",
+				HTTP: &mocov1beta2.HTTPHook{URL: "http://example.com/"},
+			}},
+			Post: []mocov1beta2.SwitchoverHook{{
+				Name: "warm",
+				Job:  &mocov1beta2.JobHook{Image: "ghcr.io/cybozu/ubuntu:22.04", Command: []string{"true"}},
+			}},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(r.Spec.SwitchoverHooks.Pre[0].TimeoutSeconds).To(BeNumerically("==", 5))
+		Expect(r.Spec.SwitchoverHooks.Pre[0].FailurePolicy).To(Equal(mocov1beta2.HookFailureAbort))
+	})
+
+	It("should validate maintenance windows", func() {
+		r := makeMySQLCluster()
+		r.Spec.MaintenanceWindows = []mocov1beta2.MaintenanceWindow{
+			{Schedule: "hoge fuga", Duration: metav1.Duration{Duration: time.Hour}},
+		}
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.MaintenanceWindows = []mocov1beta2.MaintenanceWindow{
+			{Schedule: "0 3 * * *"},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r.Spec.MaintenanceWindows = []mocov1beta2.MaintenanceWindow{
+			{Schedule: "0 3 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+			{Schedule: "CRON_TZ=Asia/Tokyo 0 12 * * 6", Duration: metav1.Duration{Duration: time.Hour}},
+		}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should validate GroupReplication mode", func() {
+		r := makeMySQLCluster()
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		r.Spec.Replicas = 11
+		err := k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r = makeMySQLCluster()
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		r.Spec.ReplicationSourceSecretName = new("foo")
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r = makeMySQLCluster()
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		r.Spec.Replicas = 3
+		r.Spec.ReadPoolReplicas = []int32{2}
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r = makeMySQLCluster()
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		r.Spec.FailoverPolicy = mocov1beta2.FailoverPolicyManual
+		err = k8sClient.Create(ctx, r)
+		Expect(err).To(HaveOccurred())
+
+		r = makeMySQLCluster()
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		r.Spec.Replicas = 3
+		err = k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeSemiSync
+		err = k8sClient.Update(ctx, r)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should deny changing the replication mode", func() {
+		r := makeMySQLCluster()
+		err := k8sClient.Create(ctx, r)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.Spec.ReplicationMode).To(Equal(mocov1beta2.ReplicationModeSemiSync))
+
+		r.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+		err = k8sClient.Update(ctx, r)
+		Expect(err).To(HaveOccurred())
+	})
+
 	It("should deny invalid restore spec", func() {
 		r := makeMySQLCluster()
 		r.Spec.Restore = &mocov1beta2.RestoreSpec{
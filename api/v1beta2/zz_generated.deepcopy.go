@@ -5,6 +5,7 @@
 package v1beta2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -163,6 +164,137 @@ func (in *BucketConfig) DeepCopy() *BucketConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReference) DeepCopyInto(out *ClusterReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReference.
+func (in *ClusterReference) DeepCopy() *ClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterStateRecord) DeepCopyInto(out *ClusterStateRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStateRecord.
+func (in *ClusterStateRecord) DeepCopy() *ClusterStateRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterStateRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusteringSpec) DeepCopyInto(out *ClusteringSpec) {
+	*out = *in
+	if in.GTIDWaitTimeoutSeconds != nil {
+		in, out := &in.GTIDWaitTimeoutSeconds, &out.GTIDWaitTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReadOnlyTimeoutSeconds != nil {
+		in, out := &in.ReadOnlyTimeoutSeconds, &out.ReadOnlyTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RoleChangeWait != nil {
+		in, out := &in.RoleChangeWait, &out.RoleChangeWait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CloneRestartWait != nil {
+		in, out := &in.CloneRestartWait, &out.CloneRestartWait
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CheckInterval != nil {
+		in, out := &in.CheckInterval, &out.CheckInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ConnectionDrain != nil {
+		in, out := &in.ConnectionDrain, &out.ConnectionDrain
+		*out = new(ConnectionDrainSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SwitchoverCheck != nil {
+		in, out := &in.SwitchoverCheck, &out.SwitchoverCheck
+		*out = new(SwitchoverCheckSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RoleChangeLimit != nil {
+		in, out := &in.RoleChangeLimit, &out.RoleChangeLimit
+		*out = new(RoleChangeLimitSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusteringSpec.
+func (in *ClusteringSpec) DeepCopy() *ClusteringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusteringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionDrainSpec) DeepCopyInto(out *ConnectionDrainSpec) {
+	*out = *in
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProtectedUsers != nil {
+		in, out := &in.ProtectedUsers, &out.ProtectedUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KillFirstUsers != nil {
+		in, out := &in.KillFirstUsers, &out.KillFirstUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionDrainSpec.
+func (in *ConnectionDrainSpec) DeepCopy() *ConnectionDrainSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionDrainSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DelayedReplica) DeepCopyInto(out *DelayedReplica) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DelayedReplica.
+func (in *DelayedReplica) DeepCopy() *DelayedReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(DelayedReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvFromSourceApplyConfiguration) DeepCopyInto(out *EnvFromSourceApplyConfiguration) {
 	clone := in.DeepCopy()
@@ -175,6 +307,93 @@ func (in *EnvVarApplyConfiguration) DeepCopyInto(out *EnvVarApplyConfiguration)
 	*out = *clone
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrantReplicaRecord) DeepCopyInto(out *ErrantReplicaRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrantReplicaRecord.
+func (in *ErrantReplicaRecord) DeepCopy() *ErrantReplicaRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrantReplicaRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FencedPrimaryStatus) DeepCopyInto(out *FencedPrimaryStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FencedPrimaryStatus.
+func (in *FencedPrimaryStatus) DeepCopy() *FencedPrimaryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FencedPrimaryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPHook) DeepCopyInto(out *HTTPHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPHook.
+func (in *HTTPHook) DeepCopy() *HTTPHook {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceReplicationStatus) DeepCopyInto(out *InstanceReplicationStatus) {
+	*out = *in
+	if in.SecondsBehindSource != nil {
+		in, out := &in.SecondsBehindSource, &out.SecondsBehindSource
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceReplicationStatus.
+func (in *InstanceReplicationStatus) DeepCopy() *InstanceReplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceReplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceStatus) DeepCopyInto(out *InstanceStatus) {
+	*out = *in
+	if in.Replication != nil {
+		in, out := &in.Replication, &out.Replication
+		*out = new(InstanceReplicationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceStatus.
+func (in *InstanceStatus) DeepCopy() *InstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *JobConfig) DeepCopyInto(out *JobConfig) {
 	*out = *in
@@ -244,6 +463,54 @@ func (in *JobConfig) DeepCopy() *JobConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobHook) DeepCopyInto(out *JobHook) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobHook.
+func (in *JobHook) DeepCopy() *JobHook {
+	if in == nil {
+		return nil
+	}
+	out := new(JobHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	out.Duration = in.Duration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MySQLCluster) DeepCopyInto(out *MySQLCluster) {
 	*out = *in
@@ -334,6 +601,11 @@ func (in *MySQLClusterSpec) DeepCopyInto(out *MySQLClusterSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ReplicationSource != nil {
+		in, out := &in.ReplicationSource, &out.ReplicationSource
+		*out = new(ReplicationSourceSpec)
+		**out = **in
+	}
 	if in.Collectors != nil {
 		in, out := &in.Collectors, &out.Collectors
 		*out = make([]string, len(*in))
@@ -359,6 +631,46 @@ func (in *MySQLClusterSpec) DeepCopyInto(out *MySQLClusterSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.FencingStrategies != nil {
+		in, out := &in.FencingStrategies, &out.FencingStrategies
+		*out = make([]FencingStrategy, len(*in))
+		copy(*out, *in)
+	}
+	if in.Clustering != nil {
+		in, out := &in.Clustering, &out.Clustering
+		*out = new(ClusteringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SwitchoverHooks != nil {
+		in, out := &in.SwitchoverHooks, &out.SwitchoverHooks
+		*out = new(SwitchoverHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(PromotionSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DelayedReplicas != nil {
+		in, out := &in.DelayedReplicas, &out.DelayedReplicas
+		*out = make([]DelayedReplica, len(*in))
+		copy(*out, *in)
+	}
+	if in.SemiSync != nil {
+		in, out := &in.SemiSync, &out.SemiSync
+		*out = new(SemiSyncSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadPoolReplicas != nil {
+		in, out := &in.ReadPoolReplicas, &out.ReadPoolReplicas
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MySQLClusterSpec.
@@ -381,16 +693,64 @@ func (in *MySQLClusterStatus) DeepCopyInto(out *MySQLClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StateHistory != nil {
+		in, out := &in.StateHistory, &out.StateHistory
+		*out = make([]ClusterStateRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SwitchoverHooks != nil {
+		in, out := &in.SwitchoverHooks, &out.SwitchoverHooks
+		*out = new(SwitchoverHooksStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FencedPrimary != nil {
+		in, out := &in.FencedPrimary, &out.FencedPrimary
+		*out = new(FencedPrimaryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RoleChangeHistory != nil {
+		in, out := &in.RoleChangeHistory, &out.RoleChangeHistory
+		*out = make([]RoleChangeRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.ErrantReplicaList != nil {
 		in, out := &in.ErrantReplicaList, &out.ErrantReplicaList
 		*out = make([]int, len(*in))
 		copy(*out, *in)
 	}
+	if in.ErrantReplicaHistory != nil {
+		in, out := &in.ErrantReplicaHistory, &out.ErrantReplicaHistory
+		*out = make([]ErrantReplicaRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Instances != nil {
+		in, out := &in.Instances, &out.Instances
+		*out = make([]InstanceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Backup.DeepCopyInto(&out.Backup)
 	if in.RestoredTime != nil {
 		in, out := &in.RestoredTime, &out.RestoredTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Promotion != nil {
+		in, out := &in.Promotion, &out.Promotion
+		*out = new(PromotionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Plan != nil {
+		in, out := &in.Plan, &out.Plan
+		*out = new(OperationPlan)
+		(*in).DeepCopyInto(*out)
+	}
 	out.ReconcileInfo = in.ReconcileInfo
 }
 
@@ -433,6 +793,42 @@ func (in *ObjectMeta) DeepCopy() *ObjectMeta {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationPlan) DeepCopyInto(out *OperationPlan) {
+	*out = *in
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Errants != nil {
+		in, out := &in.Errants, &out.Errants
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.NextPrimary != nil {
+		in, out := &in.NextPrimary, &out.NextPrimary
+		*out = new(int)
+		**out = **in
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationPlan.
+func (in *OperationPlan) DeepCopy() *OperationPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OverwriteContainer) DeepCopyInto(out *OverwriteContainer) {
 	*out = *in
@@ -509,6 +905,84 @@ func (in *PodTemplateSpec) DeepCopy() *PodTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionPhaseRecord) DeepCopyInto(out *PromotionPhaseRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionPhaseRecord.
+func (in *PromotionPhaseRecord) DeepCopy() *PromotionPhaseRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionPhaseRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionSpec) DeepCopyInto(out *PromotionSpec) {
+	*out = *in
+	if in.Priorities != nil {
+		in, out := &in.Priorities, &out.Priorities
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.NeverPromote != nil {
+		in, out := &in.NeverPromote, &out.NeverPromote
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.PreferredZones != nil {
+		in, out := &in.PreferredZones, &out.PreferredZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionSpec.
+func (in *PromotionSpec) DeepCopy() *PromotionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PromotionStatus) DeepCopyInto(out *PromotionStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StoppedSourceCluster != nil {
+		in, out := &in.StoppedSourceCluster, &out.StoppedSourceCluster
+		*out = new(ClusterReference)
+		**out = **in
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]PromotionPhaseRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PromotionStatus.
+func (in *PromotionStatus) DeepCopy() *PromotionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PromotionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReconcileInfo) DeepCopyInto(out *ReconcileInfo) {
 	*out = *in
@@ -524,6 +998,22 @@ func (in *ReconcileInfo) DeepCopy() *ReconcileInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSourceSpec) DeepCopyInto(out *ReplicationSourceSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSourceSpec.
+func (in *ReplicationSourceSpec) DeepCopy() *ReplicationSourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceRequirementsApplyConfiguration) DeepCopyInto(out *ResourceRequirementsApplyConfiguration) {
 	clone := in.DeepCopy()
@@ -547,12 +1037,78 @@ func (in *RestoreSpec) DeepCopy() *RestoreSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleChangeLimitSpec) DeepCopyInto(out *RoleChangeLimitSpec) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinFailoverInterval != nil {
+		in, out := &in.MinFailoverInterval, &out.MinFailoverInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleChangeLimitSpec.
+func (in *RoleChangeLimitSpec) DeepCopy() *RoleChangeLimitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleChangeLimitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleChangeRecord) DeepCopyInto(out *RoleChangeRecord) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleChangeRecord.
+func (in *RoleChangeRecord) DeepCopy() *RoleChangeRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleChangeRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecurityContextApplyConfiguration) DeepCopyInto(out *SecurityContextApplyConfiguration) {
 	clone := in.DeepCopy()
 	*out = *clone
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemiSyncSpec) DeepCopyInto(out *SemiSyncSpec) {
+	*out = *in
+	if in.WaitForReplicaCount != nil {
+		in, out := &in.WaitForReplicaCount, &out.WaitForReplicaCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemiSyncSpec.
+func (in *SemiSyncSpec) DeepCopy() *SemiSyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SemiSyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceSpecApplyConfiguration) DeepCopyInto(out *ServiceSpecApplyConfiguration) {
 	clone := in.DeepCopy()
@@ -594,6 +1150,105 @@ func (in *StatefulSetDefaulter) DeepCopy() *StatefulSetDefaulter {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverCheckSpec) DeepCopyInto(out *SwitchoverCheckSpec) {
+	*out = *in
+	if in.MaxTransactionAge != nil {
+		in, out := &in.MaxTransactionAge, &out.MaxTransactionAge
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxReplicationLag != nil {
+		in, out := &in.MaxReplicationLag, &out.MaxReplicationLag
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverCheckSpec.
+func (in *SwitchoverCheckSpec) DeepCopy() *SwitchoverCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverHook) DeepCopyInto(out *SwitchoverHook) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPHook)
+		**out = **in
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(JobHook)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverHook.
+func (in *SwitchoverHook) DeepCopy() *SwitchoverHook {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverHooks) DeepCopyInto(out *SwitchoverHooks) {
+	*out = *in
+	if in.Pre != nil {
+		in, out := &in.Pre, &out.Pre
+		*out = make([]SwitchoverHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Post != nil {
+		in, out := &in.Post, &out.Post
+		*out = make([]SwitchoverHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverHooks.
+func (in *SwitchoverHooks) DeepCopy() *SwitchoverHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverHooksStatus) DeepCopyInto(out *SwitchoverHooksStatus) {
+	*out = *in
+	if in.CalledPreHooks != nil {
+		in, out := &in.CalledPreHooks, &out.CalledPreHooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverHooksStatus.
+func (in *SwitchoverHooksStatus) DeepCopy() *SwitchoverHooksStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverHooksStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeApplyConfiguration) DeepCopyInto(out *VolumeApplyConfiguration) {
 	clone := in.DeepCopy()
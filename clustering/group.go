@@ -0,0 +1,378 @@
+package clustering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/dbop"
+	"github.com/cybozu-go/moco/pkg/event"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Member states and roles in `performance_schema.replication_group_members`.
+const (
+	memberOnline      = "ONLINE"
+	memberRecovering  = "RECOVERING"
+	memberOffline     = "OFFLINE"
+	memberError       = "ERROR"
+	memberUnreachable = "UNREACHABLE"
+
+	memberRolePrimary = "PRIMARY"
+)
+
+// gatherGroupStatus sets `ss.GroupMemberStates`, `ss.GroupQuorum`, and `ss.Primary`
+// from the group membership reported by the instances.
+//
+// The membership reported by an ONLINE member is trusted only if the majority of
+// the members are reachable from it.  Otherwise, each instance reports its own state.
+func gatherGroupStatus(ss *StatusSet) {
+	ss.GroupMemberStates = make([]string, len(ss.MySQLStatus))
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil {
+			continue
+		}
+		ss.GroupMemberStates[i] = memberOffline
+		for _, m := range ist.GroupMembers {
+			if m.ID == ist.GlobalVariables.UUID {
+				ss.GroupMemberStates[i] = m.State
+			}
+		}
+	}
+
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil || ss.GroupMemberStates[i] != memberOnline {
+			continue
+		}
+		var reachable int
+		for _, m := range ist.GroupMembers {
+			if m.State != memberUnreachable {
+				reachable++
+			}
+		}
+		if reachable*2 <= len(ist.GroupMembers) {
+			continue
+		}
+
+		ss.GroupQuorum = true
+		for _, m := range ist.GroupMembers {
+			index := groupMemberIndex(ss, m)
+			if index < 0 {
+				continue
+			}
+			ss.GroupMemberStates[index] = m.State
+			if m.State == memberOnline && m.Role == memberRolePrimary {
+				ss.Primary = index
+			}
+		}
+		return
+	}
+}
+
+// groupMemberIndex returns the index of the instance for a group member.
+// Members excluded from the cluster by scale-in are not found.
+func groupMemberIndex(ss *StatusSet, m dbop.GroupMember) int {
+	for i, ist := range ss.MySQLStatus {
+		if ist != nil && ist.GlobalVariables.UUID == m.ID {
+			return i
+		}
+	}
+	// the UUID of an unreachable instance is not known.
+	for i := range ss.MySQLStatus {
+		if m.Host == ss.Cluster.PodName(i) || m.Host == ss.Cluster.PodHostname(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isGroupMember(ss *StatusSet, index int) bool {
+	st := ss.GroupMemberStates[index]
+	return st == memberOnline || st == memberRecovering
+}
+
+// decideGroupState decides the ClusterState of a cluster in GroupReplication mode.
+// The group elects a new primary by itself, so the cluster never becomes Failed.
+// It also collects the candidates for switchover.
+func decideGroupState(ss *StatusSet) ClusterState {
+	switch {
+	case isOffline(ss):
+		return StateOffline
+	case isRestoring(ss):
+		return StateRestoring
+	case !ss.GroupQuorum && slices.Contains(ss.GroupMemberStates, memberOnline):
+		// the remaining members cannot decide anything without the majority.
+		return StateLost
+	case !ss.GroupQuorum:
+		return StateIncomplete
+	}
+
+	healthy := true
+	for i, pod := range ss.Pods {
+		if ss.GroupMemberStates[i] != memberOnline || !isPodReady(pod) {
+			healthy = false
+			continue
+		}
+		if i != ss.Primary && ss.MySQLStatus[i] != nil && isPromotable(ss, i) {
+			ss.Candidates = append(ss.Candidates, i)
+		}
+	}
+	if healthy {
+		return StateHealthy
+	}
+	return StateDegraded
+}
+
+// groupRole returns the value of the role label of an instance in GroupReplication mode.
+// Instances that are not ONLINE in the group have no role.
+func groupRole(ss *StatusSet, index int) string {
+	switch {
+	case ss.GroupMemberStates[index] != memberOnline:
+		return ""
+	case index == ss.Primary:
+		return constants.RolePrimary
+	default:
+		return constants.RoleReplica
+	}
+}
+
+// needGroupReconfigure returns true if the role labels or the status of MySQLCluster
+// do not follow the group, or if an instance is not a member of the group.
+func needGroupReconfigure(ss *StatusSet) bool {
+	if ss.Cluster.Status.CurrentPrimaryIndex != ss.Primary {
+		return true
+	}
+	for i, pod := range ss.Pods {
+		if pod.Labels[constants.LabelMocoRole] != groupRole(ss, i) {
+			return true
+		}
+		if ss.MySQLStatus[i] != nil && !isGroupMember(ss, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func groupAddress(cluster *mocov1beta2.MySQLCluster, index int) string {
+	return net.JoinHostPort(cluster.PodHostname(index), strconv.Itoa(constants.GroupReplicationPort))
+}
+
+func groupReplicationConfig(ss *StatusSet, index int, bootstrap bool) dbop.GroupReplicationConfig {
+	seeds := make([]string, 0, ss.Cluster.Spec.Replicas)
+	for i := 0; i < int(ss.Cluster.Spec.Replicas); i++ {
+		seeds = append(seeds, groupAddress(ss.Cluster, i))
+	}
+	return dbop.GroupReplicationConfig{
+		LocalAddress: groupAddress(ss.Cluster, index),
+		Seeds:        seeds,
+		User:         constants.ReplicationUser,
+		Password:     ss.Password.Replicator(),
+		Bootstrap:    bootstrap,
+	}
+}
+
+// configureGroup makes the cluster follow the group in GroupReplication mode.
+// It bootstraps the group if it has not been formed, updates the primary index and
+// the role labels to the primary elected by the group, and lets the other instances join the group.
+func (p *managerProcess) configureGroup(ctx context.Context, ss *StatusSet) (bool, error) {
+	log := logFromContext(ctx)
+
+	if !ss.GroupQuorum {
+		return p.bootstrapGroup(ctx, ss)
+	}
+
+	redo := false
+	if ss.Cluster.Status.CurrentPrimaryIndex != ss.Primary {
+		// the group has elected a new primary without MOCO.
+		redo = true
+		log.Info("the group elected a new primary", "previous", ss.Cluster.Status.CurrentPrimaryIndex, "primary", ss.Primary)
+		if err := p.setCurrentPrimaryIndex(ctx, ss.Primary); err != nil {
+			return false, err
+		}
+		p.metrics.failoverCount.Inc()
+		event.FailOverSucceeded.Emit(ss.Cluster, p.recorder, ss.Primary)
+	}
+
+	// remove the role labels first so that the connections to the old roles can be killed.
+	var changed []int
+	for i, pod := range ss.Pods {
+		role := groupRole(ss, i)
+		if pod.Labels[constants.LabelMocoRole] == role || pod.Labels[constants.LabelMocoRole] == "" {
+			continue
+		}
+		modified := pod.DeepCopy()
+		delete(modified.Labels, constants.LabelMocoRole)
+		if err := p.client.Patch(ctx, modified, client.MergeFrom(pod)); err != nil {
+			return false, fmt.Errorf("failed to remove %s label from %s/%s: %w", constants.LabelMocoRole, pod.Namespace, pod.Name, err)
+		}
+		ss.Pods[i] = modified
+		if ss.MySQLStatus[i] != nil {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) > 0 {
+		time.Sleep(roleChangeWait(ss.Cluster))
+	}
+	if err := drainConnections(ctx, ss, changed); err != nil {
+		return false, err
+	}
+
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil || isGroupMember(ss, i) {
+			continue
+		}
+		redo = true
+		op := ss.DBOps[i]
+		if ss.GroupMemberStates[i] == memberError {
+			log.Info("stop group replication of an instance in error state", "instance", i)
+			if err := op.StopGroupReplication(ctx); err != nil {
+				return false, fmt.Errorf("failed to stop group replication of instance %d: %w", i, err)
+			}
+		}
+		log.Info("join the group", "instance", i)
+		if err := op.StartGroupReplication(ctx, groupReplicationConfig(ss, i, false)); err != nil {
+			return false, fmt.Errorf("failed to join instance %d to the group: %w", i, err)
+		}
+		event.GroupMemberJoined.Emit(ss.Cluster, p.recorder, i)
+	}
+
+	for i, pod := range ss.Pods {
+		role := groupRole(ss, i)
+		if role == "" || pod.Labels[constants.LabelMocoRole] == role {
+			continue
+		}
+		modified := pod.DeepCopy()
+		if modified.Labels == nil {
+			modified.Labels = make(map[string]string)
+		}
+		modified.Labels[constants.LabelMocoRole] = role
+		if err := p.client.Patch(ctx, modified, client.MergeFrom(pod)); err != nil {
+			return false, fmt.Errorf("failed to add %s label to pod %s/%s: %w", constants.LabelMocoRole, pod.Namespace, pod.Name, err)
+		}
+	}
+	return redo, nil
+}
+
+// bootstrapGroup forms a new group with the instance that has all the transactions.
+// To avoid losing transactions, it waits for all the instances to be reachable, and
+// it refuses to bootstrap the group if none of the instances has all the transactions.
+func (p *managerProcess) bootstrapGroup(ctx context.Context, ss *StatusSet) (bool, error) {
+	log := logFromContext(ctx)
+
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil {
+			log.Info("wait for all instances to be reachable to bootstrap the group", "instance", i)
+			return false, nil
+		}
+	}
+
+	// prefer the current primary to keep the primary unchanged.
+	indices := []int{ss.Primary}
+	for i := range ss.MySQLStatus {
+		if i != ss.Primary {
+			indices = append(indices, i)
+		}
+	}
+	seed := -1
+	for _, i := range indices {
+		ok, err := hasAllTransactions(ctx, ss, i)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			seed = i
+			break
+		}
+	}
+	if seed < 0 {
+		return false, errors.New("cannot bootstrap the group because no instance has all the transactions")
+	}
+
+	op := ss.DBOps[seed]
+	if ss.GroupMemberStates[seed] != memberOffline {
+		if err := op.StopGroupReplication(ctx); err != nil {
+			return false, fmt.Errorf("failed to stop group replication of instance %d: %w", seed, err)
+		}
+	}
+	log.Info("bootstrap the group", "instance", seed)
+	if err := op.StartGroupReplication(ctx, groupReplicationConfig(ss, seed, true)); err != nil {
+		return false, fmt.Errorf("failed to bootstrap the group with instance %d: %w", seed, err)
+	}
+	event.GroupBootstrapped.Emit(ss.Cluster, p.recorder, seed)
+
+	if seed != ss.Cluster.Status.CurrentPrimaryIndex {
+		if err := p.setCurrentPrimaryIndex(ctx, seed); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// hasAllTransactions returns true if the executed GTID set of the instance
+// contains those of all the other instances.
+func hasAllTransactions(ctx context.Context, ss *StatusSet, index int) (bool, error) {
+	gtid := ss.MySQLStatus[index].GlobalVariables.ExecutedGTID
+	for i, ist := range ss.MySQLStatus {
+		if i == index {
+			continue
+		}
+		ok, err := ss.DBOps[index].IsSubsetGTID(ctx, ist.GlobalVariables.ExecutedGTID, gtid)
+		if err != nil {
+			return false, fmt.Errorf("failed to compare GTID of instance %d and %d: %w", i, index, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (p *managerProcess) setCurrentPrimaryIndex(ctx context.Context, index int) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cluster.Status.CurrentPrimaryIndex = index
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set the current primary index: %w", err)
+	}
+	return nil
+}
+
+func planConfigureGroup(ss *StatusSet) []string {
+	if !ss.GroupQuorum {
+		for i, ist := range ss.MySQLStatus {
+			if ist == nil {
+				return []string{fmt.Sprintf("wait for instance %d to be reachable to bootstrap the group", i)}
+			}
+		}
+		return []string{"bootstrap the group with the instance that has all the transactions"}
+	}
+
+	var steps []string
+	if ss.Cluster.Status.CurrentPrimaryIndex != ss.Primary {
+		steps = append(steps, fmt.Sprintf("update the primary to instance %d elected by the group", ss.Primary))
+	}
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil || isGroupMember(ss, i) {
+			continue
+		}
+		steps = append(steps, fmt.Sprintf("join instance %d to the group", i))
+	}
+	for i, pod := range ss.Pods {
+		if role := groupRole(ss, i); pod.Labels[constants.LabelMocoRole] != role {
+			steps = append(steps, fmt.Sprintf("update the role label of instance %d to %q", i, role))
+		}
+	}
+	return steps
+}
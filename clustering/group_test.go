@@ -0,0 +1,253 @@
+package clustering
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/dbop"
+)
+
+func groupMember(index int, state, role string) dbop.GroupMember {
+	return dbop.GroupMember{
+		ID:    fmt.Sprintf("uuid-%d", index),
+		Host:  fmt.Sprintf("moco-test-%d", index),
+		State: state,
+		Role:  role,
+	}
+}
+
+func newGroupMySQL(index int, members ...dbop.GroupMember) *dbop.MySQLInstanceStatus {
+	st := newMySQL("123", true, false, false).build()
+	st.GlobalVariables.UUID = fmt.Sprintf("uuid-%d", index)
+	st.GroupMembers = members
+	return st
+}
+
+func newGroupSS(roles []string, ready []bool, statuses ...*dbop.MySQLInstanceStatus) *StatusSet {
+	b := newSS(int32(len(statuses)), 0, false, false, false, false)
+	for i, st := range statuses {
+		b = b.withPod(ready[i], false, false).withMySQL(st)
+	}
+	ss := b.build()
+	ss.Cluster.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+	for i, role := range roles {
+		if role != "" {
+			ss.Pods[i].Labels = map[string]string{constants.LabelMocoRole: role}
+		}
+	}
+	return ss
+}
+
+func TestGroupStatus(t *testing.T) {
+	allReady := []bool{true, true, true}
+	fullView := []dbop.GroupMember{
+		groupMember(0, "ONLINE", "SECONDARY"),
+		groupMember(1, "ONLINE", "PRIMARY"),
+		groupMember(2, "ONLINE", "SECONDARY"),
+	}
+	currentRoles := []string{"primary", "replica", "replica"}
+
+	testCases := []struct {
+		name               string
+		statusSet          *StatusSet
+		expectedState      ClusterState
+		expectedPrimary    int
+		expectedCandidates []int
+		expectedStates     []string
+		expectedSteps      []string
+	}{
+		{
+			name: "healthy-elected",
+			statusSet: newGroupSS(currentRoles, allReady,
+				newGroupMySQL(0, fullView...),
+				newGroupMySQL(1, fullView...),
+				newGroupMySQL(2, fullView...),
+			),
+			expectedState:      StateHealthy,
+			expectedPrimary:    1,
+			expectedCandidates: []int{0, 2},
+			expectedStates:     []string{"ONLINE", "ONLINE", "ONLINE"},
+			expectedSteps: []string{
+				"update the primary to instance 1 elected by the group",
+				`update the role label of instance 0 to "replica"`,
+				`update the role label of instance 1 to "primary"`,
+			},
+		},
+		{
+			name: "degraded-unreachable",
+			statusSet: newGroupSS(currentRoles, []bool{true, true, false},
+				newGroupMySQL(0,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "ONLINE", "SECONDARY"),
+					groupMember(2, "UNREACHABLE", "SECONDARY"),
+				),
+				newGroupMySQL(1,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "ONLINE", "SECONDARY"),
+					groupMember(2, "UNREACHABLE", "SECONDARY"),
+				),
+				nil,
+			),
+			expectedState:      StateDegraded,
+			expectedPrimary:    0,
+			expectedCandidates: []int{1},
+			expectedStates:     []string{"ONLINE", "ONLINE", "UNREACHABLE"},
+			expectedSteps: []string{
+				`update the role label of instance 2 to ""`,
+			},
+		},
+		{
+			name: "degraded-left",
+			statusSet: newGroupSS([]string{"primary", "replica", ""}, allReady,
+				newGroupMySQL(0,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "ONLINE", "SECONDARY"),
+				),
+				newGroupMySQL(1,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "ONLINE", "SECONDARY"),
+				),
+				newGroupMySQL(2),
+			),
+			expectedState:      StateDegraded,
+			expectedPrimary:    0,
+			expectedCandidates: []int{1},
+			expectedStates:     []string{"ONLINE", "ONLINE", "OFFLINE"},
+			expectedSteps: []string{
+				"join instance 2 to the group",
+			},
+		},
+		{
+			name: "degraded-recovering",
+			statusSet: newGroupSS([]string{"primary", "replica", ""}, []bool{true, true, false},
+				newGroupMySQL(0,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "ONLINE", "SECONDARY"),
+					groupMember(2, "RECOVERING", "SECONDARY"),
+				),
+				newGroupMySQL(1),
+				newGroupMySQL(2),
+			),
+			expectedState:      StateDegraded,
+			expectedPrimary:    0,
+			expectedCandidates: []int{1},
+			expectedStates:     []string{"ONLINE", "ONLINE", "RECOVERING"},
+			expectedSteps: []string{
+				"nothing to do",
+			},
+		},
+		{
+			name: "lost",
+			statusSet: newGroupSS(currentRoles, []bool{true, false, false},
+				newGroupMySQL(0,
+					groupMember(0, "ONLINE", "PRIMARY"),
+					groupMember(1, "UNREACHABLE", "SECONDARY"),
+					groupMember(2, "UNREACHABLE", "SECONDARY"),
+				),
+				nil,
+				nil,
+			),
+			expectedState:   StateLost,
+			expectedPrimary: 0,
+			expectedStates:  []string{"ONLINE", "", ""},
+			expectedSteps: []string{
+				"do nothing because the majority of the group is lost",
+			},
+		},
+		{
+			name: "bootstrap",
+			statusSet: newGroupSS(nil, []bool{false, false, false},
+				newGroupMySQL(0),
+				newGroupMySQL(1),
+				newGroupMySQL(2),
+			),
+			expectedState:   StateIncomplete,
+			expectedPrimary: 0,
+			expectedStates:  []string{"OFFLINE", "OFFLINE", "OFFLINE"},
+			expectedSteps: []string{
+				"bootstrap the group with the instance that has all the transactions",
+			},
+		},
+		{
+			name: "bootstrap-waiting",
+			statusSet: newGroupSS(nil, []bool{false, false, false},
+				newGroupMySQL(0),
+				newGroupMySQL(1, groupMember(1, "ERROR", "")),
+				nil,
+			),
+			expectedState:   StateIncomplete,
+			expectedPrimary: 0,
+			expectedStates:  []string{"OFFLINE", "ERROR", ""},
+			expectedSteps: []string{
+				"wait for instance 2 to be reachable to bootstrap the group",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := tc.statusSet
+			gatherGroupStatus(ss)
+			ss.DecideState()
+
+			if ss.State != tc.expectedState {
+				t.Errorf("wrong state %s: expected=%s", ss.State, tc.expectedState)
+			}
+			if ss.Primary != tc.expectedPrimary {
+				t.Errorf("wrong primary %d: expected=%d", ss.Primary, tc.expectedPrimary)
+			}
+			if !slices.Equal(ss.Candidates, tc.expectedCandidates) {
+				t.Errorf("wrong candidates %v: expected=%v", ss.Candidates, tc.expectedCandidates)
+			}
+			if !slices.Equal(ss.GroupMemberStates, tc.expectedStates) {
+				t.Errorf("wrong member states %q: expected=%q", ss.GroupMemberStates, tc.expectedStates)
+			}
+			if ss.NeedSwitch {
+				t.Error("unexpected switchover")
+			}
+
+			plan := makePlan(ss, nil)
+			if !slices.Equal(plan.Steps, tc.expectedSteps) {
+				t.Errorf("wrong steps %q: expected=%q", plan.Steps, tc.expectedSteps)
+			}
+		})
+	}
+}
+
+func TestGroupSwitchover(t *testing.T) {
+	view := []dbop.GroupMember{
+		groupMember(0, "ONLINE", "PRIMARY"),
+		groupMember(1, "ONLINE", "SECONDARY"),
+		groupMember(2, "ONLINE", "SECONDARY"),
+	}
+	ss := newGroupSS([]string{"primary", "replica", "replica"}, []bool{true, true, true},
+		newGroupMySQL(0, view...),
+		newGroupMySQL(1, view...),
+		newGroupMySQL(2, view...),
+	)
+	ss.Pods[0].Annotations = map[string]string{
+		constants.AnnDemote:       "true",
+		constants.AnnSwitchoverTo: "2",
+	}
+	gatherGroupStatus(ss)
+	ss.DecideState()
+
+	if ss.State != StateHealthy {
+		t.Fatalf("wrong state %s", ss.State)
+	}
+	if !ss.NeedSwitch || ss.Candidate != 2 {
+		t.Fatalf("wrong switchover: NeedSwitch=%v, Candidate=%d", ss.NeedSwitch, ss.Candidate)
+	}
+
+	plan := makePlan(ss, nil)
+	expected := []string{
+		"set instance 2 as the primary of the group",
+		"switch the primary to instance 2",
+	}
+	if !slices.Equal(plan.Steps, expected) {
+		t.Errorf("wrong steps %q: expected=%q", plan.Steps, expected)
+	}
+}
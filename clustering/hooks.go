@@ -0,0 +1,337 @@
+package clustering
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/event"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	hookPhasePre   = "pre"
+	hookPhasePost  = "post"
+	hookPhaseAbort = "abort"
+
+	// hookJobTTLSeconds is the TTL of the finished Jobs run by switchover hooks.
+	hookJobTTLSeconds = 24 * 60 * 60
+)
+
+var hookJobPollInterval = 1 * time.Second
+
+// hookTransport is the transport for HTTP hooks.  Connections are not reused
+// because hooks are called only on switchovers.
+var hookTransport = &http.Transport{
+	DialContext:            (&net.Dialer{Timeout: 5 * time.Second, Control: refuseLocalAddress}).DialContext,
+	TLSHandshakeTimeout:    5 * time.Second,
+	MaxResponseHeaderBytes: 64 << 10,
+	DisableKeepAlives:      true,
+}
+
+// refuseLocalAddress refuses to connect to the loopback and link-local addresses
+// so that HTTP hooks cannot reach moco-controller itself or the metadata services of
+// the cloud providers.  The address is checked after the name resolution.
+func refuseLocalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("invalid address %s", address)
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refused to connect to %s", address)
+	}
+	return nil
+}
+
+// hookRequest is the request body sent to HTTP hooks.
+type hookRequest struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Phase       string `json:"phase"`
+	Primary     int    `json:"primary"`
+	NextPrimary int    `json:"nextPrimary"`
+}
+
+func newHookRequest(cluster *mocov1beta2.MySQLCluster, phase string, primary, next int) hookRequest {
+	return hookRequest{
+		Namespace:   cluster.Namespace,
+		Name:        cluster.Name,
+		Phase:       phase,
+		Primary:     primary,
+		NextPrimary: next,
+	}
+}
+
+// runPreSwitchoverHooks calls the pre hooks in order.  The hooks that have already been
+// called for the same switchover are skipped, and the called hooks are recorded in
+// `status.switchoverHooks`.  It returns an error if a hook whose failure policy is Abort fails.
+//
+// The switchover of a deleted primary or for scale-in cannot be aborted.  In that case,
+// the hooks are called within the time left before the Pod is killed and failures are only recorded.
+func (p *managerProcess) runPreSwitchoverHooks(ctx context.Context, ss *StatusSet) error {
+	spec := ss.Cluster.Spec.SwitchoverHooks
+	if spec == nil || len(spec.Pre) == 0 {
+		return nil
+	}
+
+	var called []string
+	if st := ss.Cluster.Status.SwitchoverHooks; st != nil && st.Primary == ss.Primary && st.NextPrimary == ss.Candidate {
+		called = slices.Clone(st.CalledPreHooks)
+	}
+
+	hookCtx := ctx
+	forced := ss.Pods[ss.Primary].DeletionTimestamp != nil || ss.ScaleInTarget > 0
+	if forced {
+		var cancel context.CancelFunc
+		hookCtx, cancel = context.WithTimeout(ctx, ss.Cluster.PreSwitchoverHookBudget())
+		defer cancel()
+	}
+
+	log := logFromContext(ctx)
+	req := newHookRequest(ss.Cluster, hookPhasePre, ss.Primary, ss.Candidate)
+	for _, hook := range spec.Pre {
+		if slices.Contains(called, hook.Name) {
+			log.Info("skip switchover hook called before", "phase", hookPhasePre, "hook", hook.Name)
+			continue
+		}
+		err := p.callSwitchoverHook(hookCtx, ss.Cluster, hook, req)
+		if err != nil && hookFailurePolicy(hook) == mocov1beta2.HookFailureAbort && !forced {
+			return fmt.Errorf("%s-switchover hook %q failed: %w", hookPhasePre, hook.Name, err)
+		}
+
+		called = append(called, hook.Name)
+		err = p.setSwitchoverHooksStatus(ctx, &mocov1beta2.SwitchoverHooksStatus{
+			Primary:        ss.Primary,
+			NextPrimary:    ss.Candidate,
+			CalledPreHooks: called,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startPostSwitchoverHooks calls the post hooks in order in the background
+// so that they do not delay the new primary from accepting writes.
+// `phase` is hookPhasePost for a completed switchover or hookPhaseAbort for an abandoned one.
+func (p *managerProcess) startPostSwitchoverHooks(ctx context.Context, cluster *mocov1beta2.MySQLCluster, phase string, primary, next int) {
+	spec := cluster.Spec.SwitchoverHooks
+	if spec == nil || len(spec.Post) == 0 {
+		return
+	}
+
+	req := newHookRequest(cluster, phase, primary, next)
+	go func() {
+		for _, hook := range spec.Post {
+			err := p.callSwitchoverHook(ctx, cluster, hook, req)
+			if err != nil && hookFailurePolicy(hook) == mocov1beta2.HookFailureAbort {
+				logFromContext(ctx).Info("the remaining post-switchover hooks are not called", "phase", phase)
+				return
+			}
+		}
+	}()
+}
+
+// abandonSwitchoverHooks calls the post hooks with the abort phase if the pre hooks have been
+// called for a switchover that is no longer going to happen, and clears `status.switchoverHooks`.
+// The record of a completed switchover is cleared by `switchover`.
+func (p *managerProcess) abandonSwitchoverHooks(ctx context.Context, ss *StatusSet) error {
+	st := ss.Cluster.Status.SwitchoverHooks
+	if st == nil {
+		return nil
+	}
+	if ss.NeedSwitch && st.Primary == ss.Primary && st.NextPrimary == ss.Candidate {
+		return nil
+	}
+
+	logFromContext(ctx).Info("the switchover was abandoned after the pre-switchover hooks", "primary", st.Primary, "next", st.NextPrimary)
+	if err := p.setSwitchoverHooksStatus(ctx, nil); err != nil {
+		return err
+	}
+	p.startPostSwitchoverHooks(ctx, ss.Cluster, hookPhaseAbort, st.Primary, st.NextPrimary)
+	return nil
+}
+
+func (p *managerProcess) setSwitchoverHooksStatus(ctx context.Context, st *mocov1beta2.SwitchoverHooksStatus) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cluster.Status.SwitchoverHooks = st
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the status of switchover hooks: %w", err)
+	}
+	return nil
+}
+
+func hookFailurePolicy(hook mocov1beta2.SwitchoverHook) mocov1beta2.HookFailurePolicy {
+	if hook.FailurePolicy == "" {
+		return mocov1beta2.HookFailureAbort
+	}
+	return hook.FailurePolicy
+}
+
+// callSwitchoverHook calls a hook and records the result as an event.
+func (p *managerProcess) callSwitchoverHook(ctx context.Context, cluster *mocov1beta2.MySQLCluster, hook mocov1beta2.SwitchoverHook, req hookRequest) error {
+	log := logFromContext(ctx)
+	log.Info("call switchover hook", "phase", req.Phase, "hook", hook.Name)
+	err := p.runSwitchoverHook(ctx, cluster, hook, req)
+	if err == nil {
+		event.SwitchoverHookSucceeded.Emit(cluster, p.recorder, req.Phase, hook.Name)
+		return nil
+	}
+
+	policy := hookFailurePolicy(hook)
+	event.SwitchoverHookFailed.Emit(cluster, p.recorder, req.Phase, hook.Name, policy, err)
+	log.Error(err, "switchover hook failed", "phase", req.Phase, "hook", hook.Name, "failurePolicy", policy)
+	return err
+}
+
+func (p *managerProcess) runSwitchoverHook(ctx context.Context, cluster *mocov1beta2.MySQLCluster, hook mocov1beta2.SwitchoverHook, req hookRequest) error {
+	timeout := time.Duration(hook.TimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = mocov1beta2.DefaultHookTimeoutSeconds * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	switch {
+	case hook.HTTP != nil:
+		return callHTTPHook(ctx, hook.HTTP, req, timeout)
+	case hook.Job != nil:
+		return p.runJobHook(ctx, cluster, hook, req)
+	}
+	return errors.New("neither http nor job is specified")
+}
+
+func callHTTPHook(ctx context.Context, hook *mocov1beta2.HTTPHook, req hookRequest, timeout time.Duration) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	hc := &http.Client{
+		Transport: hookTransport,
+		// redirects are not followed not to let a hook lead MOCO to other endpoints.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Timeout: timeout,
+	}
+	resp, err := hc.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// runJobHook creates a Job for the hook and waits for it to finish.
+// The Job is not deleted on timeout so that users can investigate it.
+func (p *managerProcess) runJobHook(ctx context.Context, cluster *mocov1beta2.MySQLCluster, hook mocov1beta2.SwitchoverHook, req hookRequest) error {
+	// the webhook rejects this, but the hook must never act as mysqld.
+	if hook.Job.ServiceAccountName == cluster.PrefixedName() {
+		return fmt.Errorf("job hook must not run with ServiceAccount %s", cluster.PrefixedName())
+	}
+
+	prefix := fmt.Sprintf("%s-%s-%s", cluster.PrefixedName(), req.Phase, hook.Name)
+	if len(prefix) > 57 {
+		prefix = prefix[:57]
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "MOCO_CLUSTER_NAMESPACE", Value: req.Namespace},
+		{Name: "MOCO_CLUSTER_NAME", Value: req.Name},
+		{Name: "MOCO_HOOK_PHASE", Value: req.Phase},
+		{Name: "MOCO_PRIMARY_INDEX", Value: strconv.Itoa(req.Primary)},
+		{Name: "MOCO_NEXT_PRIMARY_INDEX", Value: strconv.Itoa(req.NextPrimary)},
+	}
+
+	job := &batchv1.Job{}
+	job.Namespace = cluster.Namespace
+	job.GenerateName = prefix + "-"
+	job.Labels = map[string]string{
+		constants.LabelAppName:      constants.AppNameMySQL,
+		constants.LabelAppInstance:  cluster.Name,
+		constants.LabelAppCreatedBy: constants.AppCreator,
+	}
+	if err := controllerutil.SetControllerReference(cluster, job, p.client.Scheme()); err != nil {
+		return err
+	}
+	job.Spec = batchv1.JobSpec{
+		BackoffLimit:            ptr.To[int32](0),
+		TTLSecondsAfterFinished: ptr.To[int32](hookJobTTLSeconds),
+		Template: corev1.PodTemplateSpec{
+			Spec: corev1.PodSpec{
+				RestartPolicy:      corev1.RestartPolicyNever,
+				ServiceAccountName: hook.Job.ServiceAccountName,
+				Containers: []corev1.Container{{
+					Name:    "hook",
+					Image:   hook.Job.Image,
+					Command: hook.Job.Command,
+					Args:    hook.Job.Args,
+					Env:     append(env, hook.Job.Env...),
+				}},
+			},
+		},
+	}
+	if err := p.client.Create(ctx, job); err != nil {
+		return fmt.Errorf("failed to create Job: %w", err)
+	}
+	logFromContext(ctx).Info("created Job for switchover hook", "job", job.Name)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("job %s did not finish: %w", job.Name, ctx.Err())
+		case <-time.After(hookJobPollInterval):
+		}
+
+		current := &batchv1.Job{}
+		if err := p.reader.Get(ctx, client.ObjectKeyFromObject(job), current); err != nil {
+			return fmt.Errorf("failed to get Job %s: %w", job.Name, err)
+		}
+		for _, cond := range current.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch cond.Type {
+			case batchv1.JobComplete:
+				return nil
+			case batchv1.JobFailed:
+				return fmt.Errorf("job %s failed: %s", job.Name, cond.Message)
+			}
+		}
+	}
+}
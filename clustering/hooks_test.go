@@ -0,0 +1,27 @@
+package clustering
+
+import "testing"
+
+func TestRefuseLocalAddress(t *testing.T) {
+	testCases := []struct {
+		address string
+		refused bool
+	}{
+		{"10.0.0.1:80", false},
+		{"[2001:db8::1]:443", false},
+		{"127.0.0.1:8080", true},
+		{"[::1]:8080", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			err := refuseLocalAddress("tcp", tc.address, nil)
+			if (err != nil) != tc.refused {
+				t.Errorf("unexpected result: refused=%v, err=%v", tc.refused, err)
+			}
+		})
+	}
+}
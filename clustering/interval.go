@@ -0,0 +1,112 @@
+package clustering
+
+import (
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+)
+
+const (
+	// fastCheckDivisor divides the check interval while the cluster needs attention.
+	fastCheckDivisor = 4
+
+	// maxCheckBackoff is the maximum multiplier of the check interval while the cluster stays healthy.
+	maxCheckBackoff = 4
+
+	// minCheckInterval is the minimum interval that can be configured for a cluster.
+	minCheckInterval = time.Second
+)
+
+// checkInterval decides the interval of the periodic checks of a cluster.
+type checkInterval struct {
+	// defaultInterval is the value of `--check-interval` flag.
+	defaultInterval time.Duration
+
+	healthyChecks int
+	lastPrimary   int
+	fastUntil     time.Time
+
+	// lastBase and adaptive are the configuration of the cluster at the last check.
+	lastBase time.Duration
+	adaptive bool
+}
+
+func newCheckInterval(defaultInterval time.Duration) *checkInterval {
+	return &checkInterval{
+		defaultInterval: defaultInterval,
+		lastPrimary:     -1,
+	}
+}
+
+// baseCheckInterval returns the check interval configured for the cluster.
+// An annotation that is invalid or shorter than `minCheckInterval` is ignored.
+func baseCheckInterval(cluster *mocov1beta2.MySQLCluster, defaultInterval time.Duration) time.Duration {
+	if val, ok := cluster.Annotations[constants.AnnCheckInterval]; ok {
+		if d, err := time.ParseDuration(val); err == nil && d >= minCheckInterval {
+			return d
+		}
+	}
+	if c := cluster.Spec.Clustering; c != nil && c.CheckInterval != nil {
+		return c.CheckInterval.Duration
+	}
+	return defaultInterval
+}
+
+// next returns the interval until the next check of the cluster in `state`.
+//
+// If `spec.clustering.adaptiveCheckInterval` is true, the interval is shortened
+// while the cluster is Degraded, Failed, or Incomplete, and for one base interval
+// after the primary is changed.  While the cluster stays Healthy, the interval is
+// doubled on each check up to `maxCheckBackoff` times the base interval.
+func (c *checkInterval) next(cluster *mocov1beta2.MySQLCluster, state ClusterState, now time.Time) time.Duration {
+	base := baseCheckInterval(cluster, c.defaultInterval)
+
+	primary := cluster.Status.CurrentPrimaryIndex
+	if c.lastPrimary >= 0 && primary != c.lastPrimary {
+		c.fastUntil = now.Add(base)
+	}
+	c.lastPrimary = primary
+
+	if state == StateHealthy {
+		c.healthyChecks++
+	} else {
+		c.healthyChecks = 0
+	}
+
+	c.lastBase = base
+	c.adaptive = cluster.Spec.Clustering != nil && cluster.Spec.Clustering.AdaptiveCheckInterval
+	if !c.adaptive {
+		return base
+	}
+
+	switch {
+	case state == StateDegraded || state == StateFailed || state == StateIncomplete || now.Before(c.fastUntil):
+		return fastCheckInterval(base)
+	case state == StateHealthy:
+		backoff := 1
+		for i := 1; i < c.healthyChecks && backoff < maxCheckBackoff; i++ {
+			backoff *= 2
+		}
+		return base * time.Duration(backoff)
+	}
+	return base
+}
+
+// failed returns the interval until the next check after the status of the cluster
+// could not be gathered.  The cluster is checked as often as a cluster that needs
+// attention, and the backoff for a healthy cluster is reset.
+// This returns zero if the cluster has never been checked.
+func (c *checkInterval) failed() time.Duration {
+	c.healthyChecks = 0
+	if !c.adaptive {
+		return c.lastBase
+	}
+	return fastCheckInterval(c.lastBase)
+}
+
+// fastCheckInterval returns the shortened interval for `base`.
+// It is not shorter than `minCheckInterval` unless `base` is.
+func fastCheckInterval(base time.Duration) time.Duration {
+	return max(base/fastCheckDivisor, min(base, minCheckInterval))
+}
@@ -0,0 +1,130 @@
+package clustering
+
+import (
+	"testing"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBaseCheckInterval(t *testing.T) {
+	testCases := []struct {
+		name       string
+		annotation string
+		spec       *metav1.Duration
+		expected   time.Duration
+	}{
+		{name: "default", expected: time.Minute},
+		{name: "spec", spec: &metav1.Duration{Duration: 10 * time.Second}, expected: 10 * time.Second},
+		{name: "annotation", annotation: "5s", spec: &metav1.Duration{Duration: 10 * time.Second}, expected: 5 * time.Second},
+		{name: "invalid-annotation", annotation: "foo", spec: &metav1.Duration{Duration: 10 * time.Second}, expected: 10 * time.Second},
+		{name: "too-short-annotation", annotation: "1ms", expected: time.Minute},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &mocov1beta2.MySQLCluster{}
+			if tc.annotation != "" {
+				cluster.Annotations = map[string]string{constants.AnnCheckInterval: tc.annotation}
+			}
+			if tc.spec != nil {
+				cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{CheckInterval: tc.spec}
+			}
+			if d := baseCheckInterval(cluster, time.Minute); d != tc.expected {
+				t.Errorf("wrong interval %s: expected=%s", d, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCheckInterval(t *testing.T) {
+	base := 40 * time.Second
+	now := time.Now()
+
+	cluster := &mocov1beta2.MySQLCluster{}
+	cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{CheckInterval: &metav1.Duration{Duration: base}}
+
+	c := newCheckInterval(time.Minute)
+	for i := 0; i < 3; i++ {
+		if d := c.next(cluster, StateDegraded, now); d != base {
+			t.Errorf("non-adaptive interval should not change: %s", d)
+		}
+	}
+
+	cluster.Spec.Clustering.AdaptiveCheckInterval = true
+	c = newCheckInterval(time.Minute)
+	for _, state := range []ClusterState{StateDegraded, StateFailed, StateIncomplete} {
+		if d := c.next(cluster, state, now); d != base/fastCheckDivisor {
+			t.Errorf("wrong interval for %s: %s", state, d)
+		}
+	}
+	if d := c.next(cluster, StateOffline, now); d != base {
+		t.Errorf("wrong interval for %s: %s", StateOffline, d)
+	}
+
+	for i, expected := range []time.Duration{base, 2 * base, 4 * base, 4 * base} {
+		if d := c.next(cluster, StateHealthy, now); d != expected {
+			t.Errorf("wrong interval after %d healthy checks %s: expected=%s", i+1, d, expected)
+		}
+	}
+
+	cluster.Status.CurrentPrimaryIndex = 1
+	if d := c.next(cluster, StateHealthy, now); d != base/fastCheckDivisor {
+		t.Errorf("interval should be shortened after the primary is changed: %s", d)
+	}
+	if d := c.next(cluster, StateHealthy, now.Add(base/2)); d != base/fastCheckDivisor {
+		t.Errorf("interval should be shortened for a while: %s", d)
+	}
+	if d := c.next(cluster, StateHealthy, now.Add(base)); d != 4*base {
+		t.Errorf("interval should be backed off again: %s", d)
+	}
+}
+
+func TestCheckIntervalMinimum(t *testing.T) {
+	cluster := &mocov1beta2.MySQLCluster{}
+	cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+		CheckInterval:         &metav1.Duration{Duration: 2 * time.Second},
+		AdaptiveCheckInterval: true,
+	}
+
+	c := newCheckInterval(time.Minute)
+	if d := c.next(cluster, StateDegraded, time.Now()); d != minCheckInterval {
+		t.Errorf("interval should not be shorter than %s: %s", minCheckInterval, d)
+	}
+
+	cluster.Spec.Clustering.CheckInterval.Duration = 500 * time.Millisecond
+	if d := c.next(cluster, StateDegraded, time.Now()); d != 500*time.Millisecond {
+		t.Errorf("interval should not be longer than the base: %s", d)
+	}
+}
+
+func TestCheckIntervalFailed(t *testing.T) {
+	base := 40 * time.Second
+	now := time.Now()
+
+	cluster := &mocov1beta2.MySQLCluster{}
+	cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{CheckInterval: &metav1.Duration{Duration: base}}
+
+	c := newCheckInterval(time.Minute)
+	if d := c.failed(); d != 0 {
+		t.Errorf("interval should not be decided before the first check: %s", d)
+	}
+	c.next(cluster, StateHealthy, now)
+	if d := c.failed(); d != base {
+		t.Errorf("non-adaptive interval should not change: %s", d)
+	}
+
+	cluster.Spec.Clustering.AdaptiveCheckInterval = true
+	c = newCheckInterval(time.Minute)
+	for i := 0; i < 3; i++ {
+		c.next(cluster, StateHealthy, now)
+	}
+	if d := c.failed(); d != base/fastCheckDivisor {
+		t.Errorf("interval should be shortened after a failure: %s", d)
+	}
+	if d := c.next(cluster, StateHealthy, now); d != base {
+		t.Errorf("backoff should be reset after a failure: %s", d)
+	}
+}
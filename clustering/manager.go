@@ -28,9 +28,21 @@ type ClusterManager interface {
 	Stop(types.NamespacedName)
 	StopAll()
 	Pause(types.NamespacedName)
+	StopUnowned()
+}
+
+// Owner tells whether this controller is responsible for a MySQLCluster.
+type Owner interface {
+	Owns(types.NamespacedName) bool
 }
 
 func NewClusterManager(interval time.Duration, m manager.Manager, opf dbop.OperatorFactory, af AgentFactory, log logr.Logger) ClusterManager {
+	return NewShardedClusterManager(interval, m, opf, af, nil, log)
+}
+
+// NewShardedClusterManager creates a ClusterManager that manages only the MySQLClusters
+// owned by `owner`.  If `owner` is nil, all MySQLClusters are managed.
+func NewShardedClusterManager(interval time.Duration, m manager.Manager, opf dbop.OperatorFactory, af AgentFactory, owner Owner, log logr.Logger) ClusterManager {
 	return &clusterManager{
 		client:    m.GetClient(),
 		reader:    m.GetAPIReader(),
@@ -38,12 +50,18 @@ func NewClusterManager(interval time.Duration, m manager.Manager, opf dbop.Opera
 		dbf:       opf,
 		agentf:    af,
 		interval:  interval,
+		owner:     owner,
 		log:       log,
 		processes: make(map[string]*managerProcess),
+		stopping:  make(map[*managerProcess]struct{}),
 	}
 }
 
-//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;update;patch;delete
+//+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;create
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
 
 type clusterManager struct {
 	client   client.Client
@@ -52,12 +70,17 @@ type clusterManager struct {
 	dbf      dbop.OperatorFactory
 	agentf   AgentFactory
 	interval time.Duration
+	owner    Owner
 	log      logr.Logger
 
 	mu        sync.Mutex
 	processes map[string]*managerProcess
 	stopped   bool
 
+	// stopping is the set of the processes that have been stopped but may still be running.
+	// StopUnowned waits for them so that another controller does not manage the same cluster.
+	stopping map[*managerProcess]struct{}
+
 	wg sync.WaitGroup
 }
 
@@ -76,6 +99,9 @@ func (m *clusterManager) update(name types.NamespacedName, noStart bool, origin
 	if m.stopped {
 		return
 	}
+	if m.owner != nil && !m.owner.Owns(name) {
+		return
+	}
 
 	key := name.String()
 	p, ok := m.processes[key]
@@ -91,6 +117,8 @@ func (m *clusterManager) update(name types.NamespacedName, noStart bool, origin
 
 	p = newManagerProcess(m.client, m.reader, m.recorder, m.dbf, m.agentf, name, cancel)
 	m.wg.Go(func() {
+		defer close(p.done)
+		defer cancel()
 		p.Start(ctx, m.log.WithName(key), m.interval)
 	})
 	m.processes[key] = p
@@ -106,6 +134,7 @@ func (m *clusterManager) Stop(name types.NamespacedName) {
 	if ok {
 		p.Cancel()
 		delete(m.processes, key)
+		m.trackStopping(p)
 	}
 }
 
@@ -133,5 +162,66 @@ func (m *clusterManager) Pause(name types.NamespacedName) {
 	if ok {
 		p.Pause()
 		delete(m.processes, key)
+		m.trackStopping(p)
+	}
+}
+
+// trackStopping records a stopped process for StopUnowned to wait for.
+// It must be called with m.mu held.
+func (m *clusterManager) trackStopping(p *managerProcess) {
+	if m.owner == nil {
+		return
+	}
+	for sp := range m.stopping {
+		select {
+		case <-sp.done:
+			delete(m.stopping, sp)
+		default:
+		}
+	}
+	m.stopping[p] = struct{}{}
+}
+
+// StopUnowned stops the manager processes for the clusters that are no longer owned
+// by this controller, and waits for them to finish.
+//
+// The operation in progress, such as a switchover or a failover, is not interrupted;
+// the processes quit after it completes.  The processes stopped by Stop or Pause are
+// also waited for because they may still be operating the clusters.
+//
+// This may be called concurrently.  Each call also waits for the processes being
+// stopped by the other calls so that none of them returns before the processes finish.
+func (m *clusterManager) StopUnowned() {
+	if m.owner == nil {
+		return
+	}
+
+	m.mu.Lock()
+	for key, p := range m.processes {
+		if m.owner.Owns(p.name) {
+			continue
+		}
+		p.Finish()
+		delete(m.processes, key)
+		m.trackStopping(p)
+	}
+	stopping := make([]*managerProcess, 0, len(m.stopping))
+	for p := range m.stopping {
+		stopping = append(stopping, p)
+	}
+	m.mu.Unlock()
+
+	for _, p := range stopping {
+		<-p.done
+	}
+
+	m.mu.Lock()
+	for p := range m.stopping {
+		select {
+		case <-p.done:
+			delete(m.stopping, p)
+		default:
+		}
 	}
+	m.mu.Unlock()
 }
@@ -4,7 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
@@ -15,7 +20,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -112,7 +121,10 @@ var _ = Describe("manager", func() {
 		ms.errorCount = metrics.ErrorCountVec.WithLabelValues("test", "test")
 		ms.available = metrics.AvailableVec.WithLabelValues("test", "test")
 		ms.healthy = metrics.HealthyVec.WithLabelValues("test", "test")
+		ms.state = metrics.StateVec.MustCurryWith(prometheus.Labels{"name": "test", "namespace": "test"})
 		ms.switchoverCount = metrics.SwitchoverCountVec.WithLabelValues("test", "test")
+		ms.replicaIORunning = metrics.ReplicaIORunningVec.MustCurryWith(prometheus.Labels{"name": "test", "namespace": "test"})
+		ms.missingGTIDs = metrics.MissingGTIDsVec.MustCurryWith(prometheus.Labels{"name": "test", "namespace": "test"})
 		ms.failoverCount = metrics.FailoverCountVec.WithLabelValues("test", "test")
 		ms.replicas = metrics.TotalReplicasVec.WithLabelValues("test", "test")
 		ms.readyReplicas = metrics.ReadyReplicasVec.WithLabelValues("test", "test")
@@ -209,8 +221,30 @@ var _ = Describe("manager", func() {
 		events := &corev1.EventList{}
 		err = k8sClient.List(ctx, events, client.InNamespace("test"))
 		Expect(err).NotTo(HaveOccurred())
-		Expect(events.Items).To(HaveLen(1))
-		Expect(events.Items[0].Reason).To(Equal(event.SetWritable.Reason))
+		var writableEvents, stateEvents, otherEvents int
+		for _, ev := range events.Items {
+			switch ev.Reason {
+			case event.SetWritable.Reason:
+				writableEvents++
+			case event.StateChanged.Reason:
+				stateEvents++
+			default:
+				otherEvents++
+			}
+		}
+		Expect(writableEvents).To(Equal(1))
+		Expect(stateEvents).To(BeNumerically(">", 0))
+		Expect(otherEvents).To(Equal(0))
+
+		Expect(cluster.Status.Instances).To(HaveLen(1))
+		Expect(cluster.Status.Instances[0].Reachable).To(BeTrue())
+		Expect(cluster.Status.Instances[0].Role).To(Equal(constants.RolePrimary))
+		Expect(cluster.Status.Instances[0].ReadOnly).To(BeFalse())
+		Expect(cluster.Status.Instances[0].Replication).To(BeNil())
+
+		Expect(cluster.Status.State).To(Equal(StateHealthy.String()))
+		Expect(cluster.Status.StateHistory).NotTo(BeEmpty())
+		Expect(cluster.Status.StateHistory[len(cluster.Status.StateHistory)-1].State).To(Equal(StateHealthy.String()))
 
 		st := of.getInstanceStatus(cluster.PodHostname(0))
 		Expect(st).NotTo(BeNil())
@@ -222,6 +256,8 @@ var _ = Describe("manager", func() {
 		Expect(ms.replicas).To(MetricsIs("==", 1))
 		Expect(ms.readyReplicas).To(MetricsIs("==", 1))
 		Expect(ms.errantReplicas).To(MetricsIs("==", 0))
+		Expect(ms.state.WithLabelValues(StateHealthy.String())).To(MetricsIs("==", 1))
+		Expect(ms.state.WithLabelValues(StateFailed.String())).To(MetricsIs("==", 0))
 
 		By("set the instance 0 failing")
 		of.setFailing(cluster.PodHostname(0), true)
@@ -371,6 +407,7 @@ var _ = Describe("manager", func() {
 				cloneSuccesses++
 			case event.InitCloneFailed.Reason:
 				cloneErrors++
+			case event.StateChanged.Reason:
 			default:
 				otherEvents++
 			}
@@ -528,6 +565,16 @@ var _ = Describe("manager", func() {
 		Expect(ms.errantReplicas).To(MetricsIs("==", 0))
 		Expect(ms.switchoverCount).To(MetricsIs("==", 1))
 		Expect(ms.failoverCount).To(MetricsIs("==", 0))
+		Expect(testutil.CollectAndCount(metrics.SwitchoverDurationVec)).To(Equal(1))
+
+		Eventually(func(g Gomega) {
+			for i := range 3 {
+				g.Expect(ms.replicaIORunning.WithLabelValues(strconv.Itoa(i))).To(MetricsIs("==", 1))
+				if i != newPrimary {
+					g.Expect(ms.missingGTIDs.WithLabelValues(strconv.Itoa(i))).To(MetricsIs("==", 0))
+				}
+			}
+		}).Should(Succeed())
 
 		for i := range 3 {
 			st := of.getInstanceStatus(cluster.PodHostname(i))
@@ -601,6 +648,143 @@ var _ = Describe("manager", func() {
 		}
 	})
 
+	It("should replicate data from another MySQLCluster, follow its switchover, and promote the cluster", func() {
+		testSetupResources(ctx, 1, "")
+
+		By("creating the source cluster")
+		source := &mocov1beta2.MySQLCluster{}
+		source.Namespace = "test"
+		source.Name = "source"
+		source.Spec.Replicas = 3
+		source.Spec.ServerIDBase = 100
+		source.Spec.VolumeClaimTemplates = []mocov1beta2.PersistentVolumeClaim{{}}
+		source.Spec.PodTemplate.Spec = (mocov1beta2.PodSpecApplyConfiguration)(*corev1ac.PodSpec().WithContainers(
+			corev1ac.Container().WithName("mysqld")),
+		)
+		err := k8sClient.Create(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+		source.Status.CurrentPrimaryIndex = 0
+		source.Status.SyncedReplicas = 3
+		err = k8sClient.Status().Update(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+
+		passwd := mysqlPassword.ToSecret()
+		passwd.Namespace = "test"
+		passwd.Name = source.UserSecretName()
+		err = k8sClient.Create(ctx, passwd)
+		Expect(err).NotTo(HaveOccurred())
+
+		testSetGTID(source.PodHostname(0), "ex:1,ex:2,ex:3,ex:4")
+		testSetGTID(source.PodHostname(1), "ex:1,ex:2,ex:3,ex:4")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.ReplicationSource = &mocov1beta2.ReplicationSourceSpec{
+			ClusterRef: mocov1beta2.ClusterReference{Name: "source"},
+		}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		By("checking the cluster is cloned and becomes healthy")
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.Cloned).To(BeTrue())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		st0 := of.getInstanceStatus(cluster.PodHostname(0))
+		Expect(st0.GlobalVariables.SuperReadOnly).To(BeTrue())
+		Expect(st0.ReplicaStatus).NotTo(BeNil())
+		Expect(st0.ReplicaStatus.SourceHost).To(Equal(source.PodHostname(0)))
+
+		By("switching over the source cluster")
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(source), source)
+		Expect(err).NotTo(HaveOccurred())
+		source.Status.CurrentPrimaryIndex = 1
+		err = k8sClient.Status().Update(ctx, source)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			st0 := of.getInstanceStatus(cluster.PodHostname(0))
+			g.Expect(st0.ReplicaStatus).NotTo(BeNil())
+			g.Expect(st0.ReplicaStatus.SourceHost).To(Equal(source.PodHostname(1)))
+		}).Should(Succeed())
+
+		By("promoting the cluster")
+		cluster, err = testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Annotations = map[string]string{constants.AnnPromote: "true"}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		// the controller that stops the clustering of the source cluster is not running in this test.
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(source), source)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(source.Annotations).To(HaveKeyWithValue(constants.AnnClusteringStopped, "true"))
+
+			meta.SetStatusCondition(&source.Status.Conditions, metav1.Condition{
+				Type:    mocov1beta2.ConditionClusteringActive,
+				Status:  metav1.ConditionFalse,
+				Reason:  "StopClustering",
+				Message: "the clustering is stopped",
+			})
+			err = k8sClient.Status().Update(ctx, source)
+			g.Expect(err).NotTo(HaveOccurred())
+		}).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.Promotion).NotTo(BeNil())
+			g.Expect(cluster.Status.Promotion.Phase).To(Equal(mocov1beta2.PromotionPhaseCompleted))
+			g.Expect(cluster.Annotations).NotTo(HaveKey(constants.AnnPromote))
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		Expect(cluster.HasReplicationSource()).To(BeFalse())
+		Expect(cluster.Status.Promotion.CompletionTime).NotTo(BeNil())
+		var phases []mocov1beta2.PromotionPhase
+		for _, r := range cluster.Status.Promotion.History {
+			phases = append(phases, r.Phase)
+		}
+		Expect(phases).To(Equal([]mocov1beta2.PromotionPhase{
+			mocov1beta2.PromotionPhaseFencingSource,
+			mocov1beta2.PromotionPhaseCatchingUp,
+			mocov1beta2.PromotionPhaseDetaching,
+			mocov1beta2.PromotionPhaseCompleted,
+		}))
+		Expect(cluster.Status.Promotion.History[1].Message).To(ContainSubstring("read-only"))
+		Expect(cluster.Status.Promotion.StoppedSourceCluster).To(BeNil())
+
+		By("checking the clustering of the source cluster is resumed")
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(source), source)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(source.Annotations).NotTo(HaveKey(constants.AnnClusteringStopped))
+
+		stSource := of.getInstanceStatus(source.PodHostname(1))
+		Expect(stSource).NotTo(BeNil())
+		Expect(stSource.GlobalVariables.SuperReadOnly).To(BeTrue())
+		st0 = of.getInstanceStatus(cluster.PodHostname(0))
+		Expect(st0.GlobalVariables.ReadOnly).To(BeFalse())
+	})
+
 	It("should handle failover", func() {
 		testSetupResources(ctx, 3, "")
 
@@ -698,18 +882,23 @@ var _ = Describe("manager", func() {
 		Expect(ms.errantReplicas).To(MetricsIs("==", 0))
 		Expect(ms.switchoverCount).To(MetricsIs("==", 0))
 		Expect(ms.failoverCount).To(MetricsIs("==", 1))
+		Expect(testutil.CollectAndCount(metrics.FailoverDurationVec)).To(Equal(1))
 
 		events := &corev1.EventList{}
 		err = k8sClient.List(ctx, events, client.InNamespace("test"))
 		Expect(err).NotTo(HaveOccurred())
-		var failOverEvents int
+		var failOverEvents, fenceEvents int
 		for _, ev := range events.Items {
 			switch ev.Reason {
 			case event.FailOverSucceeded.Reason:
 				failOverEvents++
+			case event.FenceSucceeded.Reason:
+				fenceEvents++
 			}
 		}
 		Expect(failOverEvents).To(Equal(1))
+		// super_read_only is not set because the old primary is unreachable.
+		Expect(fenceEvents).To(Equal(1))
 
 		for i := range 3 {
 			switch i {
@@ -764,26 +953,28 @@ var _ = Describe("manager", func() {
 		}
 	})
 
-	It("should handle errant replicas and lost", func() {
-		testSetupResources(ctx, 5, "")
+	It("should fence the old primary with a NetworkPolicy on failover", func() {
+		testSetupResources(ctx, 3, "")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.FencingStrategies = []mocov1beta2.FencingStrategy{
+			mocov1beta2.FencingRemoveRoleLabel,
+			mocov1beta2.FencingNetworkPolicy,
+			mocov1beta2.FencingSuperReadOnly,
+		}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
 
 		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
 		defer cm.StopAll()
 
-		cluster, err := testGetCluster(ctx)
-		Expect(err).NotTo(HaveOccurred())
 		cm.Update(client.ObjectKeyFromObject(cluster), "test")
 		defer func() {
 			cm.Stop(client.ObjectKeyFromObject(cluster))
 			time.Sleep(400 * time.Millisecond)
-			Eventually(func(g Gomega) {
-				ch := make(chan prometheus.Metric, 2)
-				metrics.ErrantReplicasVec.Collect(ch)
-				g.Expect(ch).NotTo(Receive())
-			}).Should(Succeed())
 		}()
 
-		// wait for cluster's condition changes
 		Eventually(func(g Gomega) {
 			cluster, err = testGetCluster(ctx)
 			g.Expect(err).NotTo(HaveOccurred())
@@ -793,143 +984,706 @@ var _ = Describe("manager", func() {
 			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
 		}).Should(Succeed())
 
-		// wait for the pods' metadata are updated
+		By("triggering a failover")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1")           // new primary
+		testSetGTID(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(1), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
+
 		Eventually(func(g Gomega) {
-			for i := range 5 {
-				pod := &corev1.Pod{}
-				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
-				g.Expect(err).NotTo(HaveOccurred())
-				switch i {
-				case 0:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary))
-				default:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica))
-				}
-			}
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(1), "the primary is not switched yet")
 		}).Should(Succeed())
 
-		By("making an errant replica")
-		of.resetKillConnectionsCount()
+		np := &networkingv1.NetworkPolicy{}
+		err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.FencingNetworkPolicyName(0)}, np)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(np.Spec.PodSelector.MatchLabels).To(HaveKeyWithValue(appsv1.StatefulSetPodNameLabel, cluster.PodName(0)))
+		Expect(np.Spec.Ingress).To(HaveLen(1))
+		Expect(np.Spec.Ingress[0].Ports).To(HaveLen(2))
 
-		// When the primary load is high, sometimes the gtid_executed of a replica precedes the primary.
-		// pod(4) is intended for such situations.
-		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
-		testSetGTID(cluster.PodHostname(1), "p0:1,p0:2,p1:1") // errant replica
-		testSetGTID(cluster.PodHostname(2), "p0:1")
-		testSetGTID(cluster.PodHostname(3), "p0:1,p0:2,p0:3")
-		testSetGTID(cluster.PodHostname(4), "p0:1,p0:2,p0:3")
+		events := &corev1.EventList{}
+		err = k8sClient.List(ctx, events, client.InNamespace("test"))
+		Expect(err).NotTo(HaveOccurred())
+		var fenceEvents, fenceErrors int
+		for _, ev := range events.Items {
+			switch ev.Reason {
+			case event.FenceSucceeded.Reason:
+				fenceEvents++
+			case event.FenceFailed.Reason:
+				fenceErrors++
+			}
+		}
+		Expect(fenceEvents).To(Equal(2))
+		Expect(fenceErrors).To(Equal(0))
+
+		By("recovering the old primary")
+		of.setFailing(cluster.PodHostname(0), false)
 
-		// wait for the errant replica is detected
 		Eventually(func(g Gomega) {
 			cluster, err = testGetCluster(ctx)
 			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(cluster.Status.ErrantReplicas).To(Equal(1))
-			g.Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
-		}).Should(Succeed())
 
-		// wait for the pods' metadata are updated
-		Eventually(func(g Gomega) {
-			for i := range 5 {
-				pod := &corev1.Pod{}
-				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
-				g.Expect(err).NotTo(HaveOccurred())
-				switch i {
-				case 0:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary))
-				case 1:
-					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole))
-				default:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica))
-				}
-			}
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+
+			nps := &networkingv1.NetworkPolicyList{}
+			err = k8sClient.List(ctx, nps, client.InNamespace("test"))
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(nps.Items).To(BeEmpty())
 		}).Should(Succeed())
+	})
 
-		condAvailable, err := testGetCondition(cluster, mocov1beta2.ConditionAvailable)
+	It("should wait for an approval to failover with the manual failover policy", func() {
+		testSetupResources(ctx, 3, "")
+
+		cluster, err := testGetCluster(ctx)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(condAvailable.Status).To(Equal(metav1.ConditionTrue))
-		condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+		cluster.Spec.FailoverPolicy = mocov1beta2.FailoverPolicyManual
+		err = k8sClient.Update(ctx, cluster)
 		Expect(err).NotTo(HaveOccurred())
-		Expect(condHealthy.Status).To(Equal(metav1.ConditionFalse))
-
-		Expect(ms.available).To(MetricsIs("==", 1))
-		Expect(ms.healthy).To(MetricsIs("==", 0))
-		Expect(ms.errantReplicas).To(MetricsIs("==", 1))
-
-		st1 := of.getInstanceStatus(cluster.PodHostname(1))
-		Expect(st1).NotTo(BeNil())
-		if st1.ReplicaHosts != nil {
-			Expect(st1.ReplicaStatus.ReplicaIORunning).NotTo(Equal("Yes"))
-		}
 
-		for i := range 5 {
-			Expect(of.getKillConnectionsCount(cluster.PodHostname(i))).To(Equal(0))
-		}
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
 
-		By("triggering a failover")
-		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1")
-		of.setRetrievedGTIDSet(cluster.PodHostname(3), "p0:1,p0:2,p0:3")
-		of.setRetrievedGTIDSet(cluster.PodHostname(4), "p0:1,p0:2,p0:3")
-		of.setFailing(cluster.PodHostname(0), true)
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
 
-		// wait for the new primary to be selected
+		// wait for cluster's condition changes
 		Eventually(func(g Gomega) {
 			cluster, err = testGetCluster(ctx)
 			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(3), "the primary is not switched yet")
 
-			condAvailable, err := testGetCondition(cluster, mocov1beta2.ConditionAvailable)
-			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(condAvailable.Status).To(Equal(metav1.ConditionTrue))
 			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
 			g.Expect(err).NotTo(HaveOccurred())
-			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionFalse))
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+			condPending, err := testGetCondition(cluster, mocov1beta2.ConditionFailoverPending)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condPending.Status).To(Equal(metav1.ConditionFalse))
 		}).Should(Succeed())
 
-		// wait for the pods' metadata are updated
-		Eventually(func(g Gomega) {
-			for i := range 5 {
-				pod := &corev1.Pod{}
-				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
-				g.Expect(err).NotTo(HaveOccurred())
-				switch i {
-				case 0:
-					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole), "failing pod should not have role label")
-				case 1:
-					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole), "errant pod should not have role label")
-				case 3:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary), "new primary should have role=primary")
-				default:
-					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica), "other pods should have role=replica")
-				}
-			}
-		}).Should(Succeed())
+		By("making the primary fail")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1")           // new primary
+		testSetGTID(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(1), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
 
-		st3 := of.getInstanceStatus(cluster.PodHostname(3))
-		Expect(st3.GlobalVariables.ReadOnly).To(BeFalse())
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
 
-		Expect(cluster.Status.ErrantReplicas).To(Equal(1))
-		Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
+			condPending, err := testGetCondition(cluster, mocov1beta2.ConditionFailoverPending)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condPending.Status).To(Equal(metav1.ConditionTrue))
+			g.Expect(condPending.Message).To(ContainSubstring("instance 1"))
+		}).Should(Succeed())
 
-		Expect(ms.available).To(MetricsIs("==", 1))
-		Expect(ms.healthy).To(MetricsIs("==", 0))
-		Expect(ms.replicas).To(MetricsIs("==", 5))
-		Expect(ms.errantReplicas).To(MetricsIs("==", 1))
-		Expect(ms.switchoverCount).To(MetricsIs("==", 0))
-		Expect(ms.failoverCount).To(MetricsIs("==", 1))
+		Consistently(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(0), "the primary should not be switched without approval")
+		}, 3*time.Second).Should(Succeed())
+		Expect(ms.failoverCount).To(MetricsIs("==", 0))
 
 		events := &corev1.EventList{}
 		err = k8sClient.List(ctx, events, client.InNamespace("test"))
 		Expect(err).NotTo(HaveOccurred())
-		var failOverEvents int
+		var pendingEvents int
 		for _, ev := range events.Items {
-			switch ev.Reason {
-			case event.FailOverSucceeded.Reason:
-				failOverEvents++
+			if ev.Reason == event.FailOverPending.Reason {
+				pendingEvents++
 			}
 		}
-		Expect(failOverEvents).To(Equal(1))
+		Expect(pendingEvents).To(Equal(1))
 
-		By("re-initializing the errant replica")
+		By("approving the failover")
+		cluster, err = testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Annotations = map[string]string{constants.AnnApproveFailover: "true"}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(1), "the primary is not switched yet")
+			g.Expect(cluster.Annotations).NotTo(HaveKey(constants.AnnApproveFailover))
+
+			condPending, err := testGetCondition(cluster, mocov1beta2.ConditionFailoverPending)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condPending.Status).To(Equal(metav1.ConditionFalse))
+			condAvailable, err := testGetCondition(cluster, mocov1beta2.ConditionAvailable)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condAvailable.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		Expect(ms.failoverCount).To(MetricsIs("==", 1))
+	})
+
+	It("should not promote instances that must never be promoted", func() {
+		testSetupResources(ctx, 3, "")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{NeverPromote: []int32{1}}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		// wait for cluster's condition changes
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		By("triggering a failover")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1")
+		testSetGTID(cluster.PodHostname(2), "p0:1") // new primary
+		of.setRetrievedGTIDSet(cluster.PodHostname(1), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(2), "the primary is not switched yet")
+		}).Should(Succeed())
+
+		st2 := of.getInstanceStatus(cluster.PodHostname(2))
+		Expect(st2.GlobalVariables.ExecutedGTID).To(Equal("p0:1,p0:2,p0:3"))
+		Expect(ms.failoverCount).To(MetricsIs("==", 1))
+	})
+
+	It("should configure delayed replicas", func() {
+		testSetupResources(ctx, 3, "")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 3600}}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		st0 := of.getInstanceStatus(cluster.PodHostname(0))
+		Expect(st0.GlobalVariables.WaitForSlaveCount).To(Equal(1))
+		st1 := of.getInstanceStatus(cluster.PodHostname(1))
+		Expect(st1.GlobalVariables.SemiSyncSlaveEnabled).To(BeTrue())
+		Expect(st1.ReplicaStatus.SQLDelay).To(Equal(0))
+		st2 := of.getInstanceStatus(cluster.PodHostname(2))
+		Expect(st2.GlobalVariables.SemiSyncSlaveEnabled).To(BeFalse())
+		Expect(st2.ReplicaStatus.SQLDelay).To(Equal(3600))
+
+		By("triggering a failover")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1")           // new primary
+		testSetGTID(cluster.PodHostname(2), "p0:1")
+		of.setRetrievedGTIDSet(cluster.PodHostname(1), "p0:1,p0:2")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(1), "the primary is not switched yet")
+		}).Should(Succeed())
+	})
+
+	It("should configure read pool replicas and semi-sync", func() {
+		testSetupResources(ctx, 5, "")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.ReadPoolReplicas = []int32{3, 4}
+		cluster.Spec.SemiSync = &mocov1beta2.SemiSyncSpec{TimeoutSeconds: new(int32(10))}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		st0 := of.getInstanceStatus(cluster.PodHostname(0))
+		Expect(st0.GlobalVariables.WaitForSlaveCount).To(Equal(1))
+		Expect(st0.GlobalVariables.SemiSyncMasterTimeout).To(Equal(10000))
+		for i := 1; i < 5; i++ {
+			st := of.getInstanceStatus(cluster.PodHostname(i))
+			Expect(st.GlobalVariables.SemiSyncSlaveEnabled).To(Equal(i < 3), "instance %d", i)
+		}
+
+		By("changing the semi-sync settings of the healthy cluster")
+		cluster.Spec.SemiSync.WaitForReplicaCount = new(int32(2))
+		cluster.Spec.ReadPoolReplicas = []int32{4}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			st0 := of.getInstanceStatus(cluster.PodHostname(0))
+			g.Expect(st0.GlobalVariables.WaitForSlaveCount).To(Equal(2))
+			st3 := of.getInstanceStatus(cluster.PodHostname(3))
+			g.Expect(st3.GlobalVariables.SemiSyncSlaveEnabled).To(BeTrue())
+		}).Should(Succeed())
+
+		By("triggering a failover")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1")
+		testSetGTID(cluster.PodHostname(2), "p0:1")
+		testSetGTID(cluster.PodHostname(3), "p0:1") // new primary
+		testSetGTID(cluster.PodHostname(4), "p0:1")
+		of.setRetrievedGTIDSet(cluster.PodHostname(1), "p0:1,p0:2")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1,p0:2")
+		of.setRetrievedGTIDSet(cluster.PodHostname(3), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(4), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(3), "the primary is not switched yet")
+		}).Should(Succeed())
+	})
+
+	It("should call switchover hooks", func() {
+		testSetupResources(ctx, 3, "")
+
+		var mu sync.Mutex
+		var preOK bool
+		var requests []hookRequest
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			req := hookRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			requests = append(requests, req)
+			if req.Phase == hookPhasePre && !preOK {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer srv.Close()
+		// the test server listens on the loopback address, which HTTP hooks refuse.
+		origTransport := hookTransport
+		hookTransport = &http.Transport{DisableKeepAlives: true}
+		defer func() { hookTransport = origTransport }()
+		getRequests := func() []hookRequest {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(requests)
+		}
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Spec.SwitchoverHooks = &mocov1beta2.SwitchoverHooks{
+			Pre: []mocov1beta2.SwitchoverHook{{
+				Name:           "pause",
+				HTTP:           &mocov1beta2.HTTPHook{URL: srv.URL + "/pre"},
+				TimeoutSeconds: 5,
+				FailurePolicy:  mocov1beta2.HookFailureAbort,
+			}},
+			Post: []mocov1beta2.SwitchoverHook{{
+				Name:           "warm",
+				HTTP:           &mocov1beta2.HTTPHook{URL: srv.URL + "/post"},
+				TimeoutSeconds: 5,
+				FailurePolicy:  mocov1beta2.HookFailureContinue,
+			}},
+		}
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+		Expect(getRequests()).To(BeEmpty())
+
+		By("requesting a switchover while the pre hook fails")
+		pod0 := &corev1.Pod{}
+		err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(0)}, pod0)
+		Expect(err).NotTo(HaveOccurred())
+		pod0.Annotations = map[string]string{constants.AnnDemote: "true"}
+		err = k8sClient.Update(ctx, pod0)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			err = k8sClient.List(ctx, events, client.InNamespace("test"))
+			g.Expect(err).NotTo(HaveOccurred())
+			var hookErrors int
+			for _, ev := range events.Items {
+				if ev.Reason == event.SwitchoverHookFailed.Reason {
+					hookErrors++
+				}
+			}
+			g.Expect(hookErrors).To(BeNumerically(">", 0))
+		}).Should(Succeed())
+
+		cluster, err = testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(0))
+		st0 := of.getInstanceStatus(cluster.PodHostname(0))
+		Expect(st0.GlobalVariables.ReadOnly).To(BeFalse())
+		for _, req := range getRequests() {
+			Expect(req.Phase).To(Equal(hookPhasePre))
+		}
+
+		By("making the pre hook succeed")
+		mu.Lock()
+		preOK = true
+		mu.Unlock()
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).NotTo(Equal(0), "the primary is not switched yet")
+		}).Should(Succeed())
+		newPrimary := cluster.Status.CurrentPrimaryIndex
+
+		Eventually(func(g Gomega) {
+			reqs := getRequests()
+			g.Expect(reqs).NotTo(BeEmpty())
+			last := reqs[len(reqs)-1]
+			g.Expect(last.Phase).To(Equal(hookPhasePost))
+			g.Expect(last.Namespace).To(Equal("test"))
+			g.Expect(last.Name).To(Equal("test"))
+			g.Expect(last.Primary).To(Equal(0))
+			g.Expect(last.NextPrimary).To(Equal(newPrimary))
+		}).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			events := &corev1.EventList{}
+			err = k8sClient.List(ctx, events, client.InNamespace("test"))
+			g.Expect(err).NotTo(HaveOccurred())
+			var hookSuccesses int
+			for _, ev := range events.Items {
+				if ev.Reason == event.SwitchoverHookSucceeded.Reason {
+					hookSuccesses++
+				}
+			}
+			g.Expect(hookSuccesses).To(Equal(2))
+		}).Should(Succeed())
+
+		var preCalls int
+		for _, req := range getRequests() {
+			if req.Phase == hookPhasePre && req.NextPrimary == newPrimary {
+				preCalls++
+			}
+		}
+		Expect(preCalls).To(BeNumerically(">", 0))
+		cluster, err = testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.Status.SwitchoverHooks).To(BeNil())
+	})
+
+	It("should scale in the cluster", func() {
+		testSetupResources(ctx, 5, "")
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cluster.Status.CurrentPrimaryIndex = 4
+		err = k8sClient.Status().Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		st4 := of.getInstanceStatus(cluster.PodHostname(4))
+		Expect(st4.GlobalVariables.WaitForSlaveCount).To(Equal(2))
+
+		By("decreasing replicas from 5 to 3")
+		cluster.Spec.Replicas = 3
+		err = k8sClient.Update(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(BeNumerically("<", 3), "the primary is not switched yet")
+
+			cond, err := testGetCondition(cluster, mocov1beta2.ConditionScaleInReady)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			g.Expect(cond.ObservedGeneration).To(Equal(cluster.Generation))
+		}).Should(Succeed())
+		Expect(ms.switchoverCount).To(MetricsIs("==", 1))
+
+		pst := of.getInstanceStatus(cluster.PodHostname(cluster.Status.CurrentPrimaryIndex))
+		Expect(pst.GlobalVariables.WaitForSlaveCount).To(Equal(1))
+		for i := 3; i < 5; i++ {
+			st := of.getInstanceStatus(cluster.PodHostname(i))
+			Expect(st.ReplicaStatus).To(BeNil())
+		}
+
+		By("removing the excess pods")
+		for i := 3; i < 5; i++ {
+			pod := &corev1.Pod{}
+			pod.Namespace = "test"
+			pod.Name = cluster.PodName(i)
+			err = k8sClient.Delete(ctx, pod)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			_, err := testGetCondition(cluster, mocov1beta2.ConditionScaleInReady)
+			g.Expect(err).To(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+		Expect(ms.replicas).To(MetricsIs("==", 3))
+	})
+
+	It("should handle errant replicas and lost", func() {
+		testSetupResources(ctx, 5, "")
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+			Eventually(func(g Gomega) {
+				ch := make(chan prometheus.Metric, 2)
+				metrics.ErrantReplicasVec.Collect(ch)
+				g.Expect(ch).NotTo(Receive())
+			}).Should(Succeed())
+		}()
+
+		// wait for cluster's condition changes
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		// wait for the pods' metadata are updated
+		Eventually(func(g Gomega) {
+			for i := range 5 {
+				pod := &corev1.Pod{}
+				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
+				g.Expect(err).NotTo(HaveOccurred())
+				switch i {
+				case 0:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary))
+				default:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica))
+				}
+			}
+		}).Should(Succeed())
+
+		By("making an errant replica")
+		of.resetKillConnectionsCount()
+
+		// When the primary load is high, sometimes the gtid_executed of a replica precedes the primary.
+		// pod(4) is intended for such situations.
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1,p0:2,p1:1") // errant replica
+		testSetGTID(cluster.PodHostname(2), "p0:1")
+		testSetGTID(cluster.PodHostname(3), "p0:1,p0:2,p0:3")
+		testSetGTID(cluster.PodHostname(4), "p0:1,p0:2,p0:3")
+
+		// wait for the errant replica is detected
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.ErrantReplicas).To(Equal(1))
+			g.Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
+		}).Should(Succeed())
+
+		// wait for the pods' metadata are updated
+		Eventually(func(g Gomega) {
+			for i := range 5 {
+				pod := &corev1.Pod{}
+				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
+				g.Expect(err).NotTo(HaveOccurred())
+				switch i {
+				case 0:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary))
+				case 1:
+					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole))
+				default:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica))
+				}
+			}
+		}).Should(Succeed())
+
+		condAvailable, err := testGetCondition(cluster, mocov1beta2.ConditionAvailable)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condAvailable.Status).To(Equal(metav1.ConditionTrue))
+		condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(condHealthy.Status).To(Equal(metav1.ConditionFalse))
+
+		Expect(ms.available).To(MetricsIs("==", 1))
+		Expect(ms.healthy).To(MetricsIs("==", 0))
+		Expect(ms.errantReplicas).To(MetricsIs("==", 1))
+
+		st1 := of.getInstanceStatus(cluster.PodHostname(1))
+		Expect(st1).NotTo(BeNil())
+		if st1.ReplicaHosts != nil {
+			Expect(st1.ReplicaStatus.ReplicaIORunning).NotTo(Equal("Yes"))
+		}
+
+		for i := range 5 {
+			Expect(of.getKillConnectionsCount(cluster.PodHostname(i))).To(Equal(0))
+		}
+
+		By("triggering a failover")
+		of.setRetrievedGTIDSet(cluster.PodHostname(2), "p0:1")
+		of.setRetrievedGTIDSet(cluster.PodHostname(3), "p0:1,p0:2,p0:3")
+		of.setRetrievedGTIDSet(cluster.PodHostname(4), "p0:1,p0:2,p0:3")
+		of.setFailing(cluster.PodHostname(0), true)
+
+		// wait for the new primary to be selected
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.CurrentPrimaryIndex).To(Equal(3), "the primary is not switched yet")
+
+			condAvailable, err := testGetCondition(cluster, mocov1beta2.ConditionAvailable)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condAvailable.Status).To(Equal(metav1.ConditionTrue))
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionFalse))
+		}).Should(Succeed())
+
+		// wait for the pods' metadata are updated
+		Eventually(func(g Gomega) {
+			for i := range 5 {
+				pod := &corev1.Pod{}
+				err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(i)}, pod)
+				g.Expect(err).NotTo(HaveOccurred())
+				switch i {
+				case 0:
+					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole), "failing pod should not have role label")
+				case 1:
+					g.Expect(pod.Labels).NotTo(HaveKey(constants.LabelMocoRole), "errant pod should not have role label")
+				case 3:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RolePrimary), "new primary should have role=primary")
+				default:
+					g.Expect(pod.Labels[constants.LabelMocoRole]).To(Equal(constants.RoleReplica), "other pods should have role=replica")
+				}
+			}
+		}).Should(Succeed())
+
+		st3 := of.getInstanceStatus(cluster.PodHostname(3))
+		Expect(st3.GlobalVariables.ReadOnly).To(BeFalse())
+
+		Expect(cluster.Status.ErrantReplicas).To(Equal(1))
+		Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
+
+		Expect(ms.available).To(MetricsIs("==", 1))
+		Expect(ms.healthy).To(MetricsIs("==", 0))
+		Expect(ms.replicas).To(MetricsIs("==", 5))
+		Expect(ms.errantReplicas).To(MetricsIs("==", 1))
+		Expect(ms.switchoverCount).To(MetricsIs("==", 0))
+		Expect(ms.failoverCount).To(MetricsIs("==", 1))
+
+		events := &corev1.EventList{}
+		err = k8sClient.List(ctx, events, client.InNamespace("test"))
+		Expect(err).NotTo(HaveOccurred())
+		var failOverEvents int
+		for _, ev := range events.Items {
+			switch ev.Reason {
+			case event.FailOverSucceeded.Reason:
+				failOverEvents++
+			}
+		}
+		Expect(failOverEvents).To(Equal(1))
+
+		By("re-initializing the errant replica")
 		of.resetKillConnectionsCount()
 		testSetGTID(cluster.PodHostname(1), "")
 		Eventually(func() any {
@@ -989,6 +1743,92 @@ var _ = Describe("manager", func() {
 		}).Should(Succeed())
 	})
 
+	It("should re-clone errant replicas", func() {
+		testSetupResources(ctx, 3, "")
+
+		cm := NewClusterManager(1*time.Second, mgr, of, af, stdr.New(nil))
+		defer cm.StopAll()
+
+		cluster, err := testGetCluster(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		cm.Update(client.ObjectKeyFromObject(cluster), "test")
+		defer func() {
+			cm.Stop(client.ObjectKeyFromObject(cluster))
+			time.Sleep(400 * time.Millisecond)
+		}()
+
+		// wait for cluster's condition changes
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+		}).Should(Succeed())
+
+		By("making an errant replica")
+		testSetGTID(cluster.PodHostname(0), "p0:1,p0:2,p0:3") // primary
+		testSetGTID(cluster.PodHostname(1), "p0:1,p0:2,p1:1") // errant replica
+		testSetGTID(cluster.PodHostname(2), "p0:1,p0:2,p0:3")
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
+		}).Should(Succeed())
+
+		Consistently(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.ErrantReplicaList).To(Equal([]int{1}))
+		}, 3*time.Second).Should(Succeed())
+
+		By("annotating the errant replica Pod")
+		pod := &corev1.Pod{}
+		err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(1)}, pod)
+		Expect(err).NotTo(HaveOccurred())
+		newPod := pod.DeepCopy()
+		if newPod.Annotations == nil {
+			newPod.Annotations = make(map[string]string)
+		}
+		newPod.Annotations[constants.AnnRecloneErrant] = "true"
+		err = k8sClient.Patch(ctx, newPod, client.MergeFrom(pod))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func(g Gomega) {
+			cluster, err = testGetCluster(ctx)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(cluster.Status.ErrantReplicaList).To(BeEmpty())
+			g.Expect(cluster.Status.ErrantReplicaHistory).To(HaveLen(1))
+			g.Expect(cluster.Status.ErrantReplicaHistory[0].Index).To(Equal(1))
+			g.Expect(cluster.Status.ErrantReplicaHistory[0].ErrantGTIDSet).To(Equal("p1:1"))
+
+			condHealthy, err := testGetCondition(cluster, mocov1beta2.ConditionHealthy)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(condHealthy.Status).To(Equal(metav1.ConditionTrue))
+
+			pod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: cluster.PodName(1)}, pod)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pod.Annotations).NotTo(HaveKey(constants.AnnRecloneErrant))
+		}).Should(Succeed())
+
+		gtid, _ := testGetGTID(cluster.PodHostname(1))
+		Expect(gtid).To(Equal("p0:1,p0:2,p0:3"))
+
+		events := &corev1.EventList{}
+		err = k8sClient.List(ctx, events, client.InNamespace("test"))
+		Expect(err).NotTo(HaveOccurred())
+		var resetEvents int
+		for _, ev := range events.Items {
+			if ev.Reason == event.ErrantReplicaReset.Reason {
+				resetEvents++
+			}
+		}
+		Expect(resetEvents).To(Equal(1))
+	})
+
 	It("should export backup related metrics", func() {
 		testSetupResources(ctx, 1, "")
 
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	agent "github.com/cybozu-go/moco-agent/proto"
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
@@ -221,7 +222,7 @@ func (o *mockOperator) IsSubsetGTID(ctx context.Context, set1, set2 string) (boo
 // ConfigureReplica configures client-side replication.
 // If `symisync` is true, it enables client-side semi-synchronous replication.
 // In either case, it disables server-side semi-synchronous replication.
-func (o *mockOperator) ConfigureReplica(ctx context.Context, source dbop.AccessInfo, semisync bool) error {
+func (o *mockOperator) ConfigureReplica(ctx context.Context, source dbop.AccessInfo, semisync bool, delaySeconds int) error {
 	if o.failing {
 		return errors.New("mysqld is down")
 	}
@@ -234,6 +235,10 @@ func (o *mockOperator) ConfigureReplica(ctx context.Context, source dbop.AccessI
 	for i := 0; i < int(o.cluster.Spec.Replicas); i++ {
 		hostnames[o.cluster.PodHostname(i)] = true
 	}
+	if _, ok := testGetGTID(source.Host); ok {
+		// instances of another MySQLCluster
+		hostnames[source.Host] = true
+	}
 	if _, ok := hostnames[source.Host]; !ok {
 		return fmt.Errorf("configureReplica: wrong host: %s", source.Host)
 	}
@@ -297,21 +302,27 @@ func (o *mockOperator) ConfigureReplica(ctx context.Context, source dbop.AccessI
 		RetrievedGtidSet:  gtid,
 		ReplicaIORunning:  "Yes",
 		ReplicaSQLRunning: "Yes",
+		SQLDelay:          delaySeconds,
 	}
 	o.mysql.status.GlobalVariables.SemiSyncSlaveEnabled = semisync
 	return setPodReadiness(ctx, o.cluster.PodName(o.index), true)
 }
 
 // ConfigurePrimary configures server-side semi-synchronous replication.
-// For asynchronous replication, this method should not be called.
-func (o *mockOperator) ConfigurePrimary(ctx context.Context, waitForCount int) error {
+// If `waitForCount` is zero, it disables server-side semi-synchronous replication.
+func (o *mockOperator) ConfigurePrimary(ctx context.Context, waitForCount, timeoutSeconds int) error {
 	if o.failing {
 		return errors.New("mysqld is down")
 	}
 	o.mysql.mu.Lock()
 	defer o.mysql.mu.Unlock()
 
+	if waitForCount == 0 {
+		o.mysql.status.GlobalVariables.SemiSyncMasterEnabled = false
+		return nil
+	}
 	o.mysql.status.GlobalVariables.WaitForSlaveCount = waitForCount
+	o.mysql.status.GlobalVariables.SemiSyncMasterTimeout = timeoutSeconds * 1000
 	o.mysql.status.GlobalVariables.SemiSyncMasterEnabled = true
 	return nil
 }
@@ -406,7 +417,7 @@ func (o *mockOperator) SetReadOnly(ctx context.Context, readonly bool) error {
 	return setPodReadiness(ctx, o.cluster.PodName(o.index), true)
 }
 
-func (o *mockOperator) KillConnections(ctx context.Context) error {
+func (o *mockOperator) KillConnections(ctx context.Context, opts dbop.KillOptions) error {
 	if o.failing {
 		return errors.New("mysqld is down")
 	}
@@ -416,6 +427,57 @@ func (o *mockOperator) KillConnections(ctx context.Context) error {
 	return nil
 }
 
+func (o *mockOperator) GetLongRunningActivities(ctx context.Context, threshold time.Duration) ([]dbop.Activity, error) {
+	if o.failing {
+		return nil, errors.New("mysqld is down")
+	}
+	return nil, nil
+}
+
+func (o *mockOperator) ResetReplication(ctx context.Context) error {
+	if o.failing {
+		return errors.New("mysqld is down")
+	}
+	o.mysql.mu.Lock()
+	defer o.mysql.mu.Unlock()
+
+	if o.mysql.status.ReplicaStatus != nil {
+		si := o.factory.getInstance(o.mysql.status.ReplicaStatus.SourceHost)
+		if si != nil {
+			si.mu.Lock()
+			var newReplicas []dbop.ReplicaHost
+			for _, rep := range si.status.ReplicaHosts {
+				if rep.Host == o.Name() {
+					continue
+				}
+				newReplicas = append(newReplicas, rep)
+			}
+			si.status.ReplicaHosts = newReplicas
+			si.mu.Unlock()
+		}
+	}
+	o.mysql.status.ReplicaStatus = nil
+	o.mysql.status.GlobalVariables.SemiSyncSlaveEnabled = false
+	testSetGTID(o.Name(), "")
+	return nil
+}
+
+// Group Replication is tested with the real mysqld in pkg/dbop and
+// with StatusSet in status_test.go, so the mock does not support it.
+var errMockGroupReplication = errors.New("group replication is not supported by the mock")
+
+func (o *mockOperator) StartGroupReplication(ctx context.Context, config dbop.GroupReplicationConfig) error {
+	return errMockGroupReplication
+}
+
+func (o *mockOperator) StopGroupReplication(ctx context.Context) error {
+	return errMockGroupReplication
+}
+
+func (o *mockOperator) SetGroupPrimary(ctx context.Context, uuid string) error {
+	return errMockGroupReplication
+}
+
 type mockMySQL struct {
 	mu     sync.Mutex
 	status dbop.MySQLInstanceStatus
@@ -512,8 +574,21 @@ func (f *mockOpFactory) getInstance(name string) *mockMySQL {
 		return m
 	}
 	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f.mysqls[name]
+	m := f.mysqls[name]
+	f.mu.Unlock()
+	if m != nil {
+		return m
+	}
+
+	// instances of another MySQLCluster
+	gtid, ok := testGetGTID(name)
+	if !ok {
+		return nil
+	}
+	m = &mockMySQL{}
+	m.status.GlobalVariables.UUID = "ex"
+	m.status.GlobalVariables.ExecutedGTID = gtid
+	return m
 }
 
 func (f *mockOpFactory) getInstanceStatus(name string) *dbop.MySQLInstanceStatus {
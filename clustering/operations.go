@@ -2,9 +2,13 @@ package clustering
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	agent "github.com/cybozu-go/moco-agent/proto"
@@ -12,14 +16,28 @@ import (
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/cybozu-go/moco/pkg/dbop"
 	"github.com/cybozu-go/moco/pkg/event"
+	"github.com/cybozu-go/moco/pkg/password"
 	"google.golang.org/protobuf/types/known/durationpb"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 const (
-	timeoutSeconds = 50
+	defaultGTIDWaitTimeoutSeconds = 50
+
+	defaultSemiSyncTimeoutSeconds = 24 * 60 * 60
+
+	// maxStateHistory is the maximum number of records in `status.stateHistory`.
+	maxStateHistory = 10
 )
 
 var (
@@ -66,98 +84,276 @@ func (p *managerProcess) isCloning(ctx context.Context, ss *StatusSet) bool {
 }
 
 func (p *managerProcess) clone(ctx context.Context, ss *StatusSet) (bool, error) {
+	var req *agent.CloneRequest
+	var err error
+	if ss.Cluster.Spec.ReplicationSource != nil {
+		req, err = p.cloneRequestFromCluster(ctx, ss.Cluster)
+	} else {
+		req, err = p.cloneRequestFromSecret(ctx, ss.Cluster)
+	}
+	if err != nil {
+		return false, err
+	}
+	req.BootTimeout = durationpb.New(time.Duration(ss.Cluster.Spec.StartupWaitSeconds) * time.Second)
+
+	ag, err := p.agentf.New(ctx, ss.Cluster, ss.Primary)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to moco-agent for instance %d: %w", ss.Primary, err)
+	}
+	defer func() { _ = ag.Close() }()
+
+	log := logFromContext(ctx)
+	log.Info("begin cloning data", "source", req.Host)
+	if _, err := ag.Clone(ctx, req); err != nil {
+		log.Error(err, "clone failed", "source", req.Host)
+		return false, fmt.Errorf("failed to clone data from %s: %w", req.Host, err)
+	}
+
+	log.Info("clone succeeded", "source", req.Host)
+
+	// wait until the instance restarts after clone
+	op := ss.DBOps[ss.Primary]
+	time.Sleep(cloneRestartWait(ss.Cluster))
+	for range 60 {
+		select {
+		case <-time.After(1 * time.Second):
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+
+		_, err := op.GetStatus(ctx)
+		if err == nil {
+			break
+		}
+	}
+	return true, nil
+}
+
+func (p *managerProcess) cloneRequestFromSecret(ctx context.Context, cluster *mocov1beta2.MySQLCluster) (*agent.CloneRequest, error) {
 	secret := &corev1.Secret{}
-	name := client.ObjectKey{Namespace: ss.Cluster.Namespace, Name: *ss.Cluster.Spec.ReplicationSourceSecretName}
+	name := client.ObjectKey{Namespace: cluster.Namespace, Name: *cluster.Spec.ReplicationSourceSecretName}
 	if err := p.client.Get(ctx, name, secret); err != nil {
-		return false, fmt.Errorf("failed to get secret %s: %w", name.String(), err)
+		return nil, fmt.Errorf("failed to get secret %s: %w", name.String(), err)
 	}
 
 	req := &agent.CloneRequest{}
 	if val, ok := secret.Data[constants.CloneSourceHostKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourceHostKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourceHostKey, name.String())
 	} else {
 		req.Host = string(val)
 	}
 	if val, ok := secret.Data[constants.CloneSourcePortKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourcePortKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourcePortKey, name.String())
 	} else {
 		n, err := strconv.ParseInt(string(val), 10, 32)
 		if err != nil {
-			return false, fmt.Errorf("bad port number in secret %s: %w", name.String(), err)
+			return nil, fmt.Errorf("bad port number in secret %s: %w", name.String(), err)
 		}
 		if n <= 0 {
-			return false, fmt.Errorf("bad port number in secret %s", name.String())
+			return nil, fmt.Errorf("bad port number in secret %s", name.String())
 		}
 		req.Port = int32(n)
 	}
 	if val, ok := secret.Data[constants.CloneSourceUserKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourceUserKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourceUserKey, name.String())
 	} else {
 		req.User = string(val)
 	}
 	if val, ok := secret.Data[constants.CloneSourcePasswordKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourcePasswordKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourcePasswordKey, name.String())
 	} else {
 		req.Password = string(val)
 	}
 	if val, ok := secret.Data[constants.CloneSourceInitUserKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourceInitUserKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourceInitUserKey, name.String())
 	} else {
 		req.InitUser = string(val)
 	}
 	if val, ok := secret.Data[constants.CloneSourceInitPasswordKey]; !ok {
-		return false, fmt.Errorf("no %s in secret %s", constants.CloneSourceInitPasswordKey, name.String())
+		return nil, fmt.Errorf("no %s in secret %s", constants.CloneSourceInitPasswordKey, name.String())
 	} else {
 		req.InitPassword = string(val)
 	}
-	req.BootTimeout = durationpb.New(time.Duration(ss.Cluster.Spec.StartupWaitSeconds) * time.Second)
+	return req, nil
+}
 
-	ag, err := p.agentf.New(ctx, ss.Cluster, ss.Primary)
+// cloneRequestFromCluster creates a clone request to clone data from the current
+// primary instance of the source MySQLCluster.
+// The init user is the admin user of the source cluster because the cloned data
+// has the user accounts of the source cluster.
+func (p *managerProcess) cloneRequestFromCluster(ctx context.Context, cluster *mocov1beta2.MySQLCluster) (*agent.CloneRequest, error) {
+	source, err := p.getSourceCluster(ctx, cluster)
 	if err != nil {
-		return false, fmt.Errorf("failed to connect to moco-agent for instance %d: %w", ss.Primary, err)
+		return nil, err
+	}
+	passwd, err := p.getSourcePassword(ctx, source)
+	if err != nil {
+		return nil, err
 	}
-	defer func() { _ = ag.Close() }()
 
-	log := logFromContext(ctx)
-	log.Info("begin cloning data", "source", req.Host)
-	if _, err := ag.Clone(ctx, req); err != nil {
-		log.Error(err, "clone failed", "source", req.Host)
-		return false, fmt.Errorf("failed to clone data from %s: %w", req.Host, err)
+	return &agent.CloneRequest{
+		Host:         source.PodHostname(source.Status.CurrentPrimaryIndex),
+		Port:         constants.MySQLPort,
+		User:         constants.CloneDonorUser,
+		Password:     passwd.Donor(),
+		InitUser:     constants.AdminUser,
+		InitPassword: passwd.Admin(),
+	}, nil
+}
+
+// getSourceCluster returns the MySQLCluster referred by `spec.replicationSource`.
+// A MySQLCluster in another namespace can be referred only if its
+// `moco.cybozu.com/replication-allowed-namespaces` annotation lists the namespace.
+func (p *managerProcess) getSourceCluster(ctx context.Context, cluster *mocov1beta2.MySQLCluster) (*mocov1beta2.MySQLCluster, error) {
+	name, ok := cluster.ReplicationSourceCluster()
+	if !ok {
+		return nil, errors.New("replicationSource is not specified")
+	}
+	if name.Namespace == cluster.Namespace && name.Name == cluster.Name {
+		return nil, errors.New("replicationSource refers to the cluster itself")
 	}
 
-	log.Info("clone succeeded", "source", req.Host)
+	source := &mocov1beta2.MySQLCluster{}
+	if err := p.reader.Get(ctx, name, source); err != nil {
+		return nil, fmt.Errorf("failed to get the source cluster %s: %w", name.String(), err)
+	}
+	if name.Namespace != cluster.Namespace && !replicationAllowed(source, cluster.Namespace) {
+		return nil, fmt.Errorf("the source cluster %s does not allow replication to namespace %s", name.String(), cluster.Namespace)
+	}
+	if source.Status.SyncedReplicas == 0 {
+		return nil, fmt.Errorf("the source cluster %s is not ready", name.String())
+	}
+	return source, nil
+}
 
-	// wait until the instance restarts after clone
-	op := ss.DBOps[ss.Primary]
-	time.Sleep(waitForCloneRestartDuration)
-	for range 60 {
-		select {
-		case <-time.After(1 * time.Second):
-		case <-ctx.Done():
-			return false, ctx.Err()
+func replicationAllowed(source *mocov1beta2.MySQLCluster, namespace string) bool {
+	for _, ns := range strings.Split(source.Annotations[constants.AnnReplicationAllowedNamespaces], ",") {
+		if strings.TrimSpace(ns) == namespace {
+			return true
 		}
+	}
+	return false
+}
 
-		_, err := op.GetStatus(ctx)
-		if err == nil {
-			break
-		}
+func (p *managerProcess) getSourcePassword(ctx context.Context, source *mocov1beta2.MySQLCluster) (*password.MySQLPassword, error) {
+	secret := &corev1.Secret{}
+	name := client.ObjectKey{Namespace: source.Namespace, Name: source.UserSecretName()}
+	if err := p.client.Get(ctx, name, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name.String(), err)
 	}
-	return true, nil
+	return password.NewMySQLPasswordFromSecret(secret)
 }
 
 func (p *managerProcess) switchover(ctx context.Context, ss *StatusSet) error {
 	log := logFromContext(ctx)
 	log.Info("begin switchover the primary", "current", ss.Primary, "next", ss.Candidate)
 
+	if err := p.runPreSwitchoverHooks(ctx, ss); err != nil {
+		return err
+	}
+
+	if drainGracePeriod(ss.Cluster) > 0 {
+		if err := p.drainPrimary(ctx, ss); err != nil {
+			p.restorePrimaryRoleLabel(ctx, ss)
+			return err
+		}
+	}
+
+	if ss.Cluster.IsGroupReplication() {
+		// the group makes the old primary read-only and lets the candidate apply all the transactions.
+		uuid := ss.MySQLStatus[ss.Candidate].GlobalVariables.UUID
+		if err := ss.DBOps[ss.Primary].SetGroupPrimary(ctx, uuid); err != nil {
+			p.restorePrimaryRoleLabel(ctx, ss)
+			return err
+		}
+	} else if err := p.handOverPrimary(ctx, ss); err != nil {
+		p.restorePrimaryRoleLabel(ctx, ss)
+		return err
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cluster.Status.CurrentPrimaryIndex = ss.Candidate
+		cluster.Status.RoleChangeHistory = appendRoleChange(cluster.Status.RoleChangeHistory, mocov1beta2.RoleChangeSwitchover, ss.Primary, ss.Candidate, manualSwitchover(ss))
+		cluster.Status.SwitchoverHooks = nil
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set the current primary index: %w", err)
+	}
+
+	p.metrics.switchoverCount.Inc()
+
+	// the switchover has been done, so the post hooks do not block the new primary from becoming writable.
+	p.startPostSwitchoverHooks(ctx, ss.Cluster, hookPhasePost, ss.Primary, ss.Candidate)
+
+	if err := p.removeAnnDemote(ctx, ss); err != nil {
+		return err
+	}
+	log.Info("switchover finished", "primary", ss.Candidate)
+	return nil
+}
+
+// drainPrimary stops routing new connections to the primary by removing its role label,
+// and kills the client connections after they become idle or the grace period expires.
+// The role label is added back by `configure` after the switchover, or by `restorePrimaryRoleLabel`
+// if the switchover fails.
+func (p *managerProcess) drainPrimary(ctx context.Context, ss *StatusSet) error {
+	log := logFromContext(ctx)
+
+	pod := ss.Pods[ss.Primary]
+	if _, ok := pod.Labels[constants.LabelMocoRole]; ok {
+		modified := pod.DeepCopy()
+		delete(modified.Labels, constants.LabelMocoRole)
+		if err := p.client.Patch(ctx, modified, client.MergeFrom(pod)); err != nil {
+			return fmt.Errorf("failed to remove %s label from %s/%s: %w", constants.LabelMocoRole, pod.Namespace, pod.Name, err)
+		}
+		ss.Pods[ss.Primary] = modified
+		time.Sleep(roleChangeWait(ss.Cluster))
+	}
+
+	log.Info("draining the connections of the primary", "instance", ss.Primary, "gracePeriod", drainGracePeriod(ss.Cluster).String())
+	if err := ss.DBOps[ss.Primary].KillConnections(ctx, primaryDrainOptions(ss.Cluster)); err != nil {
+		return fmt.Errorf("failed to drain connections in instance %d: %w", ss.Primary, err)
+	}
+	return nil
+}
+
+// restorePrimaryRoleLabel adds back the role label removed by `drainPrimary` when the switchover fails
+// so that the clients are not cut off from the primary while the switchover is retried.
+// A failure is only logged because the label is also restored by `configure`.
+func (p *managerProcess) restorePrimaryRoleLabel(ctx context.Context, ss *StatusSet) {
+	pod := ss.Pods[ss.Primary]
+	if pod.Labels[constants.LabelMocoRole] == constants.RolePrimary {
+		return
+	}
+	modified := pod.DeepCopy()
+	if modified.Labels == nil {
+		modified.Labels = make(map[string]string)
+	}
+	modified.Labels[constants.LabelMocoRole] = constants.RolePrimary
+	if err := p.client.Patch(ctx, modified, client.MergeFrom(pod)); err != nil {
+		logFromContext(ctx).Error(err, "failed to restore the role label of the primary", "instance", ss.Primary)
+		return
+	}
+	ss.Pods[ss.Primary] = modified
+}
+
+// handOverPrimary makes the primary read-only and waits for the candidate
+// to execute all the transactions of the primary.
+func (p *managerProcess) handOverPrimary(ctx context.Context, ss *StatusSet) error {
+	log := logFromContext(ctx)
 	pdb := ss.DBOps[ss.Primary]
 
-	// Determine the switchover timeout based on `PreStopSeconds`
+	// The switchover timeout is less than `PreStopSeconds`.
 	// If the switchover takes longer than PreStopSeconds, the switchover will fail and failover will occur.
-	preStopSeconds, err := strconv.Atoi(constants.PreStopSeconds)
+	readOnlyTimeout, err := readOnlyTimeout(ss.Cluster)
 	if err != nil {
 		return err
 	}
-	switchOverTimeoutSeconds := preStopSeconds / 2
 
 	// SetReadOnly waits for a running DML.
 	// Therefore, if it waits for a long time, deleteGracePeriodSeconds may be reached.
@@ -170,14 +366,14 @@ func (p *managerProcess) switchover(ctx context.Context, ss *StatusSet) error {
 	case err := <-done:
 		if err != nil {
 			// If SetReadOnly fails, kill connections and retry switchover.
-			if kerr := pdb.KillConnections(ctx); kerr != nil {
+			if kerr := pdb.KillConnections(ctx, dbop.KillOptions{}); kerr != nil {
 				return fmt.Errorf("failed to make instance %d read-only: %w, and failed to kill connections: %w", ss.Primary, err, kerr)
 			}
 			return fmt.Errorf("failed to make instance %d read-only: %w", ss.Primary, err)
 		}
-	case <-time.After(time.Duration(switchOverTimeoutSeconds) * time.Second):
+	case <-time.After(readOnlyTimeout):
 		log.Info("setReadOnly is taking too long, kill connections", "instance", ss.Primary)
-		if err := pdb.KillConnections(ctx); err != nil {
+		if err := pdb.KillConnections(ctx, dbop.KillOptions{}); err != nil {
 			return fmt.Errorf("failed to kill connections in instance %d: %w", ss.Primary, err)
 		}
 		err := <-done
@@ -187,7 +383,7 @@ func (p *managerProcess) switchover(ctx context.Context, ss *StatusSet) error {
 	}
 
 	time.Sleep(100 * time.Millisecond)
-	if err := pdb.KillConnections(ctx); err != nil {
+	if err := pdb.KillConnections(ctx, dbop.KillOptions{}); err != nil {
 		return fmt.Errorf("failed to kill connections in instance %d: %w", ss.Primary, err)
 	}
 
@@ -196,34 +392,24 @@ func (p *managerProcess) switchover(ctx context.Context, ss *StatusSet) error {
 		return fmt.Errorf("failed to get the primary status: %w", err)
 	}
 
-	err = ss.DBOps[ss.Candidate].WaitForGTID(ctx, pst.GlobalVariables.ExecutedGTID, timeoutSeconds)
-	if err != nil {
-		return err
-	}
+	return ss.DBOps[ss.Candidate].WaitForGTID(ctx, pst.GlobalVariables.ExecutedGTID, gtidWaitTimeoutSeconds(ss.Cluster))
+}
 
-	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
-		cluster := &mocov1beta2.MySQLCluster{}
-		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
-			return err
-		}
-		cluster.Status.CurrentPrimaryIndex = ss.Candidate
-		return p.client.Status().Update(ctx, cluster)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to set the current primary index: %w", err)
+// removeAnnDemote removes the annotations to request a switchover from the primary Pod.
+func (p *managerProcess) removeAnnDemote(ctx context.Context, ss *StatusSet) error {
+	ppod := ss.Pods[ss.Primary]
+	_, demote := ppod.Annotations[constants.AnnDemote]
+	_, target := ppod.Annotations[constants.AnnSwitchoverTo]
+	if !demote && !target {
+		return nil
 	}
 
-	p.metrics.switchoverCount.Inc()
-
-	ppod := ss.Pods[ss.Primary]
-	if _, ok := ppod.Annotations[constants.AnnDemote]; ok {
-		newPod := ppod.DeepCopy()
-		delete(newPod.Annotations, constants.AnnDemote)
-		if err := p.client.Patch(ctx, newPod, client.MergeFrom(ppod)); err != nil {
-			return fmt.Errorf("failed to remove moco.cybozu.com/demote annotation: %w", err)
-		}
+	newPod := ppod.DeepCopy()
+	delete(newPod.Annotations, constants.AnnDemote)
+	delete(newPod.Annotations, constants.AnnSwitchoverTo)
+	if err := p.client.Patch(ctx, newPod, client.MergeFrom(ppod)); err != nil {
+		return fmt.Errorf("failed to remove moco.cybozu.com/demote annotation: %w", err)
 	}
-	log.Info("switchover finished", "primary", ss.Candidate)
 	return nil
 }
 
@@ -231,6 +417,17 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 	log := logFromContext(ctx)
 	log.Info("begin failover the primary", "current", ss.Primary)
 
+	// fence the old primary not to accept writes if it comes back.
+	// This is done only once for a failover even if the failover is retried.
+	if primaryFenced(ss) {
+		log.Info("the old primary has already been fenced", "instance", ss.Primary)
+	} else {
+		p.fence(ctx, ss)
+		if err := p.setFencedPrimary(ctx, ss); err != nil {
+			return err
+		}
+	}
+
 	// stop all replica IO threads
 	for i, ist := range ss.MySQLStatus {
 		if i == ss.Primary {
@@ -248,16 +445,7 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 	time.Sleep(100 * time.Millisecond)
 	candidates := make([]*dbop.MySQLInstanceStatus, len(ss.MySQLStatus))
 	var op dbop.Operator
-	for i, ist := range ss.MySQLStatus {
-		if i == ss.Primary {
-			continue
-		}
-		if ist == nil {
-			continue
-		}
-		if ist.IsErrant {
-			continue
-		}
+	for _, i := range failoverSources(ss) {
 		op = ss.DBOps[i]
 		newStatus, err := op.GetStatus(ctx)
 		if err != nil {
@@ -266,7 +454,7 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 		candidates[i] = newStatus
 	}
 
-	candidate, err := dbop.FindTopRunner(ctx, op, candidates)
+	candidate, err := chooseFailoverCandidate(ctx, ss, op, candidates)
 	if err != nil {
 		return fmt.Errorf("failed to choose the next primary: %w", err)
 	}
@@ -274,7 +462,7 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 
 	gtid := candidates[candidate].ReplicaStatus.RetrievedGtidSet
 	log.Info("waiting for the new primary to execute all retrieved transactions", "index", candidate, "gtid", gtid)
-	err = ss.DBOps[candidate].WaitForGTID(ctx, gtid, timeoutSeconds)
+	err = ss.DBOps[candidate].WaitForGTID(ctx, gtid, gtidWaitTimeoutSeconds(ss.Cluster))
 	if err != nil {
 		return err
 	}
@@ -285,6 +473,8 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 			return err
 		}
 		cluster.Status.CurrentPrimaryIndex = candidate
+		cluster.Status.FencedPrimary = nil
+		cluster.Status.RoleChangeHistory = appendRoleChange(cluster.Status.RoleChangeHistory, mocov1beta2.RoleChangeFailover, ss.Primary, candidate, failoverApproved(ss.Cluster))
 		return p.client.Status().Update(ctx, cluster)
 	})
 	if err != nil {
@@ -297,6 +487,218 @@ func (p *managerProcess) failover(ctx context.Context, ss *StatusSet) error {
 	return nil
 }
 
+var defaultFencingStrategies = []mocov1beta2.FencingStrategy{
+	mocov1beta2.FencingRemoveRoleLabel,
+	mocov1beta2.FencingSuperReadOnly,
+}
+
+// fence applies the fencing strategies to the old primary instance.
+// Fencing is done on a best-effort basis because the old primary is likely to be unreachable;
+// the result of each action is recorded as an Event.
+func (p *managerProcess) fence(ctx context.Context, ss *StatusSet) {
+	log := logFromContext(ctx)
+
+	strategies := ss.Cluster.Spec.FencingStrategies
+	if len(strategies) == 0 {
+		strategies = defaultFencingStrategies
+	}
+
+	for _, s := range strategies {
+		var err error
+		switch s {
+		case mocov1beta2.FencingRemoveRoleLabel:
+			err = p.fenceByRoleLabel(ctx, ss)
+		case mocov1beta2.FencingDeletePod:
+			err = p.fenceByDeletingPod(ctx, ss)
+		case mocov1beta2.FencingNetworkPolicy:
+			err = p.fenceByNetworkPolicy(ctx, ss)
+		case mocov1beta2.FencingSuperReadOnly:
+			if ss.MySQLStatus[ss.Primary] == nil {
+				log.Info("skip fencing by super_read_only because the old primary is unreachable", "instance", ss.Primary)
+				continue
+			}
+			err = ss.DBOps[ss.Primary].SetReadOnly(ctx, true)
+		default:
+			err = fmt.Errorf("unknown fencing strategy: %s", s)
+		}
+		if err != nil {
+			log.Error(err, "failed to fence the old primary", "instance", ss.Primary, "strategy", s)
+			event.FenceFailed.Emit(ss.Cluster, p.recorder, ss.Primary, s, err)
+			continue
+		}
+		log.Info("fenced the old primary", "instance", ss.Primary, "strategy", s)
+		event.FenceSucceeded.Emit(ss.Cluster, p.recorder, ss.Primary, s)
+	}
+}
+
+// primaryFenced returns true if the current primary has been fenced for the ongoing failover
+// with the current spec.
+func primaryFenced(ss *StatusSet) bool {
+	st := ss.Cluster.Status.FencedPrimary
+	return st != nil && st.Index == ss.Primary && st.Generation == ss.Cluster.Generation
+}
+
+func (p *managerProcess) setFencedPrimary(ctx context.Context, ss *StatusSet) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cluster.Status.FencedPrimary = &mocov1beta2.FencedPrimaryStatus{
+			Index:      ss.Primary,
+			Generation: ss.Cluster.Generation,
+			Time:       metav1.Now(),
+		}
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the fenced primary: %w", err)
+	}
+	return nil
+}
+
+func (p *managerProcess) fenceByRoleLabel(ctx context.Context, ss *StatusSet) error {
+	pod := ss.Pods[ss.Primary]
+	if _, ok := pod.Labels[constants.LabelMocoRole]; !ok {
+		return nil
+	}
+	newPod := pod.DeepCopy()
+	delete(newPod.Labels, constants.LabelMocoRole)
+	if err := p.client.Patch(ctx, newPod, client.MergeFrom(pod)); err != nil {
+		return fmt.Errorf("failed to remove the role label: %w", err)
+	}
+	return nil
+}
+
+func (p *managerProcess) fenceByDeletingPod(ctx context.Context, ss *StatusSet) error {
+	pod := ss.Pods[ss.Primary]
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+	// the PreventDelete annotation is not honored here because the primary is failed.
+	if err := p.client.Delete(ctx, pod, client.Preconditions{UID: &pod.UID}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the pod: %w", err)
+	}
+	return nil
+}
+
+// fenceByNetworkPolicy creates a NetworkPolicy that denies the ingress traffic to the old primary
+// except for the ports that MOCO uses to manage the instance.
+func (p *managerProcess) fenceByNetworkPolicy(ctx context.Context, ss *StatusSet) error {
+	np := &networkingv1.NetworkPolicy{}
+	np.Namespace = ss.Cluster.Namespace
+	np.Name = ss.Cluster.FencingNetworkPolicyName(ss.Primary)
+	np.Labels = map[string]string{
+		constants.LabelAppName:      constants.AppNameMySQL,
+		constants.LabelAppInstance:  ss.Cluster.Name,
+		constants.LabelAppCreatedBy: constants.AppCreator,
+	}
+	if err := controllerutil.SetControllerReference(ss.Cluster, np, p.client.Scheme()); err != nil {
+		return err
+	}
+
+	adminPort := intstr.FromInt32(constants.MySQLAdminPort)
+	agentPort := intstr.FromInt32(constants.AgentPort)
+	np.Spec = networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				appsv1.StatefulSetPodNameLabel: ss.Cluster.PodName(ss.Primary),
+			},
+		},
+		PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Port: &adminPort},
+				{Port: &agentPort},
+			},
+		}},
+	}
+	if err := p.client.Create(ctx, np); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create NetworkPolicy: %w", err)
+	}
+	return nil
+}
+
+// removeFencingNetworkPolicies deletes the NetworkPolicies created by `fenceByNetworkPolicy`.
+func (p *managerProcess) removeFencingNetworkPolicies(ctx context.Context, ss *StatusSet) error {
+	nps := &networkingv1.NetworkPolicyList{}
+	if err := p.client.List(ctx, nps, client.InNamespace(ss.Cluster.Namespace), client.MatchingLabels{
+		constants.LabelAppName:      constants.AppNameMySQL,
+		constants.LabelAppInstance:  ss.Cluster.Name,
+		constants.LabelAppCreatedBy: constants.AppCreator,
+	}); err != nil {
+		return fmt.Errorf("failed to list NetworkPolicies: %w", err)
+	}
+	for i := range nps.Items {
+		np := &nps.Items[i]
+		logFromContext(ctx).Info("remove fencing NetworkPolicy", "name", np.Name)
+		if err := p.client.Delete(ctx, np); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NetworkPolicy %s: %w", np.Name, err)
+		}
+	}
+	return nil
+}
+
+// previewFailover returns the index of the instance that `failover` would promote
+// based on the current replication status.  Unlike `failover`, this does not stop
+// replica IO threads, so the actual choice may differ if replicas are still receiving
+// transactions.
+func previewFailover(ctx context.Context, ss *StatusSet) (int, error) {
+	candidates := make([]*dbop.MySQLInstanceStatus, len(ss.MySQLStatus))
+	var op dbop.Operator
+	for _, i := range failoverSources(ss) {
+		op = ss.DBOps[i]
+		candidates[i] = ss.MySQLStatus[i]
+	}
+	return chooseFailoverCandidate(ctx, ss, op, candidates)
+}
+
+// failoverSources returns the replicas whose GTID sets are compared to choose the new primary
+// on a failover, that is, reachable replicas that are neither errant nor asynchronous.
+func failoverSources(ss *StatusSet) []int {
+	var indices []int
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary || ist == nil || ist.IsErrant || isAsync(ss, i) {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+// chooseFailoverCandidate returns the most preferable promotable instance among
+// the instances that have the most advanced GTID set in `candidates`.
+// Instances that must never be promoted are still taken into account to find the
+// most advanced GTID set so that no transactions are lost.
+func chooseFailoverCandidate(ctx context.Context, ss *StatusSet, op dbop.Operator, candidates []*dbop.MySQLInstanceStatus) (int, error) {
+	if op == nil {
+		return -1, dbop.ErrNoTopRunner
+	}
+	runners, err := dbop.FindTopRunners(ctx, op, candidates)
+	if err != nil {
+		return -1, err
+	}
+	runners = slices.DeleteFunc(runners, func(i int) bool {
+		return !isPromotable(ss, i)
+	})
+	if len(runners) == 0 {
+		return -1, errors.New("no promotable instance has all the transactions")
+	}
+	return chooseCandidate(ss, runners), nil
+}
+
+func (p *managerProcess) removeAnnApproveFailover(ctx context.Context, ss *StatusSet) error {
+	if _, ok := ss.Cluster.Annotations[constants.AnnApproveFailover]; !ok {
+		return nil
+	}
+	newCluster := ss.Cluster.DeepCopy()
+	delete(newCluster.Annotations, constants.AnnApproveFailover)
+	if err := p.client.Patch(ctx, newCluster, client.MergeFrom(ss.Cluster)); err != nil {
+		return fmt.Errorf("failed to remove moco.cybozu.com/approve-failover annotation: %w", err)
+	}
+	return nil
+}
+
 func (p *managerProcess) removeRoleLabel(ctx context.Context, ss *StatusSet) ([]int, error) {
 	var noRoles []int
 	for i, pod := range ss.Pods {
@@ -383,8 +785,10 @@ func (p *managerProcess) addAnnPreventDelete(ctx context.Context, ss *StatusSet)
 	return nil
 }
 
-func (p *managerProcess) configure(ctx context.Context, ss *StatusSet) (bool, error) {
-	redo := false
+func (p *managerProcess) configure(ctx context.Context, ss *StatusSet) (redo bool, e error) {
+	if ss.Cluster.IsGroupReplication() {
+		return p.configureGroup(ctx, ss)
+	}
 
 	// remove old role label from mysql pods whose role is changed
 	// NOTE:
@@ -406,16 +810,35 @@ func (p *managerProcess) configure(ctx context.Context, ss *StatusSet) (bool, er
 	}
 	if len(alive) > 0 {
 		// I hope the backend pods of primary and replica services will be updated during this sleep.
-		time.Sleep(waitForRoleChangeDuration)
+		time.Sleep(roleChangeWait(ss.Cluster))
 	}
+
+	// The connections made to the new primary while it was a replica are killed immediately
+	// before it becomes writable.  The other instances are read-only, so their connections
+	// are drained in the background not to delay the writes to the new primary.
+	var draining []int
 	for _, i := range alive {
-		if err := ss.DBOps[i].KillConnections(ctx); err != nil {
+		if i != ss.Primary {
+			draining = append(draining, i)
+			continue
+		}
+		if err := ss.DBOps[i].KillConnections(ctx, dbop.KillOptions{}); err != nil {
 			return false, fmt.Errorf("failed to kill connections in instance %d: %w", i, err)
 		}
 	}
+	drained := make(chan error, 1)
+	go func() {
+		drained <- drainConnections(ctx, ss, draining)
+	}()
+	// the connections of the instances must not be used after returning.
+	defer func() {
+		if err := <-drained; err != nil && e == nil {
+			redo, e = false, err
+		}
+	}()
 
 	// configure primary instance
-	if ss.Cluster.Spec.ReplicationSourceSecretName != nil {
+	if ss.Cluster.HasReplicationSource() {
 		r, err := p.configureIntermediatePrimary(ctx, ss)
 		if err != nil {
 			return false, err
@@ -451,7 +874,7 @@ func (p *managerProcess) configure(ctx context.Context, ss *StatusSet) (bool, er
 	}
 
 	// make the primary writable if it is not an intermediate primary
-	if ss.Cluster.Spec.ReplicationSourceSecretName == nil {
+	if !ss.Cluster.HasReplicationSource() {
 		pst := ss.MySQLStatus[ss.Primary]
 		op := ss.DBOps[ss.Primary]
 		if pst.GlobalVariables.ReadOnly {
@@ -466,6 +889,22 @@ func (p *managerProcess) configure(ctx context.Context, ss *StatusSet) (bool, er
 	return redo, nil
 }
 
+// drainConnections drains the client connections of the instances in parallel
+// so that the grace period is waited only once.
+func drainConnections(ctx context.Context, ss *StatusSet, indices []int) error {
+	errs := make([]error, len(indices))
+	var wg sync.WaitGroup
+	for n, i := range indices {
+		wg.Go(func() {
+			if err := ss.DBOps[i].KillConnections(ctx, drainOptions(ss.Cluster)); err != nil {
+				errs[n] = fmt.Errorf("failed to kill connections in instance %d: %w", i, err)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
 func (p *managerProcess) configureIntermediatePrimary(ctx context.Context, ss *StatusSet) (redo bool, e error) {
 	log := logFromContext(ctx)
 	pst := ss.MySQLStatus[ss.Primary]
@@ -478,30 +917,60 @@ func (p *managerProcess) configureIntermediatePrimary(ctx context.Context, ss *S
 		}
 	}
 
+	var ai dbop.AccessInfo
+	var err error
+	if ss.Cluster.Spec.ReplicationSource != nil {
+		ai, err = p.accessInfoFromCluster(ctx, ss.Cluster)
+	} else {
+		ai, err = p.accessInfoFromSecret(ctx, ss.Cluster)
+	}
+	if err != nil {
+		return false, err
+	}
+	if pst.ReplicaStatus == nil || pst.ReplicaStatus.ReplicaIORunning != "Yes" || pst.ReplicaStatus.SourceHost != ai.Host {
+		redo = true
+		log.Info("start replication", "instance", ss.Primary, "semisync", false)
+		if err := op.ConfigureReplica(ctx, ai, false, 0); err != nil {
+			return false, err
+		}
+	}
+	return redo, e
+}
+
+func (p *managerProcess) accessInfoFromSecret(ctx context.Context, cluster *mocov1beta2.MySQLCluster) (dbop.AccessInfo, error) {
 	secret := &corev1.Secret{}
-	name := client.ObjectKey{Namespace: ss.Cluster.Namespace, Name: *ss.Cluster.Spec.ReplicationSourceSecretName}
+	name := client.ObjectKey{Namespace: cluster.Namespace, Name: *cluster.Spec.ReplicationSourceSecretName}
 	if err := p.client.Get(ctx, name, secret); err != nil {
-		return false, fmt.Errorf("failed to get secret %s: %w", name.String(), err)
+		return dbop.AccessInfo{}, fmt.Errorf("failed to get secret %s: %w", name.String(), err)
 	}
 	port, err := strconv.Atoi(string(secret.Data[constants.CloneSourcePortKey]))
 	if err != nil {
-		return false, fmt.Errorf("invalid port number in secret %s: %w", name.String(), err)
+		return dbop.AccessInfo{}, fmt.Errorf("invalid port number in secret %s: %w", name.String(), err)
 	}
 
-	ai := dbop.AccessInfo{
+	return dbop.AccessInfo{
 		Host:     string(secret.Data[constants.CloneSourceHostKey]),
 		Port:     port,
 		User:     string(secret.Data[constants.CloneSourceUserKey]),
 		Password: string(secret.Data[constants.CloneSourcePasswordKey]),
+	}, nil
+}
+
+func (p *managerProcess) accessInfoFromCluster(ctx context.Context, cluster *mocov1beta2.MySQLCluster) (dbop.AccessInfo, error) {
+	source, err := p.getSourceCluster(ctx, cluster)
+	if err != nil {
+		return dbop.AccessInfo{}, err
 	}
-	if pst.ReplicaStatus == nil || pst.ReplicaStatus.ReplicaIORunning != "Yes" || pst.ReplicaStatus.SourceHost != ai.Host {
-		redo = true
-		log.Info("start replication", "instance", ss.Primary, "semisync", false)
-		if err := op.ConfigureReplica(ctx, ai, false); err != nil {
-			return false, err
-		}
+	passwd, err := p.getSourcePassword(ctx, source)
+	if err != nil {
+		return dbop.AccessInfo{}, err
 	}
-	return redo, e
+	return dbop.AccessInfo{
+		Host:     source.PodHostname(source.Status.CurrentPrimaryIndex),
+		Port:     constants.MySQLPort,
+		User:     constants.ReplicationUser,
+		Password: passwd.Replicator(),
+	}, nil
 }
 
 func (p *managerProcess) configurePrimary(ctx context.Context, ss *StatusSet) (redo bool, e error) {
@@ -526,28 +995,79 @@ func (p *managerProcess) configurePrimary(ctx context.Context, ss *StatusSet) (r
 		}
 	}
 
-	if ss.Cluster.Spec.Replicas == 1 {
+	if semiSyncPrimaryConfigured(ss) {
 		return redo, e
 	}
 
-	waitFor := int(ss.Cluster.Spec.Replicas / 2)
-	if !pst.GlobalVariables.SemiSyncMasterEnabled || pst.GlobalVariables.WaitForSlaveCount != waitFor {
-		redo = true
-		log.Info("enable semi-sync primary")
-		if err := op.ConfigurePrimary(ctx, waitFor); err != nil {
+	redo = true
+	if semiSyncInstances(ss) == 1 {
+		// semi-sync replication might have been enabled before scale-in.
+		log.Info("disable semi-sync primary")
+		if err := op.ConfigurePrimary(ctx, 0, 0); err != nil {
 			return false, err
 		}
+		return redo, e
+	}
+
+	waitFor := semiSyncWaitCount(ss)
+	timeout := semiSyncTimeoutSeconds(ss.Cluster)
+	log.Info("enable semi-sync primary", "waitFor", waitFor, "timeoutSeconds", timeout)
+	if err := op.ConfigurePrimary(ctx, waitFor, timeout); err != nil {
+		return false, err
 	}
 	return redo, e
 }
 
+// semiSyncPrimaryConfigured returns true if the semi-synchronous replication
+// settings of the primary match the spec.
+func semiSyncPrimaryConfigured(ss *StatusSet) bool {
+	gv := ss.MySQLStatus[ss.Primary].GlobalVariables
+	if semiSyncInstances(ss) == 1 {
+		return !gv.SemiSyncMasterEnabled
+	}
+	return gv.SemiSyncMasterEnabled &&
+		gv.WaitForSlaveCount == semiSyncWaitCount(ss) &&
+		gv.SemiSyncMasterTimeout == semiSyncTimeoutSeconds(ss.Cluster)*1000
+}
+
+// needReconfigure returns true if the replication settings of a healthy cluster
+// do not match the spec, e.g. after `spec.semiSync` or `spec.readPoolReplicas` is changed.
+func needReconfigure(ss *StatusSet) bool {
+	if ss.Cluster.IsGroupReplication() {
+		return needGroupReconfigure(ss)
+	}
+	intermediate := ss.Cluster.HasReplicationSource()
+	if !intermediate && !semiSyncPrimaryConfigured(ss) {
+		return true
+	}
+	// follow the switchover of the source cluster
+	if pst := ss.MySQLStatus[ss.Primary]; ss.SourceHost != "" && pst != nil {
+		if pst.ReplicaStatus == nil || pst.ReplicaStatus.SourceHost != ss.SourceHost {
+			return true
+		}
+	}
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary || ist == nil || ist.ReplicaStatus == nil {
+			continue
+		}
+		semisync := !intermediate && !isAsync(ss, i)
+		if ist.GlobalVariables.SemiSyncSlaveEnabled != semisync || ist.ReplicaStatus.SQLDelay != replicationDelay(ss.Cluster, i) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *managerProcess) configureReplica(ctx context.Context, ss *StatusSet, index int) (redo bool, e error) {
 	log := logFromContext(ctx)
 	st := ss.MySQLStatus[index]
 	op := ss.DBOps[index]
 
-	// for an errant replica, stop replication
+	// for an errant replica, re-clone it if requested.  Otherwise, stop replication.
 	if st.IsErrant {
+		if needReclone(ss, index) {
+			return p.resetErrantReplica(ctx, ss, index)
+		}
 		if st.ReplicaStatus == nil {
 			return redo, e
 		}
@@ -566,7 +1086,7 @@ func (p *managerProcess) configureReplica(ctx context.Context, ss *StatusSet, in
 
 		// When a primary is demoted due to network failure, old connections via the primary service may remain.
 		// In rare cases, the old connections running write events block `set super_read_only=1`.
-		if err := op.KillConnections(ctx); err != nil {
+		if err := op.KillConnections(ctx, dbop.KillOptions{}); err != nil {
 			return false, fmt.Errorf("failed to kill connections in instance %d: %w", index, err)
 		}
 
@@ -612,7 +1132,7 @@ func (p *managerProcess) configureReplica(ctx context.Context, ss *StatusSet, in
 		log.Info("clone succeeded", "instance", index)
 
 		// wait until the instance restarts after clone
-		time.Sleep(waitForCloneRestartDuration)
+		time.Sleep(cloneRestartWait(ss.Cluster))
 		for range 60 {
 			select {
 			case <-time.After(1 * time.Second):
@@ -641,13 +1161,468 @@ func (p *managerProcess) configureReplica(ctx context.Context, ss *StatusSet, in
 		User:     constants.ReplicationUser,
 		Password: ss.Password.Replicator(),
 	}
-	semisync := ss.Cluster.Spec.ReplicationSourceSecretName == nil
-	if st.ReplicaStatus == nil || st.ReplicaStatus.ReplicaIORunning != "Yes" || st.ReplicaStatus.SourceHost != ai.Host || st.GlobalVariables.SemiSyncSlaveEnabled != semisync {
+	delay := replicationDelay(ss.Cluster, index)
+	semisync := !ss.Cluster.HasReplicationSource() && !isAsync(ss, index)
+	if st.ReplicaStatus == nil || st.ReplicaStatus.ReplicaIORunning != "Yes" || st.ReplicaStatus.SourceHost != ai.Host || st.GlobalVariables.SemiSyncSlaveEnabled != semisync || st.ReplicaStatus.SQLDelay != delay {
 		redo = true
-		log.Info("start replication", "instance", index, "semisync", semisync)
-		if err := op.ConfigureReplica(ctx, ai, semisync); err != nil {
+		log.Info("start replication", "instance", index, "semisync", semisync, "delay", delay)
+		if err := op.ConfigureReplica(ctx, ai, semisync, delay); err != nil {
 			return false, err
 		}
 	}
 	return redo, e
 }
+
+func needReclone(ss *StatusSet, index int) bool {
+	if ss.Cluster.Spec.ErrantReplicaPolicy == mocov1beta2.ErrantReplicaPolicyReclone {
+		return true
+	}
+	pod := ss.Pods[index]
+	return pod != nil && pod.Annotations[constants.AnnRecloneErrant] == "true"
+}
+
+// resetErrantReplica resets an errant replica so that `configureReplica` clones the data
+// from the primary next time.  The errant transactions are recorded in MySQLCluster before
+// they are discarded.
+func (p *managerProcess) resetErrantReplica(ctx context.Context, ss *StatusSet, index int) (bool, error) {
+	log := logFromContext(ctx)
+	st := ss.MySQLStatus[index]
+
+	if ss.ExecutedGTID == "" {
+		// no data to clone
+		return false, nil
+	}
+	errantSet, err := ss.DBOps[ss.Primary].SubtractGTID(ctx, st.GlobalVariables.ExecutedGTID, ss.ExecutedGTID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get errant transactions of instance %d: %w", index, err)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		record := mocov1beta2.ErrantReplicaRecord{
+			Index:         index,
+			ErrantGTIDSet: errantSet,
+			Time:          metav1.Now(),
+		}
+		history := slices.DeleteFunc(cluster.Status.ErrantReplicaHistory, func(r mocov1beta2.ErrantReplicaRecord) bool {
+			return r.Index == index
+		})
+		cluster.Status.ErrantReplicaHistory = append(history, record)
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record errant transactions of instance %d: %w", index, err)
+	}
+
+	log.Info("reset errant replica to re-clone", "instance", index, "errant", errantSet)
+	if err := ss.DBOps[index].ResetReplication(ctx); err != nil {
+		return false, fmt.Errorf("failed to reset instance %d: %w", index, err)
+	}
+	event.ErrantReplicaReset.Emit(ss.Cluster, p.recorder, index, errantSet)
+
+	pod := ss.Pods[index]
+	if _, ok := pod.Annotations[constants.AnnRecloneErrant]; ok {
+		newPod := pod.DeepCopy()
+		delete(newPod.Annotations, constants.AnnRecloneErrant)
+		if err := p.client.Patch(ctx, newPod, client.MergeFrom(pod)); err != nil {
+			return false, fmt.Errorf("failed to remove %s annotation: %w", constants.AnnRecloneErrant, err)
+		}
+	}
+	return true, nil
+}
+
+// scaleIn prepares to remove the instances in `ss.Excess` by decreasing `spec.replicas`.
+// It updates the semi-synchronous replication settings of the primary and
+// detaches the excess instances from the cluster.  When all of them are detached,
+// it sets `ScaleInReady` condition so that the StatefulSet is shrunk.
+func (p *managerProcess) scaleIn(ctx context.Context, ss *StatusSet) (bool, error) {
+	log := logFromContext(ctx)
+
+	redo := false
+	if !ss.Cluster.HasReplicationSource() && !ss.Cluster.IsGroupReplication() {
+		r, err := p.configurePrimary(ctx, ss)
+		if err != nil {
+			return false, err
+		}
+		redo = r
+	}
+
+	for _, index := range ss.Excess {
+		r, err := p.detachInstance(ctx, ss, index)
+		if err != nil {
+			return false, fmt.Errorf("failed to detach instance %d: %w", index, err)
+		}
+		redo = redo || r
+	}
+	if redo {
+		return true, nil
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cond := meta.FindStatusCondition(cluster.Status.Conditions, mocov1beta2.ConditionScaleInReady)
+		if cond != nil && cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == ss.Cluster.Generation {
+			return nil
+		}
+		meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+			Type:               mocov1beta2.ConditionScaleInReady,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: ss.Cluster.Generation,
+			Reason:             "Detached",
+			Message:            fmt.Sprintf("instances %v are detached from the cluster", ss.Excess),
+		})
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to set %s condition: %w", mocov1beta2.ConditionScaleInReady, err)
+	}
+	log.Info("ready to scale in", "instances", ss.Excess)
+	return false, nil
+}
+
+// detachInstance stops the replication of an instance to be removed and resets it
+// so that the instance is cloned again if it is added back to the cluster.
+// In GroupReplication mode, the instance just leaves the group.
+// An instance that is not reachable is regarded as detached because it will be removed anyway.
+func (p *managerProcess) detachInstance(ctx context.Context, ss *StatusSet, index int) (bool, error) {
+	log := logFromContext(ctx)
+
+	op, err := p.dbf.New(ctx, ss.Cluster, ss.Password, index)
+	if err != nil {
+		return false, err
+	}
+	defer op.Close()
+
+	st, err := op.GetStatus(ctx)
+	if err != nil {
+		log.Info("skip detaching unreachable instance", "instance", index, "error", err.Error())
+		return false, nil
+	}
+	if ss.Cluster.IsGroupReplication() {
+		if len(st.GroupMembers) == 0 {
+			return false, nil
+		}
+		log.Info("leave the group", "instance", index)
+		if err := op.StopGroupReplication(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if st.ReplicaStatus == nil {
+		return false, nil
+	}
+
+	log.Info("detach instance", "instance", index)
+	if err := op.ResetReplication(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func needPromotion(ss *StatusSet) bool {
+	return ss.Cluster.Annotations[constants.AnnPromote] == "true"
+}
+
+func promotionInProgress(ss *StatusSet) bool {
+	st := ss.Cluster.Status.Promotion
+	return st != nil && st.Phase != mocov1beta2.PromotionPhaseCompleted && st.Phase != mocov1beta2.PromotionPhaseCancelled
+}
+
+// needCancelPromotion returns true if `moco.cybozu.com/promote` annotation was removed
+// while the promotion is in progress.
+func needCancelPromotion(ss *StatusSet) bool {
+	return promotionInProgress(ss) && !needPromotion(ss)
+}
+
+// promote promotes the intermediate primary to a writable primary as requested
+// by `moco.cybozu.com/promote` annotation.  It proceeds one phase at a time and
+// records each phase in `status.promotion` so that the cutover can be audited.
+func (p *managerProcess) promote(ctx context.Context, ss *StatusSet) (bool, error) {
+	log := logFromContext(ctx)
+
+	var phase mocov1beta2.PromotionPhase
+	if ss.Cluster.Status.Promotion != nil {
+		phase = ss.Cluster.Status.Promotion.Phase
+	}
+
+	switch phase {
+	case mocov1beta2.PromotionPhaseFencingSource:
+		msg, done, err := p.fenceSource(ctx, ss)
+		if err != nil || !done {
+			return false, err
+		}
+		return true, p.setPromotionPhase(ctx, mocov1beta2.PromotionPhaseCatchingUp, msg)
+
+	case mocov1beta2.PromotionPhaseCatchingUp:
+		gtid, err := p.catchUpSource(ctx, ss)
+		if err != nil {
+			return false, err
+		}
+		return true, p.setPromotionPhase(ctx, mocov1beta2.PromotionPhaseDetaching, "executed all the retrieved transactions: "+gtid)
+
+	case mocov1beta2.PromotionPhaseDetaching:
+		if err := p.detachSource(ctx, ss); err != nil {
+			return false, err
+		}
+		if err := p.resumeSource(ctx, ss); err != nil {
+			return false, err
+		}
+		if err := p.setPromotionPhase(ctx, mocov1beta2.PromotionPhaseCompleted, "stopped the replication and made the primary writable"); err != nil {
+			return false, err
+		}
+		log.Info("promotion completed")
+		event.PromotionSucceeded.Emit(ss.Cluster, p.recorder)
+		return true, p.removeAnnPromote(ctx, ss)
+	}
+
+	// no promotion is in progress.
+	if !ss.Cluster.HasReplicationSource() {
+		if phase != mocov1beta2.PromotionPhaseCompleted && phase != mocov1beta2.PromotionPhaseCancelled {
+			err := errors.New("the cluster has no replication source")
+			log.Info("reject the promotion request", "reason", err.Error())
+			event.PromotionRejected.Emit(ss.Cluster, p.recorder, err)
+		}
+		return true, p.removeAnnPromote(ctx, ss)
+	}
+
+	log.Info("begin promotion of the intermediate primary")
+	return true, p.setPromotionPhase(ctx, mocov1beta2.PromotionPhaseFencingSource, "the promotion was requested")
+}
+
+func (p *managerProcess) setPromotionPhase(ctx context.Context, phase mocov1beta2.PromotionPhase, msg string) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		now := metav1.Now()
+		st := cluster.Status.Promotion
+		if st == nil || phase == mocov1beta2.PromotionPhaseFencingSource {
+			st = &mocov1beta2.PromotionStatus{StartTime: now}
+			cluster.Status.Promotion = st
+		}
+		st.Phase = phase
+		st.History = append(st.History, mocov1beta2.PromotionPhaseRecord{
+			Phase:   phase,
+			Time:    now,
+			Message: msg,
+		})
+		if phase == mocov1beta2.PromotionPhaseCompleted || phase == mocov1beta2.PromotionPhaseCancelled {
+			st.CompletionTime = &now
+			st.StoppedSourceCluster = nil
+		}
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the promotion phase %s: %w", phase, err)
+	}
+	logFromContext(ctx).Info("promotion phase changed", "phase", phase, "message", msg)
+	return nil
+}
+
+// cancelPromotion cancels the promotion in progress.  The clustering of the source cluster
+// is resumed if MOCO has stopped it.  The replication IO thread stopped by the promotion
+// is started again by the ordinary configuration of the intermediate primary.
+func (p *managerProcess) cancelPromotion(ctx context.Context, ss *StatusSet) error {
+	phase := ss.Cluster.Status.Promotion.Phase
+	logFromContext(ctx).Info("cancel the promotion", "phase", phase)
+	if err := p.resumeSource(ctx, ss); err != nil {
+		return err
+	}
+	return p.setPromotionPhase(ctx, mocov1beta2.PromotionPhaseCancelled, fmt.Sprintf("the promotion was cancelled in phase %s", phase))
+}
+
+func (p *managerProcess) setStoppedSourceCluster(ctx context.Context, name types.NamespacedName) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		st := cluster.Status.Promotion
+		if st == nil {
+			return errors.New("the promotion status is not found")
+		}
+		st.StoppedSourceCluster = &mocov1beta2.ClusterReference{Namespace: name.Namespace, Name: name.Name}
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record the source cluster to be stopped: %w", err)
+	}
+	return nil
+}
+
+// resumeSource removes `moco.cybozu.com/clustering-stopped` annotation from the source cluster
+// if MOCO has added it in `fenceSource`.  The annotation added by others is kept.
+func (p *managerProcess) resumeSource(ctx context.Context, ss *StatusSet) error {
+	st := ss.Cluster.Status.Promotion
+	if st == nil || st.StoppedSourceCluster == nil {
+		return nil
+	}
+	name := types.NamespacedName{Namespace: st.StoppedSourceCluster.Namespace, Name: st.StoppedSourceCluster.Name}
+
+	source := &mocov1beta2.MySQLCluster{}
+	if err := p.reader.Get(ctx, name, source); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get the source cluster %s: %w", name.String(), err)
+	}
+	if _, ok := source.Annotations[constants.AnnClusteringStopped]; !ok {
+		return nil
+	}
+
+	newSource := source.DeepCopy()
+	delete(newSource.Annotations, constants.AnnClusteringStopped)
+	logFromContext(ctx).Info("resume clustering of the source cluster", "source", name.String())
+	if err := p.client.Patch(ctx, newSource, client.MergeFrom(source)); err != nil {
+		return fmt.Errorf("failed to resume clustering of the source cluster: %w", err)
+	}
+	return nil
+}
+
+func (p *managerProcess) removeAnnPromote(ctx context.Context, ss *StatusSet) error {
+	if _, ok := ss.Cluster.Annotations[constants.AnnPromote]; !ok {
+		return nil
+	}
+	newCluster := ss.Cluster.DeepCopy()
+	delete(newCluster.Annotations, constants.AnnPromote)
+	if err := p.client.Patch(ctx, newCluster, client.MergeFrom(ss.Cluster)); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", constants.AnnPromote, err)
+	}
+	return nil
+}
+
+// fenceSource makes the replication source read-only if it is a MySQLCluster and reachable.
+// To prevent the source cluster from becoming writable again, the clustering of the source
+// cluster is stopped beforehand.  It returns false while waiting for the clustering to stop.
+func (p *managerProcess) fenceSource(ctx context.Context, ss *StatusSet) (string, bool, error) {
+	log := logFromContext(ctx)
+
+	if ss.Cluster.Spec.ReplicationSource == nil {
+		return "the source is not a MySQLCluster; skipped making it read-only", true, nil
+	}
+
+	// the readiness of the source cluster is not checked here because the source may be down.
+	name, _ := ss.Cluster.ReplicationSourceCluster()
+	source := &mocov1beta2.MySQLCluster{}
+	if err := p.reader.Get(ctx, name, source); err != nil {
+		log.Error(err, "failed to get the source cluster")
+		return fmt.Sprintf("the source cluster %s is not available; skipped making it read-only: %v", name.String(), err), true, nil
+	}
+
+	if source.Annotations[constants.AnnClusteringStopped] != "true" {
+		// record it first so that the annotation is surely removed when the promotion finishes.
+		if err := p.setStoppedSourceCluster(ctx, name); err != nil {
+			return "", false, err
+		}
+		newSource := source.DeepCopy()
+		if newSource.Annotations == nil {
+			newSource.Annotations = make(map[string]string)
+		}
+		newSource.Annotations[constants.AnnClusteringStopped] = "true"
+		log.Info("stop clustering of the source cluster", "source", client.ObjectKeyFromObject(source).String())
+		if err := p.client.Patch(ctx, newSource, client.MergeFrom(source)); err != nil {
+			return "", false, fmt.Errorf("failed to stop clustering of the source cluster: %w", err)
+		}
+		return "", false, nil
+	}
+	if !meta.IsStatusConditionFalse(source.Status.Conditions, mocov1beta2.ConditionClusteringActive) {
+		log.Info("waiting for the clustering of the source cluster to stop")
+		return "", false, nil
+	}
+
+	passwd, err := p.getSourcePassword(ctx, source)
+	if err != nil {
+		return "", false, err
+	}
+	op, err := p.dbf.New(ctx, source, passwd, source.Status.CurrentPrimaryIndex)
+	if err != nil {
+		return "", false, err
+	}
+	defer op.Close()
+
+	if err := op.SetReadOnly(ctx, true); err != nil {
+		log.Error(err, "failed to make the source read-only", "source", op.Name())
+		return fmt.Sprintf("the source instance %s is not reachable; skipped making it read-only: %v", op.Name(), err), true, nil
+	}
+	log.Info("made the source read-only", "source", op.Name())
+	return fmt.Sprintf("made the source instance %s read-only", op.Name()), true, nil
+}
+
+// catchUpSource stops the replication IO thread of the intermediate primary
+// and waits for all the retrieved transactions to be executed.
+func (p *managerProcess) catchUpSource(ctx context.Context, ss *StatusSet) (string, error) {
+	log := logFromContext(ctx)
+	op := ss.DBOps[ss.Primary]
+
+	pst := ss.MySQLStatus[ss.Primary]
+	if pst == nil {
+		return "", errors.New("the primary is not available")
+	}
+	if pst.ReplicaStatus != nil && pst.ReplicaStatus.ReplicaIORunning == "Yes" {
+		log.Info("stop replica IO thread", "instance", ss.Primary)
+		if err := op.StopReplicaIOThread(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	// get the status again because the retrieved GTID set may have been updated.
+	pst, err := op.GetStatus(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the status of the primary: %w", err)
+	}
+	if pst.ReplicaStatus == nil || pst.ReplicaStatus.RetrievedGtidSet == "" {
+		return pst.GlobalVariables.ExecutedGTID, nil
+	}
+
+	gtid := pst.ReplicaStatus.RetrievedGtidSet
+	log.Info("waiting for all retrieved transactions to be executed", "instance", ss.Primary, "gtid", gtid)
+	if err := op.WaitForGTID(ctx, gtid, gtidWaitTimeoutSeconds(ss.Cluster)); err != nil {
+		return "", fmt.Errorf("failed to wait for the retrieved transactions to be executed: %w", err)
+	}
+	return gtid, nil
+}
+
+// detachSource removes the replication source from the spec and makes the primary writable.
+// The spec is updated first so that the cluster is not configured as an intermediate
+// primary again even if this fails in the middle.
+func (p *managerProcess) detachSource(ctx context.Context, ss *StatusSet) error {
+	log := logFromContext(ctx)
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		if !cluster.HasReplicationSource() {
+			return nil
+		}
+		cluster.Spec.ReplicationSourceSecretName = nil
+		cluster.Spec.ReplicationSource = nil
+		log.Info("remove the replication source")
+		return p.client.Update(ctx, cluster)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove the replication source: %w", err)
+	}
+
+	pst := ss.MySQLStatus[ss.Primary]
+	if pst == nil {
+		return errors.New("the primary is not available")
+	}
+	if !pst.GlobalVariables.ReadOnly {
+		return nil
+	}
+	log.Info("set read_only=0", "instance", ss.Primary)
+	if err := ss.DBOps[ss.Primary].SetReadOnly(ctx, false); err != nil {
+		return fmt.Errorf("failed to make the primary writable: %w", err)
+	}
+	event.SetWritable.Emit(ss.Cluster, p.recorder)
+	return nil
+}
@@ -0,0 +1,348 @@
+package clustering
+
+import (
+	"fmt"
+	"slices"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+)
+
+// operation is what `managerProcess.do` performs for the cluster in a round.
+type operation int
+
+const (
+	opNone operation = iota
+	opCancelPromotion
+	opRecover
+	opClone
+	opRejectSwitchover
+	opHoldSwitchover
+	opSwitchover
+	opPromote
+	opConfigure
+	opScaleIn
+	opNoFailoverCandidate
+	opWaitFailoverApproval
+	opHoldFailover
+	opFailover
+)
+
+// decideOperation decides the operation for the cluster.
+// Both `managerProcess.do` and `makePlan` use this so that the plan describes what `do` performs.
+// `failoverErr` is the error returned by `previewFailover` for a failed cluster.
+func decideOperation(ss *StatusSet, failoverErr error) operation {
+	if needCancelPromotion(ss) {
+		return opCancelPromotion
+	}
+	if ss.RecoveryRequested {
+		return opRecover
+	}
+
+	switch ss.State {
+	case StateCloning:
+		return opClone
+
+	case StateHealthy, StateDegraded:
+		switch {
+		case ss.SwitchRejected != nil:
+			return opRejectSwitchover
+		case ss.NeedSwitch && !ss.PreventPodDeletion:
+			if ss.RoleChangeHold != "" && !manualSwitchover(ss) {
+				return opHoldSwitchover
+			}
+			return opSwitchover
+		case needPromotion(ss):
+			return opPromote
+		case ss.State == StateDegraded || needReconfigure(ss):
+			return opConfigure
+		case len(ss.Excess) > 0 && ss.ScaleInTarget == 0:
+			return opScaleIn
+		}
+
+	case StateFailed:
+		switch {
+		case failoverErr != nil:
+			return opNoFailoverCandidate
+		case ss.FailoverPending:
+			return opWaitFailoverApproval
+		case ss.RoleChangeHold != "" && !failoverApproved(ss.Cluster):
+			return opHoldFailover
+		}
+		return opFailover
+
+	case StateIncomplete:
+		// the primary may not be ready while the promotion is stopping the replication.
+		if needPromotion(ss) && promotionInProgress(ss) {
+			return opPromote
+		}
+		return opConfigure
+	}
+
+	return opNone
+}
+
+// makePlan describes what `managerProcess.do` is going to perform for the cluster.
+// It must not have side effects and should be kept in sync with the operations.
+// `failoverErr` is the error returned by `previewFailover` for a failed cluster.
+func makePlan(ss *StatusSet, failoverErr error) *mocov1beta2.OperationPlan {
+	plan := &mocov1beta2.OperationPlan{
+		State:      ss.State.String(),
+		Primary:    ss.Primary,
+		Candidates: ss.Candidates,
+		Errants:    ss.Errants,
+	}
+
+	switch decideOperation(ss, failoverErr) {
+	case opCancelPromotion:
+		plan.Steps = planCancelPromotion(ss)
+
+	case opRecover:
+		if ss.RecoveryRejected != nil {
+			plan.Steps = []string{fmt.Sprintf("reject the recovery request: %v", ss.RecoveryRejected)}
+			break
+		}
+		plan.NextPrimary = &ss.RecoverTo
+		plan.Steps = []string{
+			fmt.Sprintf("make instances other than instance %d super_read_only", ss.RecoverTo),
+			fmt.Sprintf("wait for instance %d to execute all retrieved transactions", ss.RecoverTo),
+			fmt.Sprintf("mark the instances having transactions missing in instance %d to be re-cloned", ss.RecoverTo),
+			fmt.Sprintf("switch the primary to instance %d", ss.RecoverTo),
+		}
+
+	case opClone:
+		plan.Steps = []string{fmt.Sprintf("clone data from the replication source to instance %d", ss.Primary)}
+
+	case opRejectSwitchover:
+		plan.Steps = []string{fmt.Sprintf("reject the switchover request: %v", ss.SwitchRejected)}
+
+	case opHoldSwitchover:
+		plan.NextPrimary = &ss.Candidate
+		plan.Steps = []string{fmt.Sprintf("hold the switchover to instance %d until an operator acknowledges: %s", ss.Candidate, ss.RoleChangeHold)}
+
+	case opSwitchover:
+		plan.NextPrimary = &ss.Candidate
+		plan.Steps = planSwitchover(ss)
+
+	case opPromote:
+		plan.Steps = planPromotion(ss)
+
+	case opConfigure:
+		plan.Steps = planConfigure(ss)
+
+	case opScaleIn:
+		plan.Steps = []string{fmt.Sprintf("detach instances %v to scale in the cluster", ss.Excess)}
+
+	case opNoFailoverCandidate:
+		plan.Steps = []string{fmt.Sprintf("no instance can be promoted: %v", failoverErr)}
+
+	case opWaitFailoverApproval:
+		plan.NextPrimary = &ss.Candidate
+		plan.Steps = []string{fmt.Sprintf("wait for an approval to fail over to instance %d", ss.Candidate)}
+
+	case opHoldFailover:
+		plan.NextPrimary = &ss.Candidate
+		plan.Steps = []string{fmt.Sprintf("hold the failover to instance %d until an operator acknowledges: %s", ss.Candidate, ss.RoleChangeHold)}
+
+	case opFailover:
+		plan.NextPrimary = &ss.Candidate
+		plan.Steps = planFailover(ss)
+
+	case opNone:
+		switch {
+		case ss.State == StateOffline:
+			plan.Steps = []string{"do nothing because the cluster is offline"}
+		case ss.State == StateRestoring:
+			plan.Steps = []string{"wait for the data to be restored"}
+		case ss.State == StateLost && ss.Cluster.IsGroupReplication():
+			plan.Steps = []string{"do nothing because the majority of the group is lost"}
+		case ss.State == StateLost:
+			plan.Steps = []string{"do nothing because the data of the cluster may be lost"}
+		case slices.Contains(ss.WaitingOperations, "switchover"):
+			plan.Steps = []string{fmt.Sprintf("wait for a maintenance window to switch the primary to instance %d", ss.Candidate)}
+		case ss.SwitchoverPostponed:
+			plan.Steps = []string{fmt.Sprintf("postpone the switchover to instance %d until it becomes safe", ss.Candidate)}
+		}
+	}
+
+	return completePlan(ss, plan)
+}
+
+// completePlan adds the steps performed regardless of the cluster state.
+func completePlan(ss *StatusSet, plan *mocov1beta2.OperationPlan) *mocov1beta2.OperationPlan {
+	if fenceSplitBrain(ss.Cluster) {
+		var steps []string
+		for _, i := range ss.WritableInstances {
+			steps = append(steps, fmt.Sprintf("make instance %d super_read_only to resolve the split brain", i))
+		}
+		plan.Steps = append(steps, plan.Steps...)
+	}
+
+	if len(plan.Steps) == 0 {
+		plan.Steps = []string{"nothing to do"}
+	}
+	return plan
+}
+
+func planSwitchover(ss *StatusSet) []string {
+	var pre, post []mocov1beta2.SwitchoverHook
+	if hooks := ss.Cluster.Spec.SwitchoverHooks; hooks != nil {
+		pre, post = hooks.Pre, hooks.Post
+	}
+
+	var steps []string
+	for _, h := range pre {
+		steps = append(steps, fmt.Sprintf("call pre-switchover hook %q", h.Name))
+	}
+	if ss.Cluster.IsGroupReplication() {
+		steps = append(steps, fmt.Sprintf("set instance %d as the primary of the group", ss.Candidate))
+	} else {
+		steps = append(steps,
+			fmt.Sprintf("make instance %d super_read_only", ss.Primary),
+			fmt.Sprintf("kill connections in instance %d", ss.Primary),
+			fmt.Sprintf("wait for instance %d to execute all transactions of instance %d", ss.Candidate, ss.Primary),
+		)
+	}
+	steps = append(steps, fmt.Sprintf("switch the primary to instance %d", ss.Candidate))
+	for _, h := range post {
+		steps = append(steps, fmt.Sprintf("call post-switchover hook %q", h.Name))
+	}
+	return steps
+}
+
+func planFailover(ss *StatusSet) []string {
+	var steps []string
+
+	strategies := ss.Cluster.Spec.FencingStrategies
+	if len(strategies) == 0 {
+		strategies = defaultFencingStrategies
+	}
+	if primaryFenced(ss) {
+		strategies = nil
+	}
+	for _, s := range strategies {
+		if s == mocov1beta2.FencingSuperReadOnly && ss.MySQLStatus[ss.Primary] == nil {
+			continue
+		}
+		steps = append(steps, fmt.Sprintf("fence instance %d by %s", ss.Primary, s))
+	}
+
+	var replicas []int
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary || ist == nil {
+			continue
+		}
+		replicas = append(replicas, i)
+	}
+	steps = append(steps, fmt.Sprintf("stop replica IO thread of instances %v", replicas))
+
+	if ist := ss.MySQLStatus[ss.Candidate]; ist != nil && ist.ReplicaStatus != nil {
+		steps = append(steps, fmt.Sprintf("wait for instance %d to execute all retrieved transactions %s", ss.Candidate, ist.ReplicaStatus.RetrievedGtidSet))
+	}
+	steps = append(steps, fmt.Sprintf("switch the primary to instance %d", ss.Candidate))
+	return steps
+}
+
+func planCancelPromotion(ss *StatusSet) []string {
+	steps := []string{fmt.Sprintf("cancel the promotion in phase %s", ss.Cluster.Status.Promotion.Phase)}
+	if ref := ss.Cluster.Status.Promotion.StoppedSourceCluster; ref != nil {
+		steps = append(steps, fmt.Sprintf("resume the clustering of the source cluster %s/%s", ref.Namespace, ref.Name))
+	}
+	return steps
+}
+
+func planPromotion(ss *StatusSet) []string {
+	phase := mocov1beta2.PromotionPhaseFencingSource
+	if promotionInProgress(ss) {
+		phase = ss.Cluster.Status.Promotion.Phase
+	}
+	if !ss.Cluster.HasReplicationSource() && !promotionInProgress(ss) {
+		return []string{"reject the promotion request because the cluster has no replication source"}
+	}
+	return []string{fmt.Sprintf("promote the intermediate primary instance %d (phase %s)", ss.Primary, phase)}
+}
+
+func planConfigure(ss *StatusSet) []string {
+	if ss.Cluster.IsGroupReplication() {
+		return planConfigureGroup(ss)
+	}
+
+	var steps []string
+
+	for i, pod := range ss.Pods {
+		var role string
+		switch {
+		case ss.MySQLStatus[i] == nil || isErrantReplica(ss, i):
+		case i == ss.Primary:
+			role = constants.RolePrimary
+		default:
+			role = constants.RoleReplica
+		}
+		if pod.Labels[constants.LabelMocoRole] != role {
+			steps = append(steps, fmt.Sprintf("update the role label of instance %d to %q", i, role))
+		}
+	}
+
+	pst := ss.MySQLStatus[ss.Primary]
+	if pst == nil {
+		return append(steps, fmt.Sprintf("wait for the primary instance %d to be reachable", ss.Primary))
+	}
+	if ss.Cluster.HasReplicationSource() {
+		if !pst.GlobalVariables.SuperReadOnly {
+			steps = append(steps, fmt.Sprintf("make instance %d super_read_only", ss.Primary))
+		}
+		if pst.ReplicaStatus == nil || pst.ReplicaStatus.ReplicaIORunning != "Yes" || (ss.SourceHost != "" && pst.ReplicaStatus.SourceHost != ss.SourceHost) {
+			steps = append(steps, fmt.Sprintf("start replication of instance %d from the replication source", ss.Primary))
+		}
+	} else {
+		if pst.ReplicaStatus != nil && pst.ReplicaStatus.ReplicaIORunning == "Yes" {
+			steps = append(steps, fmt.Sprintf("stop replica IO thread of instance %d", ss.Primary))
+		}
+		if !semiSyncPrimaryConfigured(ss) {
+			steps = append(steps, fmt.Sprintf("configure semi-synchronous replication of instance %d for %d replicas", ss.Primary, semiSyncWaitCount(ss)))
+		}
+	}
+
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary {
+			continue
+		}
+		if ist == nil {
+			steps = append(steps, fmt.Sprintf("skip unreachable instance %d", i))
+			continue
+		}
+		steps = append(steps, planConfigureReplica(ss, i)...)
+	}
+
+	if !ss.Cluster.HasReplicationSource() && pst.GlobalVariables.ReadOnly {
+		steps = append(steps, fmt.Sprintf("make instance %d writable", ss.Primary))
+	}
+	return steps
+}
+
+func planConfigureReplica(ss *StatusSet, index int) []string {
+	st := ss.MySQLStatus[index]
+	if st.IsErrant {
+		if needReclone(ss, index) {
+			return []string{fmt.Sprintf("reset errant instance %d to re-clone it", index)}
+		}
+		if st.ReplicaStatus != nil && st.ReplicaStatus.ReplicaIORunning == "Yes" {
+			return []string{fmt.Sprintf("stop replica IO thread of errant instance %d", index)}
+		}
+		return nil
+	}
+
+	var steps []string
+	if !st.GlobalVariables.SuperReadOnly {
+		steps = append(steps, fmt.Sprintf("make instance %d super_read_only", index))
+	}
+	if st.GlobalVariables.ExecutedGTID == "" && ss.ExecutedGTID != "" && st.ReplicaStatus == nil {
+		steps = append(steps, fmt.Sprintf("clone data from the primary to instance %d", index))
+	}
+	delay := replicationDelay(ss.Cluster, index)
+	semisync := !ss.Cluster.HasReplicationSource() && !isAsync(ss, index)
+	if st.ReplicaStatus == nil || st.ReplicaStatus.ReplicaIORunning != "Yes" || st.ReplicaStatus.SourceHost != ss.Cluster.PodHostname(ss.Primary) || st.GlobalVariables.SemiSyncSlaveEnabled != semisync || st.ReplicaStatus.SQLDelay != delay {
+		steps = append(steps, fmt.Sprintf("start replication of instance %d (semisync=%t, delay=%ds)", index, semisync, delay))
+	}
+	return steps
+}
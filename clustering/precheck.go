@@ -0,0 +1,107 @@
+package clustering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/dbop"
+)
+
+const (
+	defaultMaxTransactionAge = 30 * time.Second
+	defaultMaxReplicationLag = 10 * time.Second
+
+	// maxReportedActivities is the maximum number of the long-running activities reported as the risks.
+	maxReportedActivities = 5
+	// maxReportedQueryLength is the maximum length of a statement reported as a risk.
+	maxReportedQueryLength = 64
+)
+
+// switchoverCheckPolicy returns the policy for an unsafe switchover.
+// The check is disabled unless `spec.clustering.switchoverCheck` is given.
+func switchoverCheckPolicy(cluster *mocov1beta2.MySQLCluster) mocov1beta2.SwitchoverCheckPolicy {
+	c := cluster.Spec.Clustering
+	if c == nil || c.SwitchoverCheck == nil {
+		return mocov1beta2.SwitchoverCheckIgnore
+	}
+	if c.SwitchoverCheck.Policy == "" {
+		return mocov1beta2.SwitchoverCheckPostpone
+	}
+	return c.SwitchoverCheck.Policy
+}
+
+func maxTransactionAge(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if c := cluster.Spec.Clustering; c != nil && c.SwitchoverCheck != nil && c.SwitchoverCheck.MaxTransactionAge != nil {
+		return c.SwitchoverCheck.MaxTransactionAge.Duration
+	}
+	return defaultMaxTransactionAge
+}
+
+func maxReplicationLag(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if c := cluster.Spec.Clustering; c != nil && c.SwitchoverCheck != nil && c.SwitchoverCheck.MaxReplicationLag != nil {
+		return c.SwitchoverCheck.MaxReplicationLag.Duration
+	}
+	return defaultMaxReplicationLag
+}
+
+// checkSwitchover checks if the planned switchover is safe and sets `ss.SwitchoverRisks`.
+// If the policy is Postpone, this postpones the switchover by clearing `ss.NeedSwitch`
+// and setting `ss.SwitchoverPostponed`.  The switchover of a deleted primary or for scale-in
+// is never postponed.
+func checkSwitchover(ctx context.Context, ss *StatusSet) {
+	policy := switchoverCheckPolicy(ss.Cluster)
+	if policy == mocov1beta2.SwitchoverCheckIgnore {
+		return
+	}
+
+	activities, err := ss.DBOps[ss.Primary].GetLongRunningActivities(ctx, maxTransactionAge(ss.Cluster))
+	if err != nil {
+		// the switchover cannot be confirmed to be safe.
+		ss.SwitchoverRisks = []string{fmt.Sprintf("failed to check the activities in instance %d: %v", ss.Primary, err)}
+	} else {
+		ss.SwitchoverRisks = switchoverRisks(ss, activities)
+	}
+
+	if len(ss.SwitchoverRisks) == 0 || policy != mocov1beta2.SwitchoverCheckPostpone {
+		return
+	}
+	if ss.Pods[ss.Primary].DeletionTimestamp != nil || ss.ScaleInTarget > 0 {
+		return
+	}
+	ss.NeedSwitch = false
+	ss.SwitchoverPostponed = true
+}
+
+// switchoverRisks returns the reasons why the switchover from `ss.Primary` to `ss.Candidate` is unsafe.
+// `activities` are the long-running transactions and DDL statements in the primary.
+func switchoverRisks(ss *StatusSet, activities []dbop.Activity) []string {
+	var risks []string
+	for i, a := range activities {
+		if i == maxReportedActivities {
+			risks = append(risks, fmt.Sprintf("%d more long-running activities in instance %d", len(activities)-i, ss.Primary))
+			break
+		}
+		kind := "transaction"
+		if a.DDL {
+			kind = "DDL"
+		}
+		risk := fmt.Sprintf("%s of %s@%s in instance %d has been running for %ds", kind, a.User, a.Host, ss.Primary, a.Seconds)
+		if q := a.Query; q != "" {
+			if len(q) > maxReportedQueryLength {
+				q = q[:maxReportedQueryLength] + "..."
+			}
+			risk += fmt.Sprintf(" (%s)", q)
+		}
+		risks = append(risks, risk)
+	}
+
+	if ist := ss.MySQLStatus[ss.Candidate]; ist != nil && ist.ReplicaStatus != nil {
+		lag := ist.ReplicaStatus.SecondsBehindSource
+		if lag.Valid && time.Duration(lag.Int64)*time.Second > maxReplicationLag(ss.Cluster) {
+			risks = append(risks, fmt.Sprintf("instance %d lags behind the primary by %ds", ss.Candidate, lag.Int64))
+		}
+	}
+	return risks
+}
@@ -0,0 +1,160 @@
+package clustering
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/dbop"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type activityOperator struct {
+	dbop.NopOperator
+	activities []dbop.Activity
+	err        error
+}
+
+func (o activityOperator) GetLongRunningActivities(ctx context.Context, threshold time.Duration) ([]dbop.Activity, error) {
+	return o.activities, o.err
+}
+
+func TestCheckSwitchover(t *testing.T) {
+	newHealthy3 := func(deleting bool, check *mocov1beta2.SwitchoverCheckSpec, op dbop.Operator, lag int64) *StatusSet {
+		ss := newSS(3, 0, false, false, false, false).
+			withPod(true, deleting, !deleting).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+		if check != nil {
+			ss.Cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{SwitchoverCheck: check}
+		}
+		ss.MySQLStatus[1].ReplicaStatus.SecondsBehindSource = sql.NullInt64{Int64: lag, Valid: true}
+		ss.DBOps = []dbop.Operator{op, nil, nil}
+		return ss
+	}
+
+	longTrx := activityOperator{activities: []dbop.Activity{
+		{ID: 10, User: "app", Host: "10.0.0.1:40000", Seconds: 120, Query: "UPDATE t SET a = 1"},
+	}}
+	longDDL := activityOperator{activities: []dbop.Activity{
+		{ID: 11, User: "admin", Host: "10.0.0.2:40000", Seconds: 600, Query: "ALTER TABLE t ADD COLUMN " + strings.Repeat("x", 100), DDL: true},
+	}}
+	failing := activityOperator{err: errors.New("mysqld is down")}
+
+	testCases := []struct {
+		name              string
+		statusSet         *StatusSet
+		expectedSwitch    bool
+		expectedPostponed bool
+		expectedRisks     int
+	}{
+		{
+			name:           "disabled",
+			statusSet:      newHealthy3(false, nil, longTrx, 100),
+			expectedSwitch: true,
+		},
+		{
+			name:           "safe",
+			statusSet:      newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{}, activityOperator{}, 5),
+			expectedSwitch: true,
+		},
+		{
+			name:              "long-transaction",
+			statusSet:         newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{}, longTrx, 0),
+			expectedPostponed: true,
+			expectedRisks:     1,
+		},
+		{
+			name:              "long-ddl-and-lag",
+			statusSet:         newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{Policy: mocov1beta2.SwitchoverCheckPostpone}, longDDL, 11),
+			expectedPostponed: true,
+			expectedRisks:     2,
+		},
+		{
+			name: "lag-within-limit",
+			statusSet: newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{
+				MaxReplicationLag: &metav1.Duration{Duration: time.Minute},
+			}, activityOperator{}, 30),
+			expectedSwitch: true,
+		},
+		{
+			name:              "check-failed",
+			statusSet:         newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{}, failing, 0),
+			expectedPostponed: true,
+			expectedRisks:     1,
+		},
+		{
+			name:           "warn",
+			statusSet:      newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{Policy: mocov1beta2.SwitchoverCheckWarn}, longTrx, 0),
+			expectedSwitch: true,
+			expectedRisks:  1,
+		},
+		{
+			name:           "ignore",
+			statusSet:      newHealthy3(false, &mocov1beta2.SwitchoverCheckSpec{Policy: mocov1beta2.SwitchoverCheckIgnore}, longTrx, 100),
+			expectedSwitch: true,
+		},
+		{
+			name:           "deleting",
+			statusSet:      newHealthy3(true, &mocov1beta2.SwitchoverCheckSpec{}, longTrx, 0),
+			expectedSwitch: true,
+			expectedRisks:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := tc.statusSet
+			ss.DecideState()
+			if ss.State != StateHealthy || !ss.NeedSwitch {
+				t.Fatalf("switchover is not planned: state=%s", ss.State)
+			}
+
+			checkSwitchover(context.Background(), ss)
+			if ss.NeedSwitch != tc.expectedSwitch {
+				t.Errorf("wrong NeedSwitch: expected=%v", tc.expectedSwitch)
+			}
+			if ss.SwitchoverPostponed != tc.expectedPostponed {
+				t.Errorf("wrong SwitchoverPostponed: expected=%v", tc.expectedPostponed)
+			}
+			if len(ss.SwitchoverRisks) != tc.expectedRisks {
+				t.Errorf("wrong risks %q: expected=%d", ss.SwitchoverRisks, tc.expectedRisks)
+			}
+			for _, risk := range ss.SwitchoverRisks {
+				if len(risk) > 200 {
+					t.Errorf("too long risk: %s", risk)
+				}
+			}
+		})
+	}
+}
+
+func TestSwitchoverRisksLimit(t *testing.T) {
+	ss := newSS(1, 0, false, false, false, false).
+		withPod(true, false, false).
+		withMySQL(newMySQL("1234", false, false, false).build()).
+		build()
+
+	var activities []dbop.Activity
+	for i := 0; i < maxReportedActivities+3; i++ {
+		activities = append(activities, dbop.Activity{ID: uint64(i), User: "app", Host: "host", Seconds: 60})
+	}
+	risks := switchoverRisks(ss, activities)
+	if len(risks) != maxReportedActivities+1 {
+		t.Fatalf("wrong number of risks: %d", len(risks))
+	}
+	if last := risks[len(risks)-1]; last != "3 more long-running activities in instance 0" {
+		t.Errorf("wrong summary: %s", last)
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/cybozu-go/moco/pkg/dbop"
 	"github.com/cybozu-go/moco/pkg/event"
 	"github.com/cybozu-go/moco/pkg/metrics"
@@ -27,12 +32,29 @@ type metricsSet struct {
 	errorCount      prometheus.Counter
 	available       prometheus.Gauge
 	healthy         prometheus.Gauge
+	state           *prometheus.GaugeVec
 	switchoverCount prometheus.Counter
 	failoverCount   prometheus.Counter
 	replicas        prometheus.Gauge
 	readyReplicas   prometheus.Gauge
 	errantReplicas  prometheus.Gauge
+	splitBrain      prometheus.Gauge
+	roleChanges     prometheus.Gauge
+	roleChangeHeld  prometheus.Gauge
+	roleChangeHolds prometheus.Counter
 	processingTime  prometheus.Observer
+	checkInterval   prometheus.Gauge
+
+	switchoverDuration prometheus.Observer
+	failoverDuration   prometheus.Observer
+
+	replicationLag       *prometheus.GaugeVec
+	replicaIORunning     *prometheus.GaugeVec
+	replicaSQLRunning    *prometheus.GaugeVec
+	replicaLastIOErrno   *prometheus.GaugeVec
+	replicaLastSQLErrno  *prometheus.GaugeVec
+	semiSyncWaitSessions *prometheus.GaugeVec
+	missingGTIDs         *prometheus.GaugeVec
 
 	backupTimestamp    prometheus.Gauge
 	backupElapsed      prometheus.Gauge
@@ -51,9 +73,16 @@ type managerProcess struct {
 	name     types.NamespacedName
 	cancel   func()
 	pause    bool
+	done     chan struct{}
+
+	finish     chan struct{}
+	finishOnce sync.Once
 
 	ch            chan string
 	metrics       metricsSet
+	lastInstances int
+	interval      *checkInterval
+	nextInterval  time.Duration
 	deleteMetrics func()
 	pauseMetrics  func()
 }
@@ -67,18 +96,37 @@ func newManagerProcess(c client.Client, r client.Reader, recorder record.EventRe
 		agentf:   agentf,
 		name:     name,
 		cancel:   cancel,
+		done:     make(chan struct{}),
+		finish:   make(chan struct{}),
 		ch:       make(chan string, 1),
 		metrics: metricsSet{
 			checkCount:         metrics.CheckCountVec.WithLabelValues(name.Name, name.Namespace),
 			errorCount:         metrics.ErrorCountVec.WithLabelValues(name.Name, name.Namespace),
 			available:          metrics.AvailableVec.WithLabelValues(name.Name, name.Namespace),
 			healthy:            metrics.HealthyVec.WithLabelValues(name.Name, name.Namespace),
+			state:              metrics.StateVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
 			switchoverCount:    metrics.SwitchoverCountVec.WithLabelValues(name.Name, name.Namespace),
 			failoverCount:      metrics.FailoverCountVec.WithLabelValues(name.Name, name.Namespace),
 			replicas:           metrics.TotalReplicasVec.WithLabelValues(name.Name, name.Namespace),
 			readyReplicas:      metrics.ReadyReplicasVec.WithLabelValues(name.Name, name.Namespace),
 			errantReplicas:     metrics.ErrantReplicasVec.WithLabelValues(name.Name, name.Namespace),
+			splitBrain:         metrics.SplitBrainVec.WithLabelValues(name.Name, name.Namespace),
+			roleChanges:        metrics.RoleChangesVec.WithLabelValues(name.Name, name.Namespace),
+			roleChangeHeld:     metrics.RoleChangeHeldVec.WithLabelValues(name.Name, name.Namespace),
+			roleChangeHolds:    metrics.RoleChangeHoldsVec.WithLabelValues(name.Name, name.Namespace),
 			processingTime:     metrics.ProcessingTimeVec.WithLabelValues(name.Name, name.Namespace),
+			checkInterval:      metrics.CheckIntervalVec.WithLabelValues(name.Name, name.Namespace),
+			switchoverDuration: metrics.SwitchoverDurationVec.WithLabelValues(name.Name, name.Namespace),
+			failoverDuration:   metrics.FailoverDurationVec.WithLabelValues(name.Name, name.Namespace),
+
+			replicationLag:       metrics.ReplicationLagVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			replicaIORunning:     metrics.ReplicaIORunningVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			replicaSQLRunning:    metrics.ReplicaSQLRunningVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			replicaLastIOErrno:   metrics.ReplicaLastIOErrnoVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			replicaLastSQLErrno:  metrics.ReplicaLastSQLErrnoVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			semiSyncWaitSessions: metrics.SemiSyncWaitSessionsVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+			missingGTIDs:         metrics.MissingGTIDsVec.MustCurryWith(prometheus.Labels{"name": name.Name, "namespace": name.Namespace}),
+
 			backupTimestamp:    metrics.BackupTimestamp.WithLabelValues(name.Name, name.Namespace),
 			backupElapsed:      metrics.BackupElapsed.WithLabelValues(name.Name, name.Namespace),
 			backupDumpSize:     metrics.BackupDumpSize.WithLabelValues(name.Name, name.Namespace),
@@ -91,12 +139,21 @@ func newManagerProcess(c client.Client, r client.Reader, recorder record.EventRe
 			metrics.ErrorCountVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.AvailableVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.HealthyVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.StateVec.DeletePartialMatch(prometheus.Labels{"name": name.Name, "namespace": name.Namespace})
 			metrics.SwitchoverCountVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.FailoverCountVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.TotalReplicasVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.ReadyReplicasVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.ErrantReplicasVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.SplitBrainVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.RoleChangesVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.RoleChangeHeldVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.RoleChangeHoldsVec.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.ProcessingTimeVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.CheckIntervalVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.SwitchoverDurationVec.DeleteLabelValues(name.Name, name.Namespace)
+			metrics.FailoverDurationVec.DeleteLabelValues(name.Name, name.Namespace)
+			deleteInstanceMetrics(name)
 			metrics.BackupTimestamp.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.BackupElapsed.DeleteLabelValues(name.Name, name.Namespace)
 			metrics.BackupDumpSize.DeleteLabelValues(name.Name, name.Namespace)
@@ -107,12 +164,35 @@ func newManagerProcess(c client.Client, r client.Reader, recorder record.EventRe
 		pauseMetrics: func() {
 			metrics.AvailableVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
 			metrics.HealthyVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			for s := StateIncomplete; s <= StateOffline; s++ {
+				metrics.StateVec.WithLabelValues(name.Name, name.Namespace, s.String()).Set(math.NaN())
+			}
 			metrics.ReadyReplicasVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
 			metrics.ErrantReplicasVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			metrics.SplitBrainVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			metrics.RoleChangesVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			metrics.RoleChangeHeldVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			metrics.CheckIntervalVec.WithLabelValues(name.Name, name.Namespace).Set(math.NaN())
+			deleteInstanceMetrics(name)
 		},
 	}
 }
 
+// deleteInstanceMetrics deletes the metrics labeled by the instance index of the cluster.
+func deleteInstanceMetrics(name types.NamespacedName) {
+	for _, vec := range []*prometheus.GaugeVec{
+		metrics.ReplicationLagVec,
+		metrics.ReplicaIORunningVec,
+		metrics.ReplicaSQLRunningVec,
+		metrics.ReplicaLastIOErrnoVec,
+		metrics.ReplicaLastSQLErrnoVec,
+		metrics.SemiSyncWaitSessionsVec,
+		metrics.MissingGTIDsVec,
+	} {
+		vec.DeletePartialMatch(prometheus.Labels{"name": name.Name, "namespace": name.Namespace})
+	}
+}
+
 func (p *managerProcess) Update(origin string) {
 	select {
 	case p.ch <- origin:
@@ -124,6 +204,13 @@ func (p *managerProcess) Cancel() {
 	p.cancel()
 }
 
+// Finish stops the manager process after the operation in progress completes.
+// Unlike Cancel, it does not interrupt the operation so that a switchover or a failover
+// is not left half-done when the cluster is handed over to another controller.
+func (p *managerProcess) Finish() {
+	p.finishOnce.Do(func() { close(p.finish) })
+}
+
 // Pause pauses the manager process.
 // Unlike Cancel, it does not delete metrics.
 // Also, it sets NaN to some metrics.
@@ -132,8 +219,15 @@ func (p *managerProcess) Pause() {
 	p.cancel()
 }
 
+// Start runs the periodic checks of the cluster until `ctx` is canceled.
+// `interval` is the default interval of the checks.  Each check may change the interval
+// for the cluster as described in `checkInterval.next`.
 func (p *managerProcess) Start(ctx context.Context, rootLog logr.Logger, interval time.Duration) {
-	tick := time.NewTicker(interval)
+	p.interval = newCheckInterval(interval)
+	p.nextInterval = interval
+	current := interval
+	p.metrics.checkInterval.Set(current.Seconds())
+	tick := time.NewTicker(current)
 	defer func() {
 		tick.Stop()
 		if p.pause {
@@ -151,6 +245,16 @@ func (p *managerProcess) Start(ctx context.Context, rootLog logr.Logger, interva
 		case <-ctx.Done():
 			rootLog.Info("quit")
 			return
+		case <-p.finish:
+			rootLog.Info("quit")
+			return
+		}
+		select {
+		case <-p.finish:
+			// do not start a new operation after Finish.
+			rootLog.Info("quit")
+			return
+		default:
 		}
 
 		log := rootLog.WithValues("operationId", "op-"+rand.String(5))
@@ -160,6 +264,12 @@ func (p *managerProcess) Start(ctx context.Context, rootLog logr.Logger, interva
 		redo, err := p.do(logr.NewContext(ctx, log))
 		duration := time.Since(startTime)
 		p.metrics.processingTime.Observe(duration.Seconds())
+		if p.nextInterval > 0 && p.nextInterval != current {
+			current = p.nextInterval
+			tick.Reset(current)
+			p.metrics.checkInterval.Set(current.Seconds())
+			log.Info("change the check interval", "interval", current.String())
+		}
 		if err != nil {
 			p.metrics.errorCount.Inc()
 			log.Error(err, "error", "duration", duration)
@@ -177,13 +287,38 @@ func (p *managerProcess) Start(ctx context.Context, rootLog logr.Logger, interva
 func (p *managerProcess) do(ctx context.Context) (bool, error) {
 	ss, err := p.GatherStatus(ctx)
 	if err != nil {
+		if p.interval != nil {
+			p.nextInterval = p.interval.failed()
+		}
 		return false, err
 	}
 	defer ss.Close()
 
+	detectSplitBrain(ss)
+
+	var failoverErr error
+	if ss.State == StateFailed {
+		candidate, err := previewFailover(ctx, ss)
+		if err != nil {
+			logFromContext(ctx).Error(err, "failed to determine the next primary")
+			candidate = -1
+			failoverErr = err
+		}
+		ss.Candidate = candidate
+	}
+	if (ss.State == StateHealthy || ss.State == StateDegraded) && ss.NeedSwitch && !ss.PreventPodDeletion {
+		checkSwitchover(ctx, ss)
+	}
+	op := decideOperation(ss, failoverErr)
+	ss.Plan = makePlan(ss, failoverErr)
+	if p.interval != nil {
+		p.nextInterval = p.interval.next(ss.Cluster, ss.State, time.Now())
+	}
+
 	if err := p.updateStatus(ctx, ss); err != nil {
 		return false, fmt.Errorf("failed to update status fields in MySQLCluster: %w", err)
 	}
+	p.updateInstanceMetrics(ss)
 
 	if ss.PreventPodDeletion {
 		err := p.addAnnPreventDelete(ctx, ss)
@@ -197,11 +332,59 @@ func (p *managerProcess) do(ctx context.Context) (bool, error) {
 		}
 	}
 
+	// an approval given to a cluster that is not failed is stale.
+	if ss.State != StateFailed {
+		if err := p.removeAnnApproveFailover(ctx, ss); err != nil {
+			return false, err
+		}
+	}
+
+	if err := p.abandonSwitchoverHooks(ctx, ss); err != nil {
+		return false, err
+	}
+
+	if op == opCancelPromotion {
+		phase := ss.Cluster.Status.Promotion.Phase
+		if err := p.cancelPromotion(ctx, ss); err != nil {
+			return false, fmt.Errorf("failed to cancel the promotion: %w", err)
+		}
+		event.PromotionCancelled.Emit(ss.Cluster, p.recorder, phase)
+		return true, nil
+	}
+
+	// the role change history has been cleared by updateStatus.
+	if err := p.removeAnnAcknowledgeRoleChangeHold(ctx, ss); err != nil {
+		return false, err
+	}
+	if ss.RoleChangeHoldAcknowledged {
+		event.RoleChangeHoldAcknowledged.Emit(ss.Cluster, p.recorder)
+	}
+
+	if len(ss.WritableInstances) > 0 && fenceSplitBrain(ss.Cluster) {
+		p.fenceWritableInstances(ctx, ss)
+	}
+
 	logFromContext(ctx).Info("cluster state is " + ss.State.String())
-	switch ss.State {
-	case StateOffline:
-		return false, nil
-	case StateCloning:
+
+	if ss.State == StateHealthy {
+		switch op {
+		case opPromote, opConfigure, opScaleIn, opNone:
+			if err := p.removeFencingNetworkPolicies(ctx, ss); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	switch op {
+	case opRecover:
+		redo, err := p.recoverCluster(ctx, ss)
+		if err != nil {
+			event.RecoveryFailed.Emit(ss.Cluster, p.recorder, err)
+			return false, fmt.Errorf("failed to recover: %w", err)
+		}
+		return redo, nil
+
+	case opClone:
 		if p.isCloning(ctx, ss) {
 			return false, nil
 		}
@@ -214,44 +397,80 @@ func (p *managerProcess) do(ctx context.Context) (bool, error) {
 		event.InitCloneSucceeded.Emit(ss.Cluster, p.recorder)
 		return redo, nil
 
-	case StateRestoring:
+	case opRejectSwitchover:
+		logFromContext(ctx).Info("reject the switchover request", "reason", ss.SwitchRejected.Error())
+		event.SwitchOverRejected.Emit(ss.Cluster, p.recorder, ss.SwitchRejected)
+		if err := p.removeAnnDemote(ctx, ss); err != nil {
+			return false, err
+		}
+		return true, nil
+
+	case opHoldSwitchover:
+		logFromContext(ctx).Info("switchover is held by the role change limit", "candidate", ss.Candidate, "reason", ss.RoleChangeHold)
 		return false, nil
 
-	case StateHealthy, StateDegraded:
-		if ss.NeedSwitch && !ss.PreventPodDeletion {
-			if err := p.switchover(ctx, ss); err != nil {
-				event.SwitchOverFailed.Emit(ss.Cluster, p.recorder, err)
-				return false, fmt.Errorf("failed to switchover: %w", err)
-			}
-			event.SwitchOverSucceeded.Emit(ss.Cluster, p.recorder, ss.Candidate)
-			// do not configure the cluster after a switchover.
-			return true, nil
+	case opSwitchover:
+		if len(ss.SwitchoverRisks) > 0 {
+			logFromContext(ctx).Info("switchover despite the risks", "risks", ss.SwitchoverRisks)
+			event.SwitchOverUnsafe.Emit(ss.Cluster, p.recorder, strings.Join(ss.SwitchoverRisks, "; "))
 		}
-		if ss.State == StateDegraded {
-			return p.configure(ctx, ss)
+		startTime := time.Now()
+		if err := p.switchover(ctx, ss); err != nil {
+			event.SwitchOverFailed.Emit(ss.Cluster, p.recorder, err)
+			return false, fmt.Errorf("failed to switchover: %w", err)
 		}
+		p.metrics.switchoverDuration.Observe(time.Since(startTime).Seconds())
+		event.SwitchOverSucceeded.Emit(ss.Cluster, p.recorder, ss.Candidate)
+		// do not configure the cluster after a switchover.
+		return true, nil
+
+	case opPromote:
+		return p.promoteCluster(ctx, ss)
+
+	case opConfigure:
+		return p.configure(ctx, ss)
+
+	case opScaleIn:
+		return p.scaleIn(ctx, ss)
+
+	case opNoFailoverCandidate:
+		// the error of previewFailover has been logged above.
+		return false, nil
+
+	case opWaitFailoverApproval:
+		logFromContext(ctx).Info("failover is waiting for approval", "candidate", ss.Candidate)
+		return false, nil
+
+	case opHoldFailover:
+		logFromContext(ctx).Info("failover is held by the role change limit", "candidate", ss.Candidate, "reason", ss.RoleChangeHold)
 		return false, nil
 
-	case StateFailed:
-		// in this case, only applicable operation is a failover.
+	case opFailover:
+		startTime := time.Now()
 		if err := p.failover(ctx, ss); err != nil {
 			event.FailOverFailed.Emit(ss.Cluster, p.recorder, err)
 			return false, fmt.Errorf("failed to failover: %w", err)
 		}
+		p.metrics.failoverDuration.Observe(time.Since(startTime).Seconds())
 		event.FailOverSucceeded.Emit(ss.Cluster, p.recorder, ss.Candidate)
+		if err := p.removeAnnApproveFailover(ctx, ss); err != nil {
+			return false, err
+		}
 		return true, nil
-
-	case StateLost:
-		// nothing can be done
-		return false, nil
-
-	case StateIncomplete:
-		return p.configure(ctx, ss)
 	}
 
 	return false, nil
 }
 
+func (p *managerProcess) promoteCluster(ctx context.Context, ss *StatusSet) (bool, error) {
+	redo, err := p.promote(ctx, ss)
+	if err != nil {
+		event.PromotionFailed.Emit(ss.Cluster, p.recorder, err)
+		return false, fmt.Errorf("failed to promote: %w", err)
+	}
+	return redo, nil
+}
+
 func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error {
 	bs := &ss.Cluster.Status.Backup
 	if !bs.Time.IsZero() {
@@ -274,7 +493,88 @@ func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error
 		return updated
 	}
 
-	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	pendingCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionFailoverPending,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoPendingFailover",
+		Message: "no failover is pending",
+	}
+	if ss.FailoverPending {
+		pendingCond.Status = metav1.ConditionTrue
+		pendingCond.Reason = "WaitingForApproval"
+		if ss.Candidate >= 0 {
+			pendingCond.Message = fmt.Sprintf("the failover to instance %d is waiting for approval", ss.Candidate)
+		} else {
+			pendingCond.Message = "the failover is waiting for approval, but the next primary cannot be determined"
+		}
+	}
+
+	windowCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionWaitingForMaintenanceWindow,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotWaiting",
+		Message: "no operation is waiting for maintenance window",
+	}
+	if len(ss.WaitingOperations) > 0 {
+		windowCond.Status = metav1.ConditionTrue
+		windowCond.Reason = "OutsideMaintenanceWindow"
+		windowCond.Message = fmt.Sprintf("waiting for maintenance window: %s", strings.Join(ss.WaitingOperations, ", "))
+		if !ss.NextMaintenanceWindow.IsZero() {
+			windowCond.Message += fmt.Sprintf(" (the next window opens at %s)", ss.NextMaintenanceWindow.UTC().Format(time.RFC3339))
+		}
+	}
+
+	interruptedCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionRollingUpdateInterrupted,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotInterrupted",
+		Message: "no rolling update is interrupted",
+	}
+	if ss.RolloutInterrupted != "" {
+		interruptedCond.Status = metav1.ConditionTrue
+		interruptedCond.Reason = "OutsideMaintenanceWindow"
+		interruptedCond.Message = fmt.Sprintf("the rolling update was interrupted by the end of the maintenance window; %s and the others may run a different MySQL version", ss.RolloutInterrupted)
+	}
+
+	postponedCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionSwitchoverPostponed,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotPostponed",
+		Message: "no switchover is postponed",
+	}
+	if ss.SwitchoverPostponed {
+		postponedCond.Status = metav1.ConditionTrue
+		postponedCond.Reason = "SwitchoverUnsafe"
+		postponedCond.Message = fmt.Sprintf("the switchover to instance %d is postponed: %s", ss.Candidate, strings.Join(ss.SwitchoverRisks, "; "))
+	}
+
+	splitBrainCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionSplitBrain,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NoSplitBrain",
+		Message: "no split brain is detected",
+	}
+	if len(ss.SplitBrain) > 0 {
+		splitBrainCond.Status = metav1.ConditionTrue
+		splitBrainCond.Reason = "SplitBrainDetected"
+		splitBrainCond.Message = strings.Join(ss.SplitBrain, "; ")
+	}
+
+	heldCond := metav1.Condition{
+		Type:    mocov1beta2.ConditionRoleChangeHeld,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotHeld",
+		Message: "role changes are not held",
+	}
+	if ss.RoleChangeHold != "" {
+		heldCond.Status = metav1.ConditionTrue
+		heldCond.Reason = "RoleChangeLimitReached"
+		heldCond.Message = ss.RoleChangeHold
+	}
+
+	var newlyPending, newlyPostponed, newlySplit, newlyHeld bool
+	var prevState string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		cluster := &mocov1beta2.MySQLCluster{}
 		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
 			return err
@@ -311,6 +611,25 @@ func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error
 			},
 		)
 
+		// the condition is set by scaleIn and is no longer needed when the scale-in is completed.
+		if len(ss.Excess) == 0 && ss.ScaleInTarget == 0 {
+			meta.RemoveStatusCondition(&cluster.Status.Conditions, mocov1beta2.ConditionScaleInReady)
+		}
+
+		newlyPending = ss.FailoverPending && !meta.IsStatusConditionTrue(orig.Status.Conditions, mocov1beta2.ConditionFailoverPending)
+		meta.SetStatusCondition(&cluster.Status.Conditions, pendingCond)
+		meta.SetStatusCondition(&cluster.Status.Conditions, windowCond)
+		meta.SetStatusCondition(&cluster.Status.Conditions, interruptedCond)
+		newlyPostponed = ss.SwitchoverPostponed && !meta.IsStatusConditionTrue(orig.Status.Conditions, mocov1beta2.ConditionSwitchoverPostponed)
+		meta.SetStatusCondition(&cluster.Status.Conditions, postponedCond)
+		newlySplit = len(ss.SplitBrain) > 0 && !meta.IsStatusConditionTrue(orig.Status.Conditions, mocov1beta2.ConditionSplitBrain)
+		meta.SetStatusCondition(&cluster.Status.Conditions, splitBrainCond)
+		newlyHeld = ss.RoleChangeHold != "" && !meta.IsStatusConditionTrue(orig.Status.Conditions, mocov1beta2.ConditionRoleChangeHeld)
+		meta.SetStatusCondition(&cluster.Status.Conditions, heldCond)
+		if ss.RoleChangeHoldAcknowledged {
+			cluster.Status.RoleChangeHistory = nil
+		}
+
 		if available == metav1.ConditionTrue {
 			p.metrics.available.Set(1)
 		} else {
@@ -321,6 +640,26 @@ func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error
 		} else {
 			p.metrics.healthy.Set(0)
 		}
+		for s := StateIncomplete; s <= StateOffline; s++ {
+			if s == ss.State {
+				p.metrics.state.WithLabelValues(s.String()).Set(1)
+			} else {
+				p.metrics.state.WithLabelValues(s.String()).Set(0)
+			}
+		}
+
+		prevState = cluster.Status.State
+		if prevState != ststr {
+			cluster.Status.State = ststr
+			history := append(cluster.Status.StateHistory, mocov1beta2.ClusterStateRecord{
+				State: ststr,
+				Time:  metav1.Now(),
+			})
+			if len(history) > maxStateHistory {
+				history = history[len(history)-maxStateHistory:]
+			}
+			cluster.Status.StateHistory = history
+		}
 
 		var syncedReplicas int
 		for _, pod := range ss.Pods {
@@ -331,14 +670,39 @@ func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error
 		cluster.Status.SyncedReplicas = syncedReplicas
 		cluster.Status.ErrantReplicas = len(ss.Errants)
 		cluster.Status.ErrantReplicaList = ss.Errants
+		cluster.Status.Instances = instanceStatuses(ss)
+		// the record of the fencing is stale if the cluster is no longer failed.
+		if ss.State != StateFailed {
+			cluster.Status.FencedPrimary = nil
+		}
 		p.metrics.replicas.Set(float64(len(ss.Pods)))
 		p.metrics.readyReplicas.Set(float64(syncedReplicas))
 		p.metrics.errantReplicas.Set(float64(len(ss.Errants)))
+		if len(ss.SplitBrain) > 0 {
+			p.metrics.splitBrain.Set(1)
+		} else {
+			p.metrics.splitBrain.Set(0)
+		}
+		p.metrics.roleChanges.Set(float64(recentRoleChanges(cluster, time.Now())))
+		if ss.RoleChangeHold != "" {
+			p.metrics.roleChangeHeld.Set(1)
+		} else {
+			p.metrics.roleChangeHeld.Set(0)
+		}
+
+		// the time of the plan is updated only when the plan is changed.
+		if plan := cluster.Status.Plan; plan != nil {
+			ss.Plan.Time = plan.Time
+		}
+		if !equality.Semantic.DeepEqual(cluster.Status.Plan, ss.Plan) {
+			ss.Plan.Time = metav1.Now()
+		}
+		cluster.Status.Plan = ss.Plan
 
 		// the completion of initial cloning is recorded in the status
 		// to make it possible to determine the cloning status even while
 		// the primary instance is down.
-		if cluster.Spec.ReplicationSourceSecretName != nil && ss.State != StateCloning {
+		if cluster.HasReplicationSource() && ss.State != StateCloning {
 			cluster.Status.Cloned = true
 		}
 
@@ -350,4 +714,146 @@ func (p *managerProcess) updateStatus(ctx context.Context, ss *StatusSet) error
 		logFromContext(ctx).Info("update the status information")
 		return p.client.Status().Update(ctx, cluster)
 	})
+	if err != nil {
+		return err
+	}
+
+	if newlyPending {
+		event.FailOverPending.Emit(ss.Cluster, p.recorder, pendingCond.Message)
+	}
+	if newlySplit {
+		event.SplitBrainDetected.Emit(ss.Cluster, p.recorder, splitBrainCond.Message)
+	}
+	if newlyHeld {
+		p.metrics.roleChangeHolds.Inc()
+		event.RoleChangeHeld.Emit(ss.Cluster, p.recorder, heldCond.Message)
+	}
+	if newlyPostponed {
+		event.SwitchOverPostponed.Emit(ss.Cluster, p.recorder, strings.Join(ss.SwitchoverRisks, "; "))
+	}
+
+	if prevState != ststr {
+		if prevState == "" {
+			prevState = "none"
+		}
+		switch ss.State {
+		case StateHealthy, StateCloning, StateRestoring, StateOffline:
+			event.StateChanged.Emit(ss.Cluster, p.recorder, prevState, ststr)
+		default:
+			event.StateChangedWarning.Emit(ss.Cluster, p.recorder, prevState, ststr)
+		}
+	}
+	return nil
+}
+
+// updateInstanceMetrics updates the metrics labeled by the instance index.
+// The metrics of unreachable or removed instances are deleted.
+func (p *managerProcess) updateInstanceMetrics(ss *StatusSet) {
+	replicaVecs := []*prometheus.GaugeVec{
+		p.metrics.replicationLag,
+		p.metrics.replicaIORunning,
+		p.metrics.replicaSQLRunning,
+		p.metrics.replicaLastIOErrno,
+		p.metrics.replicaLastSQLErrno,
+	}
+	vecs := append(slices.Clone(replicaVecs), p.metrics.semiSyncWaitSessions, p.metrics.missingGTIDs)
+	for i := len(ss.MySQLStatus); i < p.lastInstances; i++ {
+		for _, vec := range vecs {
+			vec.DeleteLabelValues(strconv.Itoa(i))
+		}
+	}
+	p.lastInstances = len(ss.MySQLStatus)
+
+	boolToFloat := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	for i, ist := range ss.MySQLStatus {
+		instance := strconv.Itoa(i)
+		if ist == nil {
+			for _, vec := range vecs {
+				vec.DeleteLabelValues(instance)
+			}
+			continue
+		}
+
+		if ist.GlobalStatus != nil {
+			p.metrics.semiSyncWaitSessions.WithLabelValues(instance).Set(float64(ist.GlobalStatus.SemiSyncMasterWaitSessions))
+		}
+
+		rs := ist.ReplicaStatus
+		if rs == nil {
+			for _, vec := range replicaVecs {
+				vec.DeleteLabelValues(instance)
+			}
+		} else {
+			if rs.SecondsBehindSource.Valid {
+				p.metrics.replicationLag.WithLabelValues(instance).Set(float64(rs.SecondsBehindSource.Int64))
+			} else {
+				p.metrics.replicationLag.DeleteLabelValues(instance)
+			}
+			p.metrics.replicaIORunning.WithLabelValues(instance).Set(boolToFloat(rs.ReplicaIORunning == "Yes"))
+			p.metrics.replicaSQLRunning.WithLabelValues(instance).Set(boolToFloat(rs.ReplicaSQLRunning == "Yes"))
+			p.metrics.replicaLastIOErrno.WithLabelValues(instance).Set(float64(rs.LastIoErrno))
+			p.metrics.replicaLastSQLErrno.WithLabelValues(instance).Set(float64(rs.LastSQLErrno))
+		}
+
+		if i == ss.Primary || ss.MissingGTIDs == nil {
+			p.metrics.missingGTIDs.DeleteLabelValues(instance)
+			continue
+		}
+		p.metrics.missingGTIDs.WithLabelValues(instance).Set(ss.MissingGTIDs[i])
+	}
+}
+
+// instanceStatuses converts the status of the instances in `ss` for `status.instances`.
+func instanceStatuses(ss *StatusSet) []mocov1beta2.InstanceStatus {
+	instances := make([]mocov1beta2.InstanceStatus, len(ss.MySQLStatus))
+	for i, ist := range ss.MySQLStatus {
+		instances[i].Index = i
+		if ss.GroupMemberStates != nil {
+			instances[i].GroupMemberState = ss.GroupMemberStates[i]
+		}
+		if ist == nil {
+			continue
+		}
+
+		st := &instances[i]
+		st.Reachable = true
+		st.Role = constants.RoleReplica
+		if i == ss.Primary {
+			st.Role = constants.RolePrimary
+		}
+		gv := ist.GlobalVariables
+		st.ServerUUID = gv.UUID
+		st.ExecutedGTIDSet = gv.ExecutedGTID
+		st.ReadOnly = gv.ReadOnly
+		st.SuperReadOnly = gv.SuperReadOnly
+		st.Errant = ist.IsErrant
+		st.SemiSyncSourceEnabled = gv.SemiSyncMasterEnabled
+		st.SemiSyncReplicaEnabled = gv.SemiSyncSlaveEnabled
+
+		if rs := ist.ReplicaStatus; rs != nil {
+			st.Replication = &mocov1beta2.InstanceReplicationStatus{
+				SourceHost:       rs.SourceHost,
+				IOThreadRunning:  rs.ReplicaIORunning,
+				SQLThreadRunning: rs.ReplicaSQLRunning,
+				LastIOErrno:      rs.LastIoErrno,
+				LastIOError:      rs.LastIoError,
+				LastSQLErrno:     rs.LastSQLErrno,
+				LastSQLError:     rs.LastSQLError,
+			}
+			if rs.SecondsBehindSource.Valid {
+				lag := rs.SecondsBehindSource.Int64
+				st.Replication.SecondsBehindSource = &lag
+			}
+		}
+		if cs := ist.CloneStatus; cs != nil && cs.State.Valid {
+			st.CloneState = cs.State.String
+		}
+	}
+	return instances
 }
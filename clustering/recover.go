@@ -0,0 +1,133 @@
+package clustering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/event"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// recoveryTarget returns the index of the instance given by `moco.cybozu.com/recover-to` annotation.
+// The recovery is accepted only for a Lost or Failed cluster and only to a reachable instance.
+func recoveryTarget(ss *StatusSet, value string) (int, error) {
+	if ss.Cluster.IsGroupReplication() {
+		return -1, errors.New("recovery is not supported in GroupReplication mode")
+	}
+	if ss.State != StateLost && ss.State != StateFailed {
+		return -1, fmt.Errorf("the cluster is %s, not Lost or Failed", ss.State)
+	}
+	target, err := strconv.Atoi(value)
+	if err != nil {
+		return -1, fmt.Errorf("invalid instance index %q: %w", value, err)
+	}
+	if target < 0 || target >= len(ss.MySQLStatus) {
+		return -1, fmt.Errorf("instance %d does not exist", target)
+	}
+	if ss.MySQLStatus[target] == nil {
+		return -1, fmt.Errorf("instance %d is unreachable", target)
+	}
+	if !isPromotable(ss, target) {
+		return -1, fmt.Errorf("instance %d must not be promoted", target)
+	}
+	return target, nil
+}
+
+// recoverCluster makes `ss.RecoverTo` the primary of a Lost or Failed cluster.
+// The instances that have transactions missing in the new primary are annotated
+// with `moco.cybozu.com/reclone-errant` so that they are re-cloned as errant replicas.
+func (p *managerProcess) recoverCluster(ctx context.Context, ss *StatusSet) (bool, error) {
+	log := logFromContext(ctx)
+
+	if ss.RecoveryRejected != nil {
+		log.Info("reject the recovery request", "reason", ss.RecoveryRejected.Error())
+		event.RecoveryRejected.Emit(ss.Cluster, p.recorder, ss.RecoveryRejected)
+		return false, p.removeAnnRecoverTo(ctx, ss)
+	}
+
+	target := ss.RecoverTo
+	log.Info("begin recovery", "current", ss.Primary, "new", target)
+
+	// fence the other instances not to accept writes.
+	for i, ist := range ss.MySQLStatus {
+		if i == target || ist == nil || ist.GlobalVariables.SuperReadOnly {
+			continue
+		}
+		if err := ss.DBOps[i].SetReadOnly(ctx, true); err != nil {
+			return false, fmt.Errorf("failed to make instance %d super_read_only: %w", i, err)
+		}
+	}
+
+	// apply the retrieved transactions as many as possible.
+	tst := ss.MySQLStatus[target]
+	gtid := tst.GlobalVariables.ExecutedGTID
+	if rs := tst.ReplicaStatus; rs != nil && rs.ReplicaSQLRunning == "Yes" && rs.RetrievedGtidSet != "" {
+		if err := ss.DBOps[target].StopReplicaIOThread(ctx); err != nil {
+			return false, err
+		}
+		log.Info("waiting for the new primary to execute all retrieved transactions", "index", target, "gtid", rs.RetrievedGtidSet)
+		if err := ss.DBOps[target].WaitForGTID(ctx, rs.RetrievedGtidSet, gtidWaitTimeoutSeconds(ss.Cluster)); err != nil {
+			return false, err
+		}
+		gtid = rs.RetrievedGtidSet + "," + gtid
+	}
+
+	var diverged []int
+	for i, ist := range ss.MySQLStatus {
+		if i == target || ist == nil {
+			continue
+		}
+		lost, err := ss.DBOps[target].SubtractGTID(ctx, ist.GlobalVariables.ExecutedGTID, gtid)
+		if err != nil {
+			return false, fmt.Errorf("failed to get the transactions of instance %d missing in instance %d: %w", i, target, err)
+		}
+		if lost == "" {
+			continue
+		}
+		log.Info("instance has diverged from the new primary", "instance", i, "lost", lost)
+		diverged = append(diverged, i)
+
+		pod := ss.Pods[i]
+		newPod := pod.DeepCopy()
+		if newPod.Annotations == nil {
+			newPod.Annotations = make(map[string]string)
+		}
+		newPod.Annotations[constants.AnnRecloneErrant] = "true"
+		if err := p.client.Patch(ctx, newPod, client.MergeFrom(pod)); err != nil {
+			return false, fmt.Errorf("failed to add %s annotation to instance %d: %w", constants.AnnRecloneErrant, i, err)
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cluster := &mocov1beta2.MySQLCluster{}
+		if err := p.reader.Get(ctx, p.name, cluster); err != nil {
+			return err
+		}
+		cluster.Status.CurrentPrimaryIndex = target
+		return p.client.Status().Update(ctx, cluster)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to set the current primary index: %w", err)
+	}
+	if err := p.removeAnnRecoverTo(ctx, ss); err != nil {
+		return false, err
+	}
+
+	log.Info("recovery finished", "primary", target, "diverged", diverged)
+	event.Recovered.Emit(ss.Cluster, p.recorder, target, diverged)
+	return true, nil
+}
+
+func (p *managerProcess) removeAnnRecoverTo(ctx context.Context, ss *StatusSet) error {
+	newCluster := ss.Cluster.DeepCopy()
+	delete(newCluster.Annotations, constants.AnnRecoverTo)
+	if err := p.client.Patch(ctx, newCluster, client.MergeFrom(ss.Cluster)); err != nil {
+		return fmt.Errorf("failed to remove %s annotation: %w", constants.AnnRecoverTo, err)
+	}
+	return nil
+}
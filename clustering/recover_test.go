@@ -0,0 +1,144 @@
+package clustering
+
+import (
+	"testing"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+)
+
+func TestRecoveryTarget(t *testing.T) {
+	newLost3 := func() *StatusSet {
+		return newSS(3, 0, false, false, false, false).
+			withPod(false, false, false).
+			withPod(true, false, false).
+			withPod(false, false, false).
+			withMySQL(nil).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(nil).
+			build()
+	}
+	newHealthy3 := func() *StatusSet {
+		return newSS(3, 0, false, false, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+	}
+
+	testCases := []struct {
+		name             string
+		statusSet        *StatusSet
+		value            string
+		expectedState    ClusterState
+		expectedTarget   int
+		expectedRejected bool
+	}{
+		{
+			name:           "lost",
+			statusSet:      newLost3(),
+			value:          "1",
+			expectedState:  StateLost,
+			expectedTarget: 1,
+		},
+		{
+			name:             "unreachable",
+			statusSet:        newLost3(),
+			value:            "2",
+			expectedState:    StateLost,
+			expectedRejected: true,
+		},
+		{
+			name:             "out-of-range",
+			statusSet:        newLost3(),
+			value:            "3",
+			expectedState:    StateLost,
+			expectedRejected: true,
+		},
+		{
+			name:             "invalid",
+			statusSet:        newLost3(),
+			value:            "foo",
+			expectedState:    StateLost,
+			expectedRejected: true,
+		},
+		{
+			name: "delayed",
+			statusSet: func() *StatusSet {
+				ss := newLost3()
+				ss.Cluster.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 1, DelaySeconds: 3600}}
+				return ss
+			}(),
+			value:            "1",
+			expectedState:    StateLost,
+			expectedRejected: true,
+		},
+		{
+			name: "never-promote",
+			statusSet: func() *StatusSet {
+				ss := newLost3()
+				ss.Pods[1].Annotations = map[string]string{constants.AnnNeverPromote: "true"}
+				return ss
+			}(),
+			value:            "1",
+			expectedState:    StateLost,
+			expectedRejected: true,
+		},
+		{
+			name:             "healthy",
+			statusSet:        newHealthy3(),
+			value:            "1",
+			expectedState:    StateHealthy,
+			expectedRejected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := tc.statusSet
+			ss.Cluster.Annotations = map[string]string{constants.AnnRecoverTo: tc.value}
+			ss.DecideState()
+			if ss.State != tc.expectedState {
+				t.Fatalf("wrong state %s: expected=%s", ss.State, tc.expectedState)
+			}
+			if !ss.RecoveryRequested {
+				t.Fatal("recovery is not requested")
+			}
+			if (ss.RecoveryRejected != nil) != tc.expectedRejected {
+				t.Fatalf("wrong RecoveryRejected: %v", ss.RecoveryRejected)
+			}
+
+			plan := makePlan(ss, nil)
+			if tc.expectedRejected {
+				if plan.NextPrimary != nil || len(plan.Steps) != 1 {
+					t.Errorf("wrong plan for a rejected request: %+v", plan)
+				}
+				return
+			}
+			if ss.RecoverTo != tc.expectedTarget {
+				t.Errorf("wrong target %d: expected=%d", ss.RecoverTo, tc.expectedTarget)
+			}
+			if plan.NextPrimary == nil || *plan.NextPrimary != tc.expectedTarget {
+				t.Errorf("wrong next primary in the plan: %v", plan.NextPrimary)
+			}
+		})
+	}
+
+	ss := newLost3()
+	ss.Cluster.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+	if _, err := recoveryTarget(ss, "1"); err == nil {
+		t.Error("recovery should be rejected in GroupReplication mode")
+	}
+
+	ss = newLost3()
+	ss.DecideState()
+	if ss.RecoveryRequested {
+		t.Error("recovery should not be requested without the annotation")
+	}
+}
@@ -0,0 +1,144 @@
+package clustering
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultRoleChangeWindow = time.Hour
+
+	// maxRoleChangeHistory is the maximum number of the records in `status.roleChangeHistory`.
+	maxRoleChangeHistory = 10
+)
+
+func roleChangeLimit(cluster *mocov1beta2.MySQLCluster) *mocov1beta2.RoleChangeLimitSpec {
+	if c := cluster.Spec.Clustering; c != nil {
+		return c.RoleChangeLimit
+	}
+	return nil
+}
+
+func roleChangeWindow(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if l := roleChangeLimit(cluster); l != nil && l.Window != nil {
+		return l.Window.Duration
+	}
+	return defaultRoleChangeWindow
+}
+
+// recentRoleChanges returns the number of the automatic role changes in the window before `now`.
+func recentRoleChanges(cluster *mocov1beta2.MySQLCluster, now time.Time) int {
+	window := roleChangeWindow(cluster)
+	var n int
+	for _, r := range cluster.Status.RoleChangeHistory {
+		if r.Manual {
+			continue
+		}
+		if now.Sub(r.Time.Time) < window {
+			n++
+		}
+	}
+	return n
+}
+
+// appendRoleChange appends a record of a role change to `history` and
+// drops the old records exceeding `maxRoleChangeHistory`.
+func appendRoleChange(history []mocov1beta2.RoleChangeRecord, typ mocov1beta2.RoleChangeType, from, to int, manual bool) []mocov1beta2.RoleChangeRecord {
+	history = append(history, mocov1beta2.RoleChangeRecord{
+		Type:   typ,
+		From:   from,
+		To:     to,
+		Manual: manual,
+		Time:   metav1.Now(),
+	})
+	if len(history) > maxRoleChangeHistory {
+		history = history[len(history)-maxRoleChangeHistory:]
+	}
+	return history
+}
+
+// holdRoleChanges holds the automatic switchovers and failovers if they would exceed
+// the role change limit.  Once held, they are held until an operator acknowledges it by
+// `moco.cybozu.com/acknowledge-role-change-hold` annotation.
+//
+// This sets `ss.RoleChangeHold` to the reason of the hold.  A switchover requested by
+// `moco.cybozu.com/demote` annotation and a failover approved by `moco.cybozu.com/approve-failover`
+// annotation are manual, so they are neither held nor counted.
+func holdRoleChanges(ss *StatusSet, now time.Time) {
+	if ss.Cluster.Annotations[constants.AnnAcknowledgeRoleChangeHold] == "true" {
+		// the history is cleared, so no limit is hit.
+		ss.RoleChangeHoldAcknowledged = true
+		return
+	}
+	limit := roleChangeLimit(ss.Cluster)
+	if limit == nil {
+		return
+	}
+
+	if cond := meta.FindStatusCondition(ss.Cluster.Status.Conditions, mocov1beta2.ConditionRoleChangeHeld); cond != nil && cond.Status == metav1.ConditionTrue {
+		ss.RoleChangeHold = cond.Message
+		return
+	}
+
+	switch {
+	case ss.State == StateFailed && !ss.FailoverPending && !failoverApproved(ss.Cluster):
+		ss.RoleChangeHold = roleChangeLimitHit(ss.Cluster, limit, mocov1beta2.RoleChangeFailover, now)
+	case (ss.State == StateHealthy || ss.State == StateDegraded) && ss.NeedSwitch && !manualSwitchover(ss):
+		ss.RoleChangeHold = roleChangeLimitHit(ss.Cluster, limit, mocov1beta2.RoleChangeSwitchover, now)
+	}
+}
+
+// roleChangeLimitHit returns the reason why the next role change of `typ` exceeds the limit.
+// If the role change is allowed, this returns an empty string.
+func roleChangeLimitHit(cluster *mocov1beta2.MySQLCluster, limit *mocov1beta2.RoleChangeLimitSpec, typ mocov1beta2.RoleChangeType, now time.Time) string {
+	history := cluster.Status.RoleChangeHistory
+	if typ == mocov1beta2.RoleChangeFailover && limit.MinFailoverInterval != nil {
+		for i := len(history) - 1; i >= 0; i-- {
+			r := history[i]
+			if r.Type != mocov1beta2.RoleChangeFailover || r.Manual {
+				continue
+			}
+			if elapsed := now.Sub(r.Time.Time); elapsed < limit.MinFailoverInterval.Duration {
+				return fmt.Sprintf("the last failover to instance %d was %s ago, within the minimum interval %s",
+					r.To, elapsed.Truncate(time.Second), limit.MinFailoverInterval.Duration)
+			}
+			break
+		}
+	}
+
+	if limit.MaxRoleChanges > 0 {
+		if n := recentRoleChanges(cluster, now); n >= int(limit.MaxRoleChanges) {
+			return fmt.Sprintf("the primary was changed %d times in the last %s", n, roleChangeWindow(cluster))
+		}
+	}
+	return ""
+}
+
+// manualSwitchover returns true if the switchover is requested by an operator
+// with `moco.cybozu.com/demote` annotation.
+func manualSwitchover(ss *StatusSet) bool {
+	ppod := ss.Pods[ss.Primary]
+	return ppod.DeletionTimestamp == nil && ss.ScaleInTarget == 0 && ppod.Annotations[constants.AnnDemote] == "true"
+}
+
+// removeAnnAcknowledgeRoleChangeHold removes the annotation after the acknowledgement
+// has been applied by `updateStatus`.  The annotation having a value other than "true"
+// is left as is.
+func (p *managerProcess) removeAnnAcknowledgeRoleChangeHold(ctx context.Context, ss *StatusSet) error {
+	if !ss.RoleChangeHoldAcknowledged {
+		return nil
+	}
+	newCluster := ss.Cluster.DeepCopy()
+	delete(newCluster.Annotations, constants.AnnAcknowledgeRoleChangeHold)
+	if err := p.client.Patch(ctx, newCluster, client.MergeFrom(ss.Cluster)); err != nil {
+		return fmt.Errorf("failed to remove moco.cybozu.com/acknowledge-role-change-hold annotation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package clustering
+
+import (
+	"testing"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHoldRoleChanges(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	record := func(typ mocov1beta2.RoleChangeType, ago time.Duration) mocov1beta2.RoleChangeRecord {
+		return mocov1beta2.RoleChangeRecord{Type: typ, From: 0, To: 1, Time: metav1.NewTime(now.Add(-ago))}
+	}
+	manual := func(ago time.Duration) mocov1beta2.RoleChangeRecord {
+		r := record(mocov1beta2.RoleChangeSwitchover, ago)
+		r.Manual = true
+		return r
+	}
+	limit := &mocov1beta2.RoleChangeLimitSpec{
+		MaxRoleChanges:      2,
+		MinFailoverInterval: &metav1.Duration{Duration: 10 * time.Minute},
+	}
+
+	testCases := []struct {
+		name               string
+		limit              *mocov1beta2.RoleChangeLimitSpec
+		state              ClusterState
+		deleting           bool
+		demoting           bool
+		approved           bool
+		needSwitch         bool
+		history            []mocov1beta2.RoleChangeRecord
+		held               bool
+		annotations        map[string]string
+		expectHold         string
+		expectNeedSw       bool
+		expectAcknowledged bool
+	}{
+		{
+			name:    "no-limit",
+			state:   StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{record(mocov1beta2.RoleChangeFailover, time.Minute)},
+		},
+		{
+			name:    "failover-allowed",
+			limit:   limit,
+			state:   StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{record(mocov1beta2.RoleChangeFailover, 20*time.Minute)},
+		},
+		{
+			name:  "failover-too-soon",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeFailover, 5*time.Minute),
+			},
+			expectHold: "the last failover to instance 1 was 5m0s ago, within the minimum interval 10m0s",
+		},
+		{
+			name:  "switchover-after-failover",
+			limit: limit,
+			state: StateHealthy,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeFailover, 5*time.Minute),
+			},
+			needSwitch:   true,
+			expectNeedSw: true,
+		},
+		{
+			name:  "too-many-role-changes",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeSwitchover, 2*time.Hour),
+				record(mocov1beta2.RoleChangeSwitchover, 30*time.Minute),
+				record(mocov1beta2.RoleChangeFailover, 20*time.Minute),
+			},
+			expectHold: "the primary was changed 2 times in the last 1h0m0s",
+		},
+		{
+			name:  "outside-window",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeSwitchover, 2*time.Hour),
+				record(mocov1beta2.RoleChangeSwitchover, 90*time.Minute),
+			},
+		},
+		{
+			name:  "manual-switchovers-not-counted",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				manual(40 * time.Minute),
+				manual(30 * time.Minute),
+				record(mocov1beta2.RoleChangeFailover, 20*time.Minute),
+			},
+		},
+		{
+			name:  "manual-switchover-not-held",
+			limit: limit,
+			state: StateHealthy,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeSwitchover, 30*time.Minute),
+				record(mocov1beta2.RoleChangeSwitchover, 20*time.Minute),
+			},
+			demoting:     true,
+			needSwitch:   true,
+			expectNeedSw: true,
+		},
+		{
+			name:  "deleted-primary",
+			limit: limit,
+			state: StateHealthy,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeSwitchover, 30*time.Minute),
+				record(mocov1beta2.RoleChangeSwitchover, 20*time.Minute),
+			},
+			deleting:     true,
+			needSwitch:   true,
+			expectHold:   "the primary was changed 2 times in the last 1h0m0s",
+			expectNeedSw: true,
+		},
+		{
+			name:  "approved-failover-not-held",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeFailover, 5*time.Minute),
+			},
+			approved: true,
+		},
+		{
+			name:  "manual-failover-not-counted",
+			limit: limit,
+			state: StateFailed,
+			history: []mocov1beta2.RoleChangeRecord{
+				func() mocov1beta2.RoleChangeRecord {
+					r := record(mocov1beta2.RoleChangeFailover, 5*time.Minute)
+					r.Manual = true
+					return r
+				}(),
+			},
+		},
+		{
+			name:         "kept-held",
+			limit:        limit,
+			state:        StateHealthy,
+			held:         true,
+			needSwitch:   true,
+			expectHold:   "held before",
+			expectNeedSw: true,
+		},
+		{
+			name:               "acknowledged-without-limit",
+			state:              StateFailed,
+			annotations:        map[string]string{constants.AnnAcknowledgeRoleChangeHold: "true"},
+			expectAcknowledged: true,
+		},
+		{
+			name:        "not-acknowledged",
+			limit:       limit,
+			state:       StateFailed,
+			held:        true,
+			annotations: map[string]string{constants.AnnAcknowledgeRoleChangeHold: "yes"},
+			expectHold:  "held before",
+		},
+		{
+			name:  "acknowledged",
+			limit: limit,
+			state: StateFailed,
+			held:  true,
+			history: []mocov1beta2.RoleChangeRecord{
+				record(mocov1beta2.RoleChangeFailover, 5*time.Minute),
+			},
+			annotations:        map[string]string{constants.AnnAcknowledgeRoleChangeHold: "true"},
+			expectAcknowledged: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := newSS(3, 0, false, false, false, false).
+				withPod(true, tc.deleting, tc.demoting).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(newMySQL("1234", false, false, false).build()).
+				withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build()
+			ss.State = tc.state
+			ss.NeedSwitch = tc.needSwitch
+			ss.Cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{RoleChangeLimit: tc.limit}
+			ss.Cluster.Status.RoleChangeHistory = tc.history
+			ss.Cluster.Annotations = tc.annotations
+			if tc.approved {
+				ss.Cluster.Spec.FailoverPolicy = mocov1beta2.FailoverPolicyManual
+				ss.Cluster.Annotations = map[string]string{constants.AnnApproveFailover: "true"}
+			}
+			if tc.held {
+				ss.Cluster.Status.Conditions = []metav1.Condition{{
+					Type:    mocov1beta2.ConditionRoleChangeHeld,
+					Status:  metav1.ConditionTrue,
+					Message: "held before",
+				}}
+			}
+
+			holdRoleChanges(ss, now)
+
+			if ss.RoleChangeHold != tc.expectHold {
+				t.Errorf("unexpected hold: expected=%q, actual=%q", tc.expectHold, ss.RoleChangeHold)
+			}
+			if ss.NeedSwitch != tc.expectNeedSw {
+				t.Errorf("unexpected NeedSwitch: expected=%v, actual=%v", tc.expectNeedSw, ss.NeedSwitch)
+			}
+			if ss.RoleChangeHoldAcknowledged != tc.expectAcknowledged {
+				t.Errorf("unexpected RoleChangeHoldAcknowledged: expected=%v, actual=%v", tc.expectAcknowledged, ss.RoleChangeHoldAcknowledged)
+			}
+		})
+	}
+}
+
+func TestAppendRoleChange(t *testing.T) {
+	var history []mocov1beta2.RoleChangeRecord
+	for i := 0; i < maxRoleChangeHistory+3; i++ {
+		history = appendRoleChange(history, mocov1beta2.RoleChangeSwitchover, i, i+1, i%2 == 0)
+	}
+	if len(history) != maxRoleChangeHistory {
+		t.Fatalf("unexpected length: %d", len(history))
+	}
+	if history[0].From != 3 || history[len(history)-1].To != maxRoleChangeHistory+3 {
+		t.Errorf("old records are not dropped: %+v", history)
+	}
+	if !history[len(history)-1].Manual || history[len(history)-2].Manual {
+		t.Errorf("manual is not recorded: %+v", history)
+	}
+}
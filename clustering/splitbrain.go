@@ -0,0 +1,118 @@
+package clustering
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/event"
+)
+
+// detectSplitBrain finds the instances that contradict the current primary
+// and sets `ss.SplitBrain` and `ss.WritableInstances`.
+//
+// A replica replicating from an instance other than the primary is reported only
+// if the instance is writable because the replicas keep replicating from the old primary
+// for a short while after a switchover.
+// This does nothing in GroupReplication mode, where the group elects the single primary.
+func detectSplitBrain(ss *StatusSet) {
+	if ss.Cluster.IsGroupReplication() {
+		return
+	}
+
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary || ist == nil {
+			continue
+		}
+		if !ist.GlobalVariables.ReadOnly {
+			ss.WritableInstances = append(ss.WritableInstances, i)
+		}
+	}
+	if len(ss.WritableInstances) > 0 {
+		ss.SplitBrain = append(ss.SplitBrain, fmt.Sprintf("instances %v are writable besides the primary instance %d", ss.WritableInstances, ss.Primary))
+	}
+
+	hosts := make(map[string]int)
+	for i := range ss.MySQLStatus {
+		hosts[ss.Cluster.PodHostname(i)] = i
+	}
+	sources := make(map[int]int)
+	for i, ist := range ss.MySQLStatus {
+		if ist == nil || ist.ReplicaStatus == nil || ist.ReplicaStatus.ReplicaIORunning != "Yes" {
+			continue
+		}
+		j, ok := hosts[ist.ReplicaStatus.SourceHost]
+		switch {
+		case !ok && i == ss.Primary:
+			// the primary replicates from the replication source or has yet to stop replication after a promotion.
+		case !ok:
+			ss.SplitBrain = append(ss.SplitBrain, fmt.Sprintf("instance %d replicates from a foreign host %s", i, ist.ReplicaStatus.SourceHost))
+		default:
+			sources[i] = j
+		}
+	}
+
+	inCycle := make(map[int]bool)
+	for i := range ss.MySQLStatus {
+		j, ok := sources[i]
+		if !ok {
+			continue
+		}
+		if cycle := replicationCycle(sources, i); cycle != nil {
+			if !inCycle[i] {
+				ss.SplitBrain = append(ss.SplitBrain, fmt.Sprintf("instances %v replicate in a cycle", cycle))
+				for _, k := range cycle {
+					inCycle[k] = true
+				}
+			}
+			continue
+		}
+		if j != ss.Primary && slices.Contains(ss.WritableInstances, j) {
+			ss.SplitBrain = append(ss.SplitBrain, fmt.Sprintf("instance %d replicates from the writable instance %d instead of the primary", i, j))
+		}
+	}
+}
+
+// replicationCycle returns the sorted instances in the replication cycle that `start` belongs to.
+// `sources` maps the index of a replica to the index of its source instance.
+// If `start` is not in a cycle, this returns nil.
+func replicationCycle(sources map[int]int, start int) []int {
+	cycle := []int{start}
+	for i := start; ; {
+		j, ok := sources[i]
+		if !ok {
+			return nil
+		}
+		if j == start {
+			slices.Sort(cycle)
+			return cycle
+		}
+		if slices.Contains(cycle, j) {
+			// a cycle that does not include `start`.
+			return nil
+		}
+		cycle = append(cycle, j)
+		i = j
+	}
+}
+
+func fenceSplitBrain(cluster *mocov1beta2.MySQLCluster) bool {
+	c := cluster.Spec.Clustering
+	return c != nil && c.FenceSplitBrain
+}
+
+// fenceWritableInstances makes the writable instances other than the primary `super_read_only`.
+// A failure is recorded as an event and does not prevent the other operations.
+func (p *managerProcess) fenceWritableInstances(ctx context.Context, ss *StatusSet) {
+	log := logFromContext(ctx)
+	for _, i := range ss.WritableInstances {
+		if err := ss.DBOps[i].SetReadOnly(ctx, true); err != nil {
+			log.Error(err, "failed to fence a writable instance", "instance", i)
+			event.SplitBrainFenceFailed.Emit(ss.Cluster, p.recorder, i, err)
+			continue
+		}
+		log.Info("fenced a writable instance", "instance", i)
+		event.SplitBrainFenced.Emit(ss.Cluster, p.recorder, i)
+	}
+}
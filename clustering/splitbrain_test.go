@@ -0,0 +1,124 @@
+package clustering
+
+import (
+	"slices"
+	"testing"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/dbop"
+)
+
+func TestDetectSplitBrain(t *testing.T) {
+	newHealthy3 := func() *StatusSet {
+		ss := newSS(3, 0, false, false, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+		for _, ist := range ss.MySQLStatus[1:] {
+			ist.ReplicaStatus.ReplicaIORunning = "Yes"
+		}
+		return ss
+	}
+	replicate := func(ist *dbop.MySQLInstanceStatus, host string) {
+		ist.ReplicaStatus = &dbop.ReplicaStatus{SourceHost: host, ReplicaIORunning: "Yes"}
+	}
+
+	testCases := []struct {
+		name             string
+		modify           func(ss *StatusSet)
+		expectedWritable []int
+		expectedProblems []string
+	}{
+		{
+			name:   "healthy",
+			modify: func(ss *StatusSet) {},
+		},
+		{
+			name: "writable-replica",
+			modify: func(ss *StatusSet) {
+				ss.MySQLStatus[2].GlobalVariables.ReadOnly = false
+				ss.MySQLStatus[2].GlobalVariables.SuperReadOnly = false
+			},
+			expectedWritable: []int{2},
+			expectedProblems: []string{"instances [2] are writable besides the primary instance 0"},
+		},
+		{
+			name: "foreign-source",
+			modify: func(ss *StatusSet) {
+				ss.MySQLStatus[1].ReplicaStatus.SourceHost = "mysql.example.com"
+			},
+			expectedProblems: []string{"instance 1 replicates from a foreign host mysql.example.com"},
+		},
+		{
+			name: "stopped-foreign-source",
+			modify: func(ss *StatusSet) {
+				ss.MySQLStatus[1].ReplicaStatus.SourceHost = "mysql.example.com"
+				ss.MySQLStatus[1].ReplicaStatus.ReplicaIORunning = "No"
+			},
+		},
+		{
+			name: "primary-with-replication-source",
+			modify: func(ss *StatusSet) {
+				replicate(ss.MySQLStatus[0], "mysql.example.com")
+			},
+		},
+		{
+			name: "after-switchover",
+			modify: func(ss *StatusSet) {
+				// instance 1 has just been promoted and the replicas still follow the old primary.
+				ss.Primary = 1
+				ss.MySQLStatus[0].GlobalVariables.ReadOnly = true
+				ss.MySQLStatus[0].GlobalVariables.SuperReadOnly = true
+			},
+		},
+		{
+			name: "old-primary",
+			modify: func(ss *StatusSet) {
+				ss.Primary = 1
+			},
+			expectedWritable: []int{0},
+			expectedProblems: []string{
+				"instances [0] are writable besides the primary instance 1",
+				"instance 1 replicates from the writable instance 0 instead of the primary",
+				"instance 2 replicates from the writable instance 0 instead of the primary",
+			},
+		},
+		{
+			name: "cycle",
+			modify: func(ss *StatusSet) {
+				replicate(ss.MySQLStatus[0], ss.Cluster.PodHostname(2))
+				replicate(ss.MySQLStatus[1], ss.Cluster.PodHostname(2))
+				replicate(ss.MySQLStatus[2], ss.Cluster.PodHostname(0))
+			},
+			expectedProblems: []string{"instances [0 2] replicate in a cycle"},
+		},
+		{
+			name: "group-replication",
+			modify: func(ss *StatusSet) {
+				ss.Cluster.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+				ss.MySQLStatus[2].GlobalVariables.ReadOnly = false
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := newHealthy3()
+			tc.modify(ss)
+			detectSplitBrain(ss)
+			if !slices.Equal(ss.WritableInstances, tc.expectedWritable) {
+				t.Errorf("wrong writable instances %v: expected=%v", ss.WritableInstances, tc.expectedWritable)
+			}
+			if !slices.Equal(ss.SplitBrain, tc.expectedProblems) {
+				t.Errorf("wrong problems %q: expected=%q", ss.SplitBrain, tc.expectedProblems)
+			}
+		})
+	}
+}
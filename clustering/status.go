@@ -1,11 +1,12 @@
 package clustering
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -15,7 +16,9 @@ import (
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/cybozu-go/moco/pkg/dbop"
 	"github.com/cybozu-go/moco/pkg/password"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -86,16 +89,75 @@ type StatusSet struct {
 	Cluster      *mocov1beta2.MySQLCluster
 	Password     *password.MySQLPassword
 	Pods         []*corev1.Pod
+	Zones        []string
 	DBOps        []dbop.Operator
 	MySQLStatus  []*dbop.MySQLInstanceStatus
 	ExecutedGTID string
 	Errants      []int
 	Candidates   []int
 
+	// Excess is the list of the instances to be removed by scale-in.
+	Excess []int
+	// ScaleInTarget is the number of instances after scale-in.
+	// It is non-zero only while the primary instance is to be removed by scale-in.
+	ScaleInTarget int
+	// SourceHost is the hostname of the current primary instance of the source MySQLCluster
+	// given by `spec.replicationSource`.
+	SourceHost string
+
+	// OutsideMaintenanceWindow is true if voluntary disruptive operations must wait
+	// for one of `spec.maintenanceWindows` to open.
+	OutsideMaintenanceWindow bool
+	// NextMaintenanceWindow is the time when the next maintenance window opens.
+	NextMaintenanceWindow time.Time
+	// WaitingOperations is the list of the operations waiting for a maintenance window.
+	WaitingOperations []string
+	// RolloutInterrupted describes the rolling update interrupted by the end of a maintenance window.
+	// Until the next window opens, the instances run different Pod templates, e.g. different MySQL versions.
+	RolloutInterrupted string
+
+	// SwitchoverRisks is the list of the reasons why the planned switchover is unsafe.
+	// It is set by `checkSwitchover`.
+	SwitchoverRisks []string
+	// SwitchoverPostponed is true if the switchover is postponed because of `SwitchoverRisks`.
+	SwitchoverPostponed bool
+
+	// SplitBrain is the list of the inconsistencies of the replication topology found by `detectSplitBrain`.
+	SplitBrain []string
+	// WritableInstances is the list of the writable instances other than the primary.
+	WritableInstances []int
+
+	// RecoveryRequested is true if MySQLCluster has `moco.cybozu.com/recover-to` annotation.
+	RecoveryRequested bool
+	// RecoverTo is the instance to be the new primary by the recovery.
+	RecoverTo int
+	// RecoveryRejected is the reason why the recovery request cannot be accepted.
+	RecoveryRejected error
+
+	// GroupMemberStates is the state of each instance as a member of the group.
+	// It is set only in GroupReplication mode.
+	GroupMemberStates []string
+	// GroupQuorum is true if the majority of the group members are reachable.
+	GroupQuorum bool
+
+	// MissingGTIDs is the number of the transactions executed on the primary but not on each instance.
+	// It is NaN if the number cannot be counted, and is nil if the primary is unreachable.
+	MissingGTIDs []float64
+
+	// RoleChangeHold is the reason why the failovers are held by the role change limit.
+	RoleChangeHold string
+	// RoleChangeHoldAcknowledged is true if an operator acknowledged the hold of the role changes.
+	RoleChangeHoldAcknowledged bool
+
 	NeedSwitch         bool
+	SwitchRejected     error
 	PreventPodDeletion bool
+	FailoverPending    bool
 	Candidate          int
 	State              ClusterState
+
+	// Plan is the operations to be performed for the cluster.  It is set by `managerProcess.do`.
+	Plan *mocov1beta2.OperationPlan
 }
 
 // Close closes `ss.DBOps`.
@@ -108,9 +170,16 @@ func (ss *StatusSet) Close() {
 }
 
 // DecideState decides the ClusterState and set it to `ss.State`.
-// It may also set `ss.NeedSwitch` and `ss.Candidate` for switchover.
+// It may also set `ss.NeedSwitch` and `ss.Candidate` for switchover,
+// `ss.SwitchRejected` for a switchover request that cannot be accepted,
+// `ss.WaitingOperations` for a switchover request waiting for a maintenance window,
+// `ss.FailoverPending` for failover that needs an approval,
+// `ss.RecoveryRequested` and `ss.RecoverTo` for a recovery request,
+// and `ss.RoleChangeHold` for switchovers and failovers held by the role change limit.
 func (ss *StatusSet) DecideState() {
 	switch {
+	case ss.Cluster.IsGroupReplication():
+		ss.State = decideGroupState(ss)
 	case isOffline(ss):
 		ss.State = StateOffline
 	case isCloning(ss):
@@ -128,12 +197,76 @@ func (ss *StatusSet) DecideState() {
 	default:
 		ss.State = StateIncomplete
 	}
+	if ss.State == StateFailed && needFailoverApproval(ss.Cluster) {
+		ss.FailoverPending = true
+	}
+	if v, ok := ss.Cluster.Annotations[constants.AnnRecoverTo]; ok {
+		ss.RecoveryRequested = true
+		ss.RecoverTo, ss.RecoveryRejected = recoveryTarget(ss, v)
+	}
 	if len(ss.Candidates) > 0 {
-		ss.NeedSwitch = needSwitch(ss.Pods[ss.Primary])
-		// Choose the lowest ordinal for a switchover target.
-		sort.Ints(ss.Candidates)
-		ss.Candidate = ss.Candidates[0]
+		ppod := ss.Pods[ss.Primary]
+		ss.NeedSwitch = needSwitch(ppod) || ss.ScaleInTarget > 0
+		ss.Candidate = chooseCandidate(ss, ss.Candidates)
+
+		if ss.NeedSwitch {
+			target, err := switchoverTarget(ss, ppod)
+			switch {
+			case err == nil:
+				ss.Candidate = target
+			case ppod.DeletionTimestamp != nil:
+				// the primary is going away, so the switchover cannot be rejected.
+			default:
+				ss.NeedSwitch = false
+				ss.SwitchRejected = err
+			}
+		}
+
+		// a planned switchover waits for a maintenance window, but the switchover
+		// of a deleted primary or for scale-in cannot be delayed.
+		if ss.NeedSwitch && ss.OutsideMaintenanceWindow && ppod.DeletionTimestamp == nil && ss.ScaleInTarget == 0 {
+			ss.NeedSwitch = false
+			ss.WaitingOperations = append(ss.WaitingOperations, "switchover")
+		}
 	}
+	holdRoleChanges(ss, time.Now())
+}
+
+// gatherMissingGTIDs counts the transactions executed on the primary but not on
+// each replica and sets `ss.MissingGTIDs`.  The instances are compared in parallel
+// on their own connections.
+func gatherMissingGTIDs(ctx context.Context, ss *StatusSet) {
+	pst := ss.MySQLStatus[ss.Primary]
+	if pst == nil {
+		return
+	}
+	log := logFromContext(ctx)
+
+	ss.MissingGTIDs = make([]float64, len(ss.MySQLStatus))
+	var wg sync.WaitGroup
+	for i, ist := range ss.MySQLStatus {
+		if i == ss.Primary || ist == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+
+			ss.MissingGTIDs[index] = math.NaN()
+			missing, err := ss.DBOps[index].SubtractGTID(ctx, pst.GlobalVariables.ExecutedGTID, ist.GlobalVariables.ExecutedGTID)
+			if err != nil {
+				log.Error(err, "failed to get missing transactions", "instance", index)
+				return
+			}
+			n, err := dbop.CountGTIDs(missing)
+			if err != nil {
+				log.Error(err, "failed to count missing transactions", "instance", index)
+				return
+			}
+			ss.MissingGTIDs[index] = float64(n)
+		}(i)
+	}
+	wg.Wait()
 }
 
 // GatherStatus collects information and Kubernetes resources and construct
@@ -169,9 +302,15 @@ func (p *managerProcess) GatherStatus(ctx context.Context) (*StatusSet, error) {
 		return nil, fmt.Errorf("failed to list Pods: %w", err)
 	}
 
-	if !cluster.Spec.Offline && int(cluster.Spec.Replicas) != len(pods.Items) {
-		return nil, fmt.Errorf("too few pods; only %d pods exist", len(pods.Items))
+	// If the primary instance is to be removed by scale-in, the instances up to
+	// the primary are managed as the cluster members until it is switched over.
+	if !cluster.Spec.Offline && ss.Primary >= int(cluster.Spec.Replicas) {
+		ss.ScaleInTarget = int(cluster.Spec.Replicas)
+		cluster = cluster.DeepCopy()
+		cluster.Spec.Replicas = int32(ss.Primary + 1)
+		ss.Cluster = cluster
 	}
+
 	ss.Pods = make([]*corev1.Pod, cluster.Spec.Replicas)
 	for i, pod := range pods.Items {
 		fields := strings.Split(pod.Name, "-")
@@ -180,11 +319,67 @@ func (p *managerProcess) GatherStatus(ctx context.Context) (*StatusSet, error) {
 			return nil, fmt.Errorf("bad pod name: %s", pod.Name)
 		}
 
-		if index < 0 || index >= len(pods.Items) {
+		if index < 0 {
 			return nil, fmt.Errorf("index out of range: %d", index)
 		}
+		if index >= len(ss.Pods) {
+			if !cluster.Spec.Offline {
+				ss.Excess = append(ss.Excess, index)
+			}
+			continue
+		}
 		ss.Pods[index] = &pods.Items[i]
 	}
+	slices.Sort(ss.Excess)
+	if !cluster.Spec.Offline && slices.Contains(ss.Pods, nil) {
+		return nil, fmt.Errorf("too few pods; only %d pods exist", len(pods.Items))
+	}
+
+	if cluster.Spec.ReplicationSource != nil {
+		source, err := p.getSourceCluster(ctx, cluster)
+		if err != nil {
+			log.Error(err, "failed to get the source cluster")
+		} else {
+			ss.SourceHost = source.PodHostname(source.Status.CurrentPrimaryIndex)
+		}
+	}
+
+	if ok, next := cluster.InMaintenanceWindow(time.Now()); !ok {
+		ss.OutsideMaintenanceWindow = true
+		ss.NextMaintenanceWindow = next
+
+		sts := &appsv1.StatefulSet{}
+		err := p.client.Get(ctx, client.ObjectKey{Namespace: p.name.Namespace, Name: cluster.PrefixedName()}, sts)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get StatefulSet: %w", err)
+		}
+		if err == nil {
+			if rolloutWaiting(sts) {
+				ss.WaitingOperations = append(ss.WaitingOperations, "rolling update")
+				if sts.Status.UpdatedReplicas > 0 {
+					ss.RolloutInterrupted = fmt.Sprintf("%d of %d instances are updated", sts.Status.UpdatedReplicas, sts.Status.Replicas)
+				}
+			}
+			if volumeResizeWaiting(cluster, sts) {
+				ss.WaitingOperations = append(ss.WaitingOperations, "volume resize")
+			}
+		}
+	}
+
+	if cluster.Spec.Promotion != nil && len(cluster.Spec.Promotion.PreferredZones) > 0 {
+		ss.Zones = make([]string, cluster.Spec.Replicas)
+		for i, pod := range ss.Pods {
+			if pod == nil || pod.Spec.NodeName == "" {
+				continue
+			}
+			node := &corev1.Node{}
+			if err := p.reader.Get(ctx, client.ObjectKey{Name: pod.Spec.NodeName}, node); err != nil {
+				log.Error(err, "failed to get the node", "instance", i, "node", pod.Spec.NodeName)
+				continue
+			}
+			ss.Zones[i] = node.Labels[corev1.LabelTopologyZone]
+		}
+	}
 
 	ss.DBOps = make([]dbop.Operator, cluster.Spec.Replicas)
 	defer func() {
@@ -228,6 +423,18 @@ func (p *managerProcess) GatherStatus(ctx context.Context) (*StatusSet, error) {
 	}
 	wg.Wait()
 
+	// the group decides the primary, and the replication of the group is not
+	// subject to the checks for the semi-synchronous replication below.
+	if cluster.IsGroupReplication() {
+		gatherGroupStatus(ss)
+		if pst := ss.MySQLStatus[ss.Primary]; pst != nil && ss.GroupQuorum {
+			ss.ExecutedGTID = pst.GlobalVariables.ExecutedGTID
+		}
+		gatherMissingGTIDs(ctx, ss)
+		ss.DecideState()
+		return ss, nil
+	}
+
 	// re-check the primary MySQL status to retrieve the latest executed GTID set
 	if ss.MySQLStatus[ss.Primary] != nil {
 		time.Sleep(100 * time.Millisecond)
@@ -238,6 +445,7 @@ func (p *managerProcess) GatherStatus(ctx context.Context) (*StatusSet, error) {
 		ss.MySQLStatus[ss.Primary] = pst
 		ss.ExecutedGTID = pst.GlobalVariables.ExecutedGTID
 	}
+	gatherMissingGTIDs(ctx, ss)
 
 	// detect replication delay
 	if cluster.Spec.MaxDelaySecondsForPodDeletion > 0 {
@@ -252,6 +460,9 @@ func (p *managerProcess) GatherStatus(ctx context.Context) (*StatusSet, error) {
 			if ist.ReplicaStatus == nil {
 				continue
 			}
+			if isAsync(ss, i) {
+				continue
+			}
 			if ist.ReplicaStatus.SecondsBehindSource.Valid && ist.ReplicaStatus.SecondsBehindSource.Int64 > cluster.Spec.MaxDelaySecondsForPodDeletion {
 				preventPodDeletion = true
 				break
@@ -393,7 +604,11 @@ func lostData(ss *StatusSet) bool {
 }
 
 func isHealthy(ss *StatusSet) bool {
-	for _, pod := range ss.Pods {
+	for i, pod := range ss.Pods {
+		// The readiness probe of a delayed replica may fail due to the intentional delay.
+		if isDelayed(ss, i) {
+			continue
+		}
 		if !isPodReady(pod) {
 			return false
 		}
@@ -419,7 +634,12 @@ func isHealthy(ss *StatusSet) bool {
 		if ist.ReplicaStatus.SourceHost != primaryHostname {
 			return false
 		}
-		ss.Candidates = append(ss.Candidates, i)
+		if isAsync(ss, i) && !ist.ReplicaStatus.IsRunning() {
+			return false
+		}
+		if isPromotable(ss, i) {
+			ss.Candidates = append(ss.Candidates, i)
+		}
 	}
 
 	pst := ss.MySQLStatus[ss.Primary]
@@ -429,7 +649,7 @@ func isHealthy(ss *StatusSet) bool {
 	if replicasInCluster(ss.Cluster, pst.ReplicaHosts) != (ss.Cluster.Spec.Replicas - 1) {
 		return false
 	}
-	if ss.Cluster.Spec.ReplicationSourceSecretName != nil {
+	if ss.Cluster.HasReplicationSource() {
 		if !pst.GlobalVariables.SuperReadOnly {
 			return false
 		}
@@ -443,7 +663,7 @@ func isHealthy(ss *StatusSet) bool {
 }
 
 func isCloning(ss *StatusSet) bool {
-	if ss.Cluster.Spec.ReplicationSourceSecretName == nil {
+	if !ss.Cluster.HasReplicationSource() {
 		return false
 	}
 
@@ -489,7 +709,7 @@ func isDegraded(ss *StatusSet) bool {
 	if pst == nil {
 		return false
 	}
-	if ss.Cluster.Spec.ReplicationSourceSecretName != nil {
+	if ss.Cluster.HasReplicationSource() {
 		if !pst.GlobalVariables.SuperReadOnly {
 			return false
 		}
@@ -502,8 +722,11 @@ func isDegraded(ss *StatusSet) bool {
 		return false
 	}
 
+	// reset the candidates that isHealthy may have collected.
+	ss.Candidates = nil
+
 	primaryHostname := ss.Cluster.PodHostname(ss.Primary)
-	var okReplicas int
+	var okReplicas, okAsyncReplicas int
 	for i, ist := range ss.MySQLStatus {
 		if i == ss.Primary {
 			continue
@@ -511,7 +734,8 @@ func isDegraded(ss *StatusSet) bool {
 		if ist == nil {
 			continue
 		}
-		if !isPodReady(ss.Pods[i]) {
+		// The readiness probe of a delayed replica may fail due to the intentional delay.
+		if !isDelayed(ss, i) && !isPodReady(ss.Pods[i]) {
 			continue
 		}
 		if !ist.GlobalVariables.SuperReadOnly {
@@ -526,11 +750,19 @@ func isDegraded(ss *StatusSet) bool {
 		if ist.IsErrant {
 			continue
 		}
+		if isAsync(ss, i) {
+			if ist.ReplicaStatus.IsRunning() {
+				okAsyncReplicas++
+			}
+			continue
+		}
 		okReplicas++
-		ss.Candidates = append(ss.Candidates, i)
+		if isPromotable(ss, i) {
+			ss.Candidates = append(ss.Candidates, i)
+		}
 	}
 
-	return okReplicas >= (int(ss.Cluster.Spec.Replicas)/2) && okReplicas != int(ss.Cluster.Spec.Replicas-1)
+	return okReplicas >= semiSyncWaitCount(ss) && okReplicas+okAsyncReplicas != int(ss.Cluster.Spec.Replicas-1)
 }
 
 func isFailed(ss *StatusSet) bool {
@@ -556,10 +788,13 @@ func isFailed(ss *StatusSet) bool {
 		if ist.GlobalVariables.ExecutedGTID == "" {
 			continue
 		}
+		if isAsync(ss, i) {
+			continue
+		}
 		okReplicas++
 	}
 
-	return okReplicas > (int(ss.Cluster.Spec.Replicas) / 2)
+	return okReplicas > maxReplicasWithoutAck(ss)
 }
 
 func isLost(ss *StatusSet) bool {
@@ -585,16 +820,262 @@ func isLost(ss *StatusSet) bool {
 		if ist.GlobalVariables.ExecutedGTID == "" {
 			continue
 		}
+		if isAsync(ss, i) {
+			continue
+		}
 		okReplicas++
 	}
 
-	return okReplicas <= (int(ss.Cluster.Spec.Replicas) / 2)
+	return okReplicas <= maxReplicasWithoutAck(ss)
+}
+
+// maxReplicasWithoutAck returns the maximum number of semi-synchronous replicas
+// that may lack the latest transactions.  If more replicas are available,
+// at least one of them has all the acknowledged transactions.
+func maxReplicasWithoutAck(ss *StatusSet) int {
+	return max(semiSyncInstances(ss)-1-semiSyncWaitCount(ss), 0)
 }
 
 func isOffline(ss *StatusSet) bool {
 	return ss.Cluster.Spec.Offline
 }
 
+// switchoverTarget returns the index of the instance requested by
+// `moco.cybozu.com/switchover-to` annotation of the primary Pod.
+// If the annotation is not given, this returns `ss.Candidate`.
+func switchoverTarget(ss *StatusSet, ppod *corev1.Pod) (int, error) {
+	val, ok := ppod.Annotations[constants.AnnSwitchoverTo]
+	if !ok {
+		return ss.Candidate, nil
+	}
+	target, err := strconv.Atoi(val)
+	if err != nil {
+		return -1, fmt.Errorf("invalid %s annotation %q: %w", constants.AnnSwitchoverTo, val, err)
+	}
+	if !slices.Contains(ss.Candidates, target) {
+		return -1, fmt.Errorf("instance %d is not a switchover candidate; candidates are %v", target, ss.Candidates)
+	}
+	return target, nil
+}
+
+// replicationDelay returns the delay in seconds configured for the instance.
+// It returns 0 if the instance is not a delayed replica.
+func replicationDelay(cluster *mocov1beta2.MySQLCluster, index int) int {
+	for _, d := range cluster.Spec.DelayedReplicas {
+		if int(d.Index) == index {
+			return int(d.DelaySeconds)
+		}
+	}
+	return 0
+}
+
+// isDelayed returns true if the instance is a replica that applies transactions with a delay.
+func isDelayed(ss *StatusSet, index int) bool {
+	return index != ss.Primary && replicationDelay(ss.Cluster, index) > 0
+}
+
+// isReadPool returns true if the instance is in the asynchronous read pool.
+func isReadPool(cluster *mocov1beta2.MySQLCluster, index int) bool {
+	return slices.Contains(cluster.Spec.ReadPoolReplicas, int32(index))
+}
+
+// isAsync returns true if the instance is a replica that does not take part in
+// the semi-synchronous replication, that is, a delayed replica or a read pool replica.
+func isAsync(ss *StatusSet, index int) bool {
+	return isDelayed(ss, index) || (index != ss.Primary && isReadPool(ss.Cluster, index))
+}
+
+// semiSyncInstances returns the number of instances that take part in
+// the semi-synchronous replication, that is, the primary and non-asynchronous replicas.
+func semiSyncInstances(ss *StatusSet) int {
+	n := 1
+	for i := 0; i < int(ss.Cluster.Spec.Replicas); i++ {
+		if i == ss.Primary || isAsync(ss, i) {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// semiSyncWaitCount returns the number of replicas that must acknowledge transactions.
+func semiSyncWaitCount(ss *StatusSet) int {
+	if semiSync := ss.Cluster.Spec.SemiSync; semiSync != nil && semiSync.WaitForReplicaCount != nil {
+		return int(*semiSync.WaitForReplicaCount)
+	}
+	return semiSyncInstances(ss) / 2
+}
+
+// semiSyncTimeoutSeconds returns the time for the primary to wait for the acknowledgements.
+func semiSyncTimeoutSeconds(cluster *mocov1beta2.MySQLCluster) int {
+	if semiSync := cluster.Spec.SemiSync; semiSync != nil && semiSync.TimeoutSeconds != nil {
+		return int(*semiSync.TimeoutSeconds)
+	}
+	return defaultSemiSyncTimeoutSeconds
+}
+
+// gtidWaitTimeoutSeconds returns the timeout to wait for the new primary to execute transactions.
+func gtidWaitTimeoutSeconds(cluster *mocov1beta2.MySQLCluster) int {
+	if c := cluster.Spec.Clustering; c != nil && c.GTIDWaitTimeoutSeconds != nil {
+		return int(*c.GTIDWaitTimeoutSeconds)
+	}
+	return defaultGTIDWaitTimeoutSeconds
+}
+
+// readOnlyTimeout returns the timeout to wait for the primary to become read-only on switchover.
+func readOnlyTimeout(cluster *mocov1beta2.MySQLCluster) (time.Duration, error) {
+	if c := cluster.Spec.Clustering; c != nil && c.ReadOnlyTimeoutSeconds != nil {
+		return time.Duration(*c.ReadOnlyTimeoutSeconds) * time.Second, nil
+	}
+	preStopSeconds, err := strconv.Atoi(constants.PreStopSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(preStopSeconds/2) * time.Second, nil
+}
+
+// roleChangeWait returns the time to wait for the role change of Pods to be propagated.
+func roleChangeWait(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if c := cluster.Spec.Clustering; c != nil && c.RoleChangeWait != nil {
+		return c.RoleChangeWait.Duration
+	}
+	return waitForRoleChangeDuration
+}
+
+// drainOptions returns the options to kill the client connections after draining them
+// on role changes of the instances.
+func drainOptions(cluster *mocov1beta2.MySQLCluster) dbop.KillOptions {
+	opts := dbop.KillOptions{GracePeriod: drainGracePeriod(cluster)}
+	if c := cluster.Spec.Clustering; c != nil && c.ConnectionDrain != nil {
+		opts.KillFirstUsers = c.ConnectionDrain.KillFirstUsers
+	}
+	return opts
+}
+
+// primaryDrainOptions returns the options to drain the client connections of the primary
+// before a switchover.  Only this drain spares the connections of the protected users;
+// they are killed with the others if the primary cannot be made read-only in time.
+func primaryDrainOptions(cluster *mocov1beta2.MySQLCluster) dbop.KillOptions {
+	opts := drainOptions(cluster)
+	if c := cluster.Spec.Clustering; c != nil && c.ConnectionDrain != nil {
+		opts.ProtectedUsers = c.ConnectionDrain.ProtectedUsers
+	}
+	return opts
+}
+
+// drainGracePeriod returns the time to wait for the client sessions to become idle.
+func drainGracePeriod(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if c := cluster.Spec.Clustering; c != nil && c.ConnectionDrain != nil && c.ConnectionDrain.GracePeriod != nil {
+		return c.ConnectionDrain.GracePeriod.Duration
+	}
+	return 0
+}
+
+// cloneRestartWait returns the time to wait for mysqld to restart after a clone.
+func cloneRestartWait(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	if c := cluster.Spec.Clustering; c != nil && c.CloneRestartWait != nil {
+		return c.CloneRestartWait.Duration
+	}
+	return waitForCloneRestartDuration
+}
+
+// isPromotable returns false if the instance must never be promoted to the primary.
+func isPromotable(ss *StatusSet, index int) bool {
+	if !ss.Cluster.IsPromotable(index) {
+		return false
+	}
+	if ss.ScaleInTarget > 0 && index >= ss.ScaleInTarget {
+		return false
+	}
+	if pod := ss.Pods[index]; pod != nil && pod.Annotations[constants.AnnNeverPromote] == "true" {
+		return false
+	}
+	return true
+}
+
+// chooseCandidate returns the most preferable instance in `indices` to be promoted.
+// Instances are compared by the promotion priority, the preferred zones,
+// the replication lag, and the ordinal in this order.
+//
+// The caller is responsible for passing only instances that are equally up-to-date.
+func chooseCandidate(ss *StatusSet, indices []int) int {
+	return slices.MinFunc(indices, func(a, b int) int {
+		return cmp.Or(
+			cmp.Compare(promotionPriority(ss, b), promotionPriority(ss, a)),
+			cmp.Compare(zoneRank(ss, a), zoneRank(ss, b)),
+			cmp.Compare(replicationLag(ss, a), replicationLag(ss, b)),
+			cmp.Compare(a, b),
+		)
+	})
+}
+
+func promotionPriority(ss *StatusSet, index int) int {
+	if pod := ss.Pods[index]; pod != nil {
+		if val, ok := pod.Annotations[constants.AnnPromotionPriority]; ok {
+			if prio, err := strconv.Atoi(val); err == nil {
+				return prio
+			}
+		}
+	}
+	promotion := ss.Cluster.Spec.Promotion
+	if promotion != nil && index < len(promotion.Priorities) {
+		return int(promotion.Priorities[index])
+	}
+	return 0
+}
+
+func zoneRank(ss *StatusSet, index int) int {
+	promotion := ss.Cluster.Spec.Promotion
+	if promotion == nil || index >= len(ss.Zones) || ss.Zones[index] == "" {
+		return math.MaxInt
+	}
+	rank := slices.Index(promotion.PreferredZones, ss.Zones[index])
+	if rank < 0 {
+		return math.MaxInt
+	}
+	return rank
+}
+
+func replicationLag(ss *StatusSet, index int) int64 {
+	ist := ss.MySQLStatus[index]
+	if ist == nil || ist.ReplicaStatus == nil || !ist.ReplicaStatus.SecondsBehindSource.Valid {
+		return math.MaxInt64
+	}
+	return ist.ReplicaStatus.SecondsBehindSource.Int64
+}
+
+// rolloutWaiting returns true if the Pods of the StatefulSet are not updated
+// because the partition is not decreased outside of the maintenance windows.
+func rolloutWaiting(sts *appsv1.StatefulSet) bool {
+	if sts.Annotations[constants.AnnForceRollingUpdate] == "true" {
+		return false
+	}
+	ru := sts.Spec.UpdateStrategy.RollingUpdate
+	if ru == nil || ru.Partition == nil || *ru.Partition == 0 {
+		return false
+	}
+	return sts.Status.UpdateRevision != sts.Status.CurrentRevision
+}
+
+// volumeResizeWaiting returns true if the volumes of the StatefulSet are smaller than
+// the cluster requests because the resize is delayed outside of the maintenance windows.
+func volumeResizeWaiting(cluster *mocov1beta2.MySQLCluster, sts *appsv1.StatefulSet) bool {
+	for _, want := range cluster.Spec.VolumeClaimTemplates {
+		if want.Spec.Resources == nil || want.Spec.Resources.Requests == nil {
+			continue
+		}
+		for _, deployed := range sts.Spec.VolumeClaimTemplates {
+			if deployed.Name != want.Name {
+				continue
+			}
+			if deployed.Spec.Resources.Requests.Storage().Cmp(want.Spec.Resources.Requests.Storage().DeepCopy()) < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func needSwitch(pod *corev1.Pod) bool {
 	if pod.DeletionTimestamp != nil {
 		return true
@@ -604,3 +1085,16 @@ func needSwitch(pod *corev1.Pod) bool {
 	}
 	return false
 }
+
+func needFailoverApproval(cluster *mocov1beta2.MySQLCluster) bool {
+	if cluster.Spec.FailoverPolicy != mocov1beta2.FailoverPolicyManual {
+		return false
+	}
+	return cluster.Annotations[constants.AnnApproveFailover] != "true"
+}
+
+// failoverApproved returns true if an operator approved the failover of a cluster
+// with `Manual` failover policy.
+func failoverApproved(cluster *mocov1beta2.MySQLCluster) bool {
+	return cluster.Spec.FailoverPolicy == mocov1beta2.FailoverPolicyManual && cluster.Annotations[constants.AnnApproveFailover] == "true"
+}
@@ -1,8 +1,12 @@
 package clustering
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"testing"
 	"time"
 
@@ -21,6 +25,10 @@ type ssBuilder struct {
 	toRestore      bool
 	isRestored     bool
 	isCloned       bool
+	failoverPolicy mocov1beta2.FailoverPolicy
+	approved       bool
+	switchoverTo   string
+	outsideWindow  bool
 	pods           []*corev1.Pod
 	mysqlStatus    []*dbop.MySQLInstanceStatus
 }
@@ -53,6 +61,19 @@ func (b *ssBuilder) build() *StatusSet {
 	if b.isCloned {
 		cluster.Status.Cloned = true
 	}
+	cluster.Spec.FailoverPolicy = b.failoverPolicy
+	if b.switchoverTo != "" {
+		ppod := b.pods[b.primaryIndex]
+		if ppod.Annotations == nil {
+			ppod.Annotations = make(map[string]string)
+		}
+		ppod.Annotations["moco.cybozu.com/switchover-to"] = b.switchoverTo
+	}
+	if b.approved {
+		cluster.Annotations = map[string]string{
+			"moco.cybozu.com/approve-failover": "true",
+		}
+	}
 	var errants []int
 	for i, ist := range b.mysqlStatus {
 		if i == b.primaryIndex {
@@ -71,11 +92,12 @@ func (b *ssBuilder) build() *StatusSet {
 		gtid = pst.GlobalVariables.ExecutedGTID
 	}
 	return &StatusSet{
-		Cluster:      cluster,
-		Pods:         b.pods,
-		MySQLStatus:  b.mysqlStatus,
-		Errants:      errants,
-		ExecutedGTID: gtid,
+		Cluster:                  cluster,
+		Pods:                     b.pods,
+		MySQLStatus:              b.mysqlStatus,
+		Errants:                  errants,
+		ExecutedGTID:             gtid,
+		OutsideMaintenanceWindow: b.outsideWindow,
 	}
 }
 
@@ -90,6 +112,22 @@ func newSS(replicas int32, primary int, intermediate, toRestore, isRestored, isC
 	}
 }
 
+func (b *ssBuilder) withFailoverPolicy(policy mocov1beta2.FailoverPolicy, approved bool) *ssBuilder {
+	b.failoverPolicy = policy
+	b.approved = approved
+	return b
+}
+
+func (b *ssBuilder) withSwitchoverTo(target string) *ssBuilder {
+	b.switchoverTo = target
+	return b
+}
+
+func (b *ssBuilder) outsideMaintenanceWindow() *ssBuilder {
+	b.outsideWindow = true
+	return b
+}
+
 func (b *ssBuilder) withPod(ready, deleting, demoting bool) *ssBuilder {
 	pod := &corev1.Pod{}
 	if ready {
@@ -192,10 +230,12 @@ func (b *mysqlBuilder) withReplica(serverID int32, hostname string) *mysqlBuilde
 
 func TestStatusSet(t *testing.T) {
 	testCases := []struct {
-		name           string
-		statusSet      *StatusSet
-		expectedState  ClusterState
-		expectedSwitch bool
+		name            string
+		statusSet       *StatusSet
+		expectedState   ClusterState
+		expectedSwitch  bool
+		expectedPending bool
+		expectedWaiting []string
 	}{
 		{
 			name: "healthy1",
@@ -306,6 +346,40 @@ func TestStatusSet(t *testing.T) {
 			expectedState:  StateHealthy,
 			expectedSwitch: true,
 		},
+		{
+			name: "healthy3-primary-demoting-outside-maintenance-window",
+			statusSet: newSS(3, 0, false, false, false, false).
+				outsideMaintenanceWindow().
+				withPod(true, false, true).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(newMySQL("1234", false, false, false).
+					withReplica(11, "replica1").
+					withReplica(12, "replica2").
+					build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build(),
+			expectedState:   StateHealthy,
+			expectedWaiting: []string{"switchover"},
+		},
+		{
+			name: "healthy3-primary-deleting-outside-maintenance-window",
+			statusSet: newSS(3, 0, false, false, false, false).
+				outsideMaintenanceWindow().
+				withPod(true, true, false).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(newMySQL("1234", false, false, false).
+					withReplica(11, "replica1").
+					withReplica(12, "replica2").
+					build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build(),
+			expectedState:  StateHealthy,
+			expectedSwitch: true,
+		},
 		{
 			name: "healthy3-replica-deleting",
 			statusSet: newSS(3, 0, false, false, false, false).
@@ -568,6 +642,49 @@ func TestStatusSet(t *testing.T) {
 				build(),
 			expectedState: StateFailed,
 		},
+		{
+			name: "failed3-manual-failover",
+			statusSet: newSS(3, 0, false, false, false, false).
+				withFailoverPolicy(mocov1beta2.FailoverPolicyManual, false).
+				withPod(false, false, false).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(nil).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build(),
+			expectedState:   StateFailed,
+			expectedPending: true,
+		},
+		{
+			name: "failed3-manual-failover-approved",
+			statusSet: newSS(3, 0, false, false, false, false).
+				withFailoverPolicy(mocov1beta2.FailoverPolicyManual, true).
+				withPod(false, false, false).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(nil).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build(),
+			expectedState: StateFailed,
+		},
+		{
+			name: "degraded3-manual-failover",
+			statusSet: newSS(3, 0, false, false, false, false).
+				withFailoverPolicy(mocov1beta2.FailoverPolicyManual, false).
+				withPod(true, false, false).
+				withPod(false, false, false).
+				withPod(true, false, false).
+				withMySQL(newMySQL("1234", false, false, false).
+					withReplica(11, "replica1").
+					withReplica(12, "replica2").
+					build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build(),
+			expectedState: StateDegraded,
+		},
 		{
 			name: "lost3-too-few-replicas",
 			statusSet: newSS(3, 0, false, false, false, false).
@@ -607,6 +724,738 @@ func TestStatusSet(t *testing.T) {
 			if tc.statusSet.NeedSwitch != tc.expectedSwitch {
 				t.Errorf("wrong NeedSwitch: expected=%v", tc.expectedSwitch)
 			}
+			if tc.statusSet.FailoverPending != tc.expectedPending {
+				t.Errorf("wrong FailoverPending: expected=%v", tc.expectedPending)
+			}
+			if !slices.Equal(tc.statusSet.WaitingOperations, tc.expectedWaiting) {
+				t.Errorf("wrong WaitingOperations %v: expected=%v", tc.statusSet.WaitingOperations, tc.expectedWaiting)
+			}
 		})
 	}
 }
+
+func TestSwitchoverTarget(t *testing.T) {
+	newHealthy3 := func(deleting bool, target string) *StatusSet {
+		return newSS(3, 0, false, false, false, false).
+			withSwitchoverTo(target).
+			withPod(true, deleting, !deleting).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+	}
+	newDegraded3 := func(target string) *StatusSet {
+		return newSS(3, 0, false, false, false, false).
+			withSwitchoverTo(target).
+			withPod(true, false, true).
+			withPod(true, false, false).
+			withPod(false, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+	}
+
+	testCases := []struct {
+		name              string
+		statusSet         *StatusSet
+		expectedSwitch    bool
+		expectedRejected  bool
+		expectedCandidate int
+	}{
+		{
+			name:              "no-target",
+			statusSet:         newHealthy3(false, ""),
+			expectedSwitch:    true,
+			expectedCandidate: 1,
+		},
+		{
+			name:              "target",
+			statusSet:         newHealthy3(false, "2"),
+			expectedSwitch:    true,
+			expectedCandidate: 2,
+		},
+		{
+			name:             "target-primary",
+			statusSet:        newHealthy3(false, "0"),
+			expectedRejected: true,
+		},
+		{
+			name:             "target-out-of-range",
+			statusSet:        newHealthy3(false, "3"),
+			expectedRejected: true,
+		},
+		{
+			name:             "target-invalid",
+			statusSet:        newHealthy3(false, "foo"),
+			expectedRejected: true,
+		},
+		{
+			name:             "target-not-ready",
+			statusSet:        newDegraded3("2"),
+			expectedRejected: true,
+		},
+		{
+			name:              "target-not-ready-deleting",
+			statusSet:         newHealthy3(true, "3"),
+			expectedSwitch:    true,
+			expectedCandidate: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.statusSet.DecideState()
+			if tc.statusSet.NeedSwitch != tc.expectedSwitch {
+				t.Errorf("wrong NeedSwitch: expected=%v", tc.expectedSwitch)
+			}
+			if (tc.statusSet.SwitchRejected != nil) != tc.expectedRejected {
+				t.Errorf("wrong SwitchRejected: %v", tc.statusSet.SwitchRejected)
+			}
+			if tc.expectedSwitch && tc.statusSet.Candidate != tc.expectedCandidate {
+				t.Errorf("wrong Candidate %d: expected=%d", tc.statusSet.Candidate, tc.expectedCandidate)
+			}
+		})
+	}
+}
+
+func TestChooseCandidate(t *testing.T) {
+	newHealthy5 := func() *StatusSet {
+		b := newSS(5, 0, false, false, false, false).withPod(true, false, true)
+		for i := 1; i < 5; i++ {
+			b = b.withPod(true, false, false)
+		}
+		primary := newMySQL("1234", false, false, false)
+		for i := 1; i < 5; i++ {
+			primary = primary.withReplica(int32(10+i), fmt.Sprintf("replica%d", i))
+		}
+		b = b.withMySQL(primary.build())
+		for i := 1; i < 5; i++ {
+			b = b.withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build())
+		}
+		return b.build()
+	}
+	setLag := func(ss *StatusSet, index int, lag int64) {
+		ss.MySQLStatus[index].ReplicaStatus.SecondsBehindSource = sql.NullInt64{Int64: lag, Valid: true}
+	}
+	setAnnotation := func(ss *StatusSet, index int, key, value string) {
+		if ss.Pods[index].Annotations == nil {
+			ss.Pods[index].Annotations = make(map[string]string)
+		}
+		ss.Pods[index].Annotations[key] = value
+	}
+
+	testCases := []struct {
+		name              string
+		setup             func(ss *StatusSet)
+		expectedCandidate int
+	}{
+		{
+			name:              "default",
+			setup:             func(ss *StatusSet) {},
+			expectedCandidate: 1,
+		},
+		{
+			name: "priority",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{Priorities: []int32{0, 0, 1, 2}}
+			},
+			expectedCandidate: 3,
+		},
+		{
+			name: "priority-annotation",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{Priorities: []int32{0, 0, 1, 2}}
+				setAnnotation(ss, 4, "moco.cybozu.com/promotion-priority", "3")
+			},
+			expectedCandidate: 4,
+		},
+		{
+			name: "zone",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{PreferredZones: []string{"zone-b", "zone-a"}}
+				ss.Zones = []string{"zone-a", "zone-c", "zone-a", "zone-b", "zone-b"}
+			},
+			expectedCandidate: 3,
+		},
+		{
+			name: "priority-over-zone",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{
+					Priorities:     []int32{0, 0, 1},
+					PreferredZones: []string{"zone-b", "zone-a"},
+				}
+				ss.Zones = []string{"zone-a", "zone-c", "zone-a", "zone-b", "zone-b"}
+			},
+			expectedCandidate: 2,
+		},
+		{
+			name: "lag",
+			setup: func(ss *StatusSet) {
+				setLag(ss, 1, 10)
+				setLag(ss, 2, 3)
+				setLag(ss, 3, 0)
+			},
+			expectedCandidate: 3,
+		},
+		{
+			name: "zone-over-lag",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{PreferredZones: []string{"zone-b"}}
+				ss.Zones = []string{"zone-a", "zone-a", "zone-b", "zone-a", "zone-b"}
+				setLag(ss, 1, 0)
+				setLag(ss, 2, 5)
+				setLag(ss, 4, 3)
+			},
+			expectedCandidate: 4,
+		},
+		{
+			name: "never-promote",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{
+					Priorities:   []int32{0, 2, 1},
+					NeverPromote: []int32{1},
+				}
+			},
+			expectedCandidate: 2,
+		},
+		{
+			name: "never-promote-annotation",
+			setup: func(ss *StatusSet) {
+				ss.Cluster.Spec.Promotion = &mocov1beta2.PromotionSpec{Priorities: []int32{0, 2, 1}}
+				setAnnotation(ss, 1, "moco.cybozu.com/never-promote", "true")
+				setAnnotation(ss, 2, "moco.cybozu.com/never-promote", "true")
+			},
+			expectedCandidate: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := newHealthy5()
+			tc.setup(ss)
+			ss.DecideState()
+			if ss.State != StateHealthy {
+				t.Fatalf("unexpected state: %s", ss.State)
+			}
+			if !ss.NeedSwitch {
+				t.Fatal("switchover is not needed")
+			}
+			if ss.Candidate != tc.expectedCandidate {
+				t.Errorf("wrong Candidate %d: expected=%d", ss.Candidate, tc.expectedCandidate)
+			}
+		})
+	}
+}
+
+func TestDelayedReplicas(t *testing.T) {
+	newSS3 := func(replica2Ready, replica2Running bool) *StatusSet {
+		ss := newSS(3, 0, false, false, false, false).
+			withPod(true, false, true).
+			withPod(true, false, false).
+			withPod(replica2Ready, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				build()).
+			withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+		ss.Cluster.Spec.DelayedReplicas = []mocov1beta2.DelayedReplica{{Index: 2, DelaySeconds: 3600}}
+		if replica2Running {
+			ss.MySQLStatus[2].ReplicaStatus.ReplicaIORunning = "Yes"
+			ss.MySQLStatus[2].ReplicaStatus.ReplicaSQLRunning = "Yes"
+		}
+		return ss
+	}
+
+	testCases := []struct {
+		name               string
+		statusSet          *StatusSet
+		expectedState      ClusterState
+		expectedCandidates []int
+	}{
+		{
+			name:               "healthy-not-ready",
+			statusSet:          newSS3(false, true),
+			expectedState:      StateHealthy,
+			expectedCandidates: []int{1},
+		},
+		{
+			name:               "degraded-stopped",
+			statusSet:          newSS3(false, false),
+			expectedState:      StateDegraded,
+			expectedCandidates: []int{1},
+		},
+		{
+			name: "failed",
+			statusSet: func() *StatusSet {
+				ss := newSS3(true, true)
+				ss.MySQLStatus[0] = nil
+				ss.ExecutedGTID = ""
+				return ss
+			}(),
+			expectedState: StateFailed,
+		},
+		{
+			name: "lost",
+			statusSet: func() *StatusSet {
+				ss := newSS3(true, true)
+				ss.MySQLStatus[0] = nil
+				ss.MySQLStatus[1] = nil
+				ss.ExecutedGTID = ""
+				return ss
+			}(),
+			expectedState: StateLost,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.statusSet.DecideState()
+			if tc.statusSet.State != tc.expectedState {
+				t.Errorf("wrong state %s: expected=%s", tc.statusSet.State, tc.expectedState)
+			}
+			if tc.expectedCandidates != nil && !slices.Equal(tc.statusSet.Candidates, tc.expectedCandidates) {
+				t.Errorf("wrong candidates %v: expected=%v", tc.statusSet.Candidates, tc.expectedCandidates)
+			}
+			if n := semiSyncInstances(tc.statusSet); n != 2 {
+				t.Errorf("wrong number of semi-sync instances: %d", n)
+			}
+		})
+	}
+}
+
+func TestScaleIn(t *testing.T) {
+	const primaryHostname = "moco-test-2.moco-test.ns.svc"
+	newSS3 := func(target int, demoteTo string) *StatusSet {
+		ss := newSS(3, 2, false, false, false, false).
+			withSwitchoverTo(demoteTo).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(primaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(primaryHostname).build()).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(10, "replica0").
+				withReplica(11, "replica1").
+				build()).
+			build()
+		ss.Primary = 2
+		ss.ScaleInTarget = target
+		return ss
+	}
+
+	testCases := []struct {
+		name              string
+		statusSet         *StatusSet
+		expectedSwitch    bool
+		expectedCandidate int
+		expectedRejected  bool
+	}{
+		{
+			name:              "no-scale-in",
+			statusSet:         newSS3(0, ""),
+			expectedSwitch:    false,
+			expectedCandidate: 0,
+		},
+		{
+			name:              "scale-in-to-1",
+			statusSet:         newSS3(1, ""),
+			expectedSwitch:    true,
+			expectedCandidate: 0,
+		},
+		{
+			name:             "scale-in-to-removed-instance",
+			statusSet:        newSS3(1, "1"),
+			expectedSwitch:   false,
+			expectedRejected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := tc.statusSet
+			ss.DecideState()
+			if ss.State != StateHealthy {
+				t.Fatalf("wrong state %s", ss.State)
+			}
+			if ss.NeedSwitch != tc.expectedSwitch {
+				t.Errorf("wrong NeedSwitch: %v", ss.NeedSwitch)
+			}
+			if tc.expectedRejected != (ss.SwitchRejected != nil) {
+				t.Errorf("wrong SwitchRejected: %v", ss.SwitchRejected)
+			}
+			if tc.expectedSwitch && ss.Candidate != tc.expectedCandidate {
+				t.Errorf("wrong candidate %d: expected=%d", ss.Candidate, tc.expectedCandidate)
+			}
+		})
+	}
+}
+
+func TestReadPoolReplicas(t *testing.T) {
+	newSS5 := func(waitFor *int32) *StatusSet {
+		b := newSS(5, 0, false, false, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(newMySQL("1234", false, false, false).
+				withReplica(11, "replica1").
+				withReplica(12, "replica2").
+				withReplica(13, "replica3").
+				withReplica(14, "replica4").
+				build())
+		for range 4 {
+			b.withMySQL(newMySQL("1234", true, false, false).withPrimary(testPrimaryHostname).build())
+		}
+		ss := b.build()
+		ss.Cluster.Spec.ReadPoolReplicas = []int32{3, 4}
+		if waitFor != nil {
+			ss.Cluster.Spec.SemiSync = &mocov1beta2.SemiSyncSpec{WaitForReplicaCount: waitFor}
+		}
+		for i := 3; i < 5; i++ {
+			ss.MySQLStatus[i].ReplicaStatus.ReplicaIORunning = "Yes"
+			ss.MySQLStatus[i].ReplicaStatus.ReplicaSQLRunning = "Yes"
+		}
+		return ss
+	}
+
+	testCases := []struct {
+		name               string
+		statusSet          *StatusSet
+		expectedState      ClusterState
+		expectedCandidates []int
+		expectedWaitFor    int
+	}{
+		{
+			name:               "healthy",
+			statusSet:          newSS5(nil),
+			expectedState:      StateHealthy,
+			expectedCandidates: []int{1, 2},
+			expectedWaitFor:    1,
+		},
+		{
+			name: "degraded",
+			statusSet: func() *StatusSet {
+				ss := newSS5(nil)
+				ss.MySQLStatus[2] = nil
+				return ss
+			}(),
+			expectedState:      StateDegraded,
+			expectedCandidates: []int{1},
+			expectedWaitFor:    1,
+		},
+		{
+			name: "incomplete-with-wait-for-2",
+			statusSet: func() *StatusSet {
+				ss := newSS5(new(int32(2)))
+				ss.MySQLStatus[2] = nil
+				return ss
+			}(),
+			expectedState:   StateIncomplete,
+			expectedWaitFor: 2,
+		},
+		{
+			name: "failed",
+			statusSet: func() *StatusSet {
+				ss := newSS5(nil)
+				ss.MySQLStatus[0] = nil
+				ss.ExecutedGTID = ""
+				return ss
+			}(),
+			expectedState:   StateFailed,
+			expectedWaitFor: 1,
+		},
+		{
+			name: "lost-with-wait-for-1",
+			statusSet: func() *StatusSet {
+				ss := newSS5(nil)
+				ss.MySQLStatus[0] = nil
+				ss.MySQLStatus[2] = nil
+				ss.ExecutedGTID = ""
+				return ss
+			}(),
+			expectedState:   StateLost,
+			expectedWaitFor: 1,
+		},
+		{
+			name: "failed-with-wait-for-2",
+			statusSet: func() *StatusSet {
+				ss := newSS5(new(int32(2)))
+				ss.MySQLStatus[0] = nil
+				ss.MySQLStatus[2] = nil
+				ss.ExecutedGTID = ""
+				return ss
+			}(),
+			expectedState:   StateFailed,
+			expectedWaitFor: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.statusSet.DecideState()
+			if tc.statusSet.State != tc.expectedState {
+				t.Errorf("wrong state %s: expected=%s", tc.statusSet.State, tc.expectedState)
+			}
+			if tc.expectedCandidates != nil && !slices.Equal(tc.statusSet.Candidates, tc.expectedCandidates) {
+				t.Errorf("wrong candidates %v: expected=%v", tc.statusSet.Candidates, tc.expectedCandidates)
+			}
+			if n := semiSyncInstances(tc.statusSet); n != 3 {
+				t.Errorf("wrong number of semi-sync instances: %d", n)
+			}
+			if n := semiSyncWaitCount(tc.statusSet); n != tc.expectedWaitFor {
+				t.Errorf("wrong wait count %d: expected=%d", n, tc.expectedWaitFor)
+			}
+		})
+	}
+}
+
+func TestMakePlan(t *testing.T) {
+	newSS3 := func(policy mocov1beta2.FailoverPolicy) *StatusSet {
+		return newSS(3, 0, false, false, false, false).
+			withFailoverPolicy(policy, false).
+			withPod(false, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withMySQL(nil).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			build()
+	}
+	held := func(ss *StatusSet) *StatusSet {
+		ss.Cluster.Spec.Clustering = &mocov1beta2.ClusteringSpec{
+			RoleChangeLimit: &mocov1beta2.RoleChangeLimitSpec{MaxRoleChanges: 1},
+		}
+		ss.Cluster.Status.Conditions = []metav1.Condition{{
+			Type:    mocov1beta2.ConditionRoleChangeHeld,
+			Status:  metav1.ConditionTrue,
+			Message: "the primary was changed 1 times in the last 1h0m0s",
+		}}
+		return ss
+	}
+
+	testCases := []struct {
+		name          string
+		statusSet     *StatusSet
+		candidate     int
+		failoverErr   error
+		expectedNext  *int
+		expectedSteps []string
+	}{
+		{
+			name:         "failover",
+			statusSet:    newSS3(mocov1beta2.FailoverPolicyAutomatic),
+			candidate:    1,
+			expectedNext: new(1),
+			expectedSteps: []string{
+				"fence instance 0 by RemoveRoleLabel",
+				"stop replica IO thread of instances [1 2]",
+				"wait for instance 1 to execute all retrieved transactions ",
+				"switch the primary to instance 1",
+			},
+		},
+		{
+			name: "failover-fenced",
+			statusSet: func() *StatusSet {
+				ss := newSS3(mocov1beta2.FailoverPolicyAutomatic)
+				ss.Cluster.Status.FencedPrimary = &mocov1beta2.FencedPrimaryStatus{Index: 0, Generation: ss.Cluster.Generation}
+				return ss
+			}(),
+			candidate:    1,
+			expectedNext: new(1),
+			expectedSteps: []string{
+				"stop replica IO thread of instances [1 2]",
+				"wait for instance 1 to execute all retrieved transactions ",
+				"switch the primary to instance 1",
+			},
+		},
+		{
+			name: "failover-fenced-before-spec-change",
+			statusSet: func() *StatusSet {
+				ss := newSS3(mocov1beta2.FailoverPolicyAutomatic)
+				ss.Cluster.Status.FencedPrimary = &mocov1beta2.FencedPrimaryStatus{Index: 0, Generation: ss.Cluster.Generation - 1}
+				return ss
+			}(),
+			candidate:    1,
+			expectedNext: new(1),
+			expectedSteps: []string{
+				"fence instance 0 by RemoveRoleLabel",
+				"stop replica IO thread of instances [1 2]",
+				"wait for instance 1 to execute all retrieved transactions ",
+				"switch the primary to instance 1",
+			},
+		},
+		{
+			name:         "failover-pending",
+			statusSet:    newSS3(mocov1beta2.FailoverPolicyManual),
+			candidate:    2,
+			expectedNext: new(2),
+			expectedSteps: []string{
+				"wait for an approval to fail over to instance 2",
+			},
+		},
+		{
+			name:         "failover-held",
+			statusSet:    held(newSS3(mocov1beta2.FailoverPolicyAutomatic)),
+			candidate:    1,
+			expectedNext: new(1),
+			expectedSteps: []string{
+				"hold the failover to instance 1 until an operator acknowledges: the primary was changed 1 times in the last 1h0m0s",
+			},
+		},
+		{
+			name: "approved-failover-not-held",
+			statusSet: held(newSS(3, 0, false, false, false, false).
+				withFailoverPolicy(mocov1beta2.FailoverPolicyManual, true).
+				withPod(false, false, false).
+				withPod(true, false, false).
+				withPod(true, false, false).
+				withMySQL(nil).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+				build()),
+			candidate:    1,
+			expectedNext: new(1),
+			expectedSteps: []string{
+				"fence instance 0 by RemoveRoleLabel",
+				"stop replica IO thread of instances [1 2]",
+				"wait for instance 1 to execute all retrieved transactions ",
+				"switch the primary to instance 1",
+			},
+		},
+		{
+			name:        "no-top-runner",
+			statusSet:   newSS3(mocov1beta2.FailoverPolicyAutomatic),
+			candidate:   -1,
+			failoverErr: dbop.ErrNoTopRunner,
+			expectedSteps: []string{
+				"no instance can be promoted: " + dbop.ErrNoTopRunner.Error(),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ss := tc.statusSet
+			ss.DecideState()
+			if ss.State != StateFailed {
+				t.Fatalf("wrong state %s", ss.State)
+			}
+			ss.Candidate = tc.candidate
+
+			plan := makePlan(ss, tc.failoverErr)
+			if plan.State != ss.State.String() {
+				t.Errorf("wrong state in the plan: %s", plan.State)
+			}
+			if plan.Primary != 0 {
+				t.Errorf("wrong primary in the plan: %d", plan.Primary)
+			}
+			if (plan.NextPrimary == nil) != (tc.expectedNext == nil) || (plan.NextPrimary != nil && *plan.NextPrimary != *tc.expectedNext) {
+				t.Errorf("wrong next primary: %v, expected=%v", plan.NextPrimary, tc.expectedNext)
+			}
+			if !slices.Equal(plan.Steps, tc.expectedSteps) {
+				t.Errorf("wrong steps %q: expected=%q", plan.Steps, tc.expectedSteps)
+			}
+		})
+	}
+}
+
+func TestInstanceStatuses(t *testing.T) {
+	ss := newSS(3, 0, false, false, false, false).
+		withPod(true, false, false).
+		withPod(true, false, false).
+		withPod(false, false, false).
+		withMySQL(newMySQL("123", false, false, false).build()).
+		withMySQL(newMySQL("123", true, true, false).withPrimary(testPrimaryHostname).build()).
+		withMySQL(nil).
+		build()
+	ss.MySQLStatus[1].ReplicaStatus.SecondsBehindSource = sql.NullInt64{Valid: true, Int64: 3}
+	ss.Primary = 0
+
+	instances := instanceStatuses(ss)
+	if len(instances) != 3 {
+		t.Fatalf("wrong number of instances: %d", len(instances))
+	}
+	for i, st := range instances {
+		if st.Index != i {
+			t.Errorf("wrong index %d: expected=%d", st.Index, i)
+		}
+	}
+	if st := instances[0]; !st.Reachable || st.Role != "primary" || st.ReadOnly || st.Replication != nil {
+		t.Errorf("wrong status of the primary: %+v", st)
+	}
+	st := instances[1]
+	if !st.Reachable || st.Role != "replica" || !st.SuperReadOnly || !st.Errant {
+		t.Errorf("wrong status of the replica: %+v", st)
+	}
+	if st.Replication == nil || st.Replication.SourceHost != testPrimaryHostname || st.Replication.SecondsBehindSource == nil || *st.Replication.SecondsBehindSource != 3 {
+		t.Errorf("wrong replication status of the replica: %+v", st.Replication)
+	}
+	if st := instances[2]; st.Reachable || st.Role != "" {
+		t.Errorf("wrong status of the unreachable instance: %+v", st)
+	}
+}
+
+type subtractOperator struct {
+	dbop.NopOperator
+	missing string
+	err     error
+}
+
+func (o subtractOperator) SubtractGTID(ctx context.Context, set1, set2 string) (string, error) {
+	return o.missing, o.err
+}
+
+func TestGatherMissingGTIDs(t *testing.T) {
+	newSS4 := func() *StatusSet {
+		return newSS(4, 0, false, false, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(true, false, false).
+			withPod(false, false, false).
+			withMySQL(newMySQL("123", false, false, false).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(newMySQL("123", true, false, false).withPrimary(testPrimaryHostname).build()).
+			withMySQL(nil).
+			build()
+	}
+
+	ss := newSS4()
+	ss.DBOps = []dbop.Operator{
+		nil,
+		subtractOperator{missing: "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5"},
+		subtractOperator{err: errors.New("mysqld is down")},
+		nil,
+	}
+	gatherMissingGTIDs(context.Background(), ss)
+	if len(ss.MissingGTIDs) != 4 {
+		t.Fatalf("wrong number of instances: %d", len(ss.MissingGTIDs))
+	}
+	if ss.MissingGTIDs[0] != 0 || ss.MissingGTIDs[3] != 0 {
+		t.Errorf("the primary and unreachable instances must not be counted: %v", ss.MissingGTIDs)
+	}
+	if ss.MissingGTIDs[1] != 5 {
+		t.Errorf("wrong number of missing transactions: %v", ss.MissingGTIDs[1])
+	}
+	if !math.IsNaN(ss.MissingGTIDs[2]) {
+		t.Errorf("failure must be NaN: %v", ss.MissingGTIDs[2])
+	}
+
+	ss = newSS4()
+	ss.MySQLStatus[0] = nil
+	gatherMissingGTIDs(context.Background(), ss)
+	if ss.MissingGTIDs != nil {
+		t.Errorf("missing transactions must not be counted without the primary: %v", ss.MissingGTIDs)
+	}
+}
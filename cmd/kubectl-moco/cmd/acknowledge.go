@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var acknowledgeCmd = &cobra.Command{
+	Use:   "acknowledge CLUSTER_NAME",
+	Short: "Acknowledge the hold of role changes",
+	Long: `Acknowledge the hold of the role changes of a MySQLCluster that hit the role change limit.
+MOCO clears the history of the role changes and resumes switchovers and failovers.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return acknowledge(cmd.Context(), args[0])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mysqlClusterCandidates(cmd.Context(), cmd, args, toComplete)
+	},
+}
+
+func acknowledge(ctx context.Context, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, mocov1beta2.ConditionRoleChangeHeld)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return errors.New("role changes are not held")
+	}
+
+	if cluster.Annotations[constants.AnnAcknowledgeRoleChangeHold] == "true" {
+		fmt.Println("The hold is already acknowledged.")
+		return nil
+	}
+
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[constants.AnnAcknowledgeRoleChangeHold] = "true"
+
+	if err := kubeClient.Update(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to acknowledge the hold of MySQLCluster: %w", err)
+	}
+
+	fmt.Printf("acknowledged the hold of role changes of MySQLCluster %q: %s\n", fmt.Sprintf("%s/%s", namespace, name), cond.Message)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(acknowledgeCmd)
+}
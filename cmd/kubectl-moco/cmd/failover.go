@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var failoverCmd = &cobra.Command{
+	Use:   "failover CLUSTER_NAME",
+	Short: "Approve a pending failover",
+	Long:  "Approve the pending failover of a MySQLCluster whose failover policy is Manual.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return failover(cmd.Context(), args[0])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mysqlClusterCandidates(cmd.Context(), cmd, args, toComplete)
+	},
+}
+
+func failover(ctx context.Context, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Offline {
+		return errors.New("offline cluster is not able to fail over")
+	}
+
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, mocov1beta2.ConditionFailoverPending)
+	if cond == nil || cond.Status != metav1.ConditionTrue {
+		return errors.New("no failover is pending")
+	}
+
+	if cluster.Annotations[constants.AnnApproveFailover] == "true" {
+		fmt.Println("The failover is already approved.")
+		return nil
+	}
+
+	newCluster := cluster.DeepCopy()
+	if newCluster.Annotations == nil {
+		newCluster.Annotations = make(map[string]string)
+	}
+	newCluster.Annotations[constants.AnnApproveFailover] = "true"
+
+	if err := kubeClient.Patch(ctx, newCluster, client.MergeFrom(cluster)); err != nil {
+		return fmt.Errorf("failed to approve failover of MySQLCluster: %w", err)
+	}
+
+	fmt.Printf("approved failover of MySQLCluster %q: %s\n", fmt.Sprintf("%s/%s", namespace, name), cond.Message)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(failoverCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan CLUSTER_NAME",
+	Short: "Show the operations MOCO is going to perform for the cluster",
+	Long: `Show the state of the MySQLCluster and the operations MOCO is going to perform for it.
+The plan is decided by MOCO without side effects and recorded in status.plan.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return plan(cmd.Context(), args[0])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mysqlClusterCandidates(cmd.Context(), cmd, args, toComplete)
+	},
+}
+
+func plan(ctx context.Context, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Status.Plan == nil {
+		return errors.New("the plan has not been decided yet")
+	}
+
+	if meta.IsStatusConditionFalse(cluster.Status.Conditions, mocov1beta2.ConditionClusteringActive) {
+		fmt.Fprintln(os.Stderr, "WARNING: clustering is not active, so the plan may be outdated.")
+	}
+
+	printPlan(os.Stdout, cluster.Status.Plan)
+	return nil
+}
+
+func printPlan(w io.Writer, p *mocov1beta2.OperationPlan) {
+	fmt.Fprintf(w, "State:        %s\n", p.State)
+	fmt.Fprintf(w, "Primary:      %d\n", p.Primary)
+	fmt.Fprintf(w, "Candidates:   %v\n", p.Candidates)
+	fmt.Fprintf(w, "Errants:      %v\n", p.Errants)
+	if p.NextPrimary != nil {
+		fmt.Fprintf(w, "Next primary: %d\n", *p.NextPrimary)
+	} else {
+		fmt.Fprintln(w, "Next primary: -")
+	}
+	fmt.Fprintf(w, "Updated at:   %s\n", p.Time.UTC().Format("2006-01-02T15:04:05Z"))
+	fmt.Fprintln(w, "Steps:")
+	for i, s := range p.Steps {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, s)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
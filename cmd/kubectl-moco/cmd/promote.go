@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var promoteConfig struct {
+	cancel bool
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote CLUSTER_NAME",
+	Short: "Promote a replicating cluster to be independent",
+	Long: `Promote a MySQLCluster that replicates data from an external source to be independent and writable.
+MOCO makes the source read-only if possible, waits for all the retrieved transactions to be executed,
+and then detaches the cluster from the source.  The progress is recorded in status.promotion.
+With --cancel, the promotion in progress is cancelled.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if promoteConfig.cancel {
+			return cancelPromotion(cmd.Context(), args[0])
+		}
+		return promote(cmd.Context(), args[0])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mysqlClusterCandidates(cmd.Context(), cmd, args, toComplete)
+	},
+}
+
+func promote(ctx context.Context, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Offline {
+		return errors.New("offline cluster is not able to be promoted")
+	}
+
+	if !cluster.HasReplicationSource() {
+		return errors.New("the cluster does not replicate data from an external source")
+	}
+
+	if !cluster.Status.Cloned {
+		return errors.New("the initial cloning has not been completed")
+	}
+
+	if cluster.Annotations[constants.AnnPromote] == "true" {
+		fmt.Println("The promotion is already requested.")
+		return nil
+	}
+
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[constants.AnnPromote] = "true"
+
+	if err := kubeClient.Update(ctx, cluster); err != nil {
+		return fmt.Errorf("failed to request promotion of MySQLCluster: %w", err)
+	}
+
+	fmt.Printf("requested promotion of MySQLCluster %q\n", fmt.Sprintf("%s/%s", namespace, name))
+	return nil
+}
+
+func cancelPromotion(ctx context.Context, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	if _, ok := cluster.Annotations[constants.AnnPromote]; !ok {
+		fmt.Println("The promotion is not requested.")
+		return nil
+	}
+
+	newCluster := cluster.DeepCopy()
+	delete(newCluster.Annotations, constants.AnnPromote)
+	if err := kubeClient.Patch(ctx, newCluster, client.MergeFrom(cluster)); err != nil {
+		return fmt.Errorf("failed to cancel promotion of MySQLCluster: %w", err)
+	}
+
+	fmt.Printf("cancelled promotion of MySQLCluster %q\n", fmt.Sprintf("%s/%s", namespace, name))
+	return nil
+}
+
+func init() {
+	fs := promoteCmd.Flags()
+	fs.BoolVar(&promoteConfig.cancel, "cancel", false, "Cancel the promotion in progress")
+
+	rootCmd.AddCommand(promoteCmd)
+}
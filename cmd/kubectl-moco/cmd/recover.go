@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/dbop"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdexec "k8s.io/kubectl/pkg/cmd/exec"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var recoverConfig struct {
+	to  int
+	yes bool
+}
+
+var validGTIDSet = regexp.MustCompile(`^[0-9A-Za-z:,_-]*$`)
+
+var recoverCmd = &cobra.Command{
+	Use:   "recover CLUSTER_NAME",
+	Short: "Recover a Lost or Failed cluster",
+	Long: `Recover a Lost or Failed MySQLCluster by choosing the new primary instance.
+This gathers gtid_executed of every instance, proposes the most advanced instance as the new primary,
+and shows the transactions that would be lost.  After confirmation, MOCO switches the primary,
+re-clones the instances having the lost transactions, and the clustering is restarted if it is stopped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return recoverCluster(cmd.Context(), cmd, args[0])
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return mysqlClusterCandidates(cmd.Context(), cmd, args, toComplete)
+	},
+}
+
+func recoverCluster(ctx context.Context, cmd *cobra.Command, name string) error {
+	cluster := &mocov1beta2.MySQLCluster{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
+		return err
+	}
+
+	if cluster.Spec.Offline {
+		return errors.New("offline cluster is not able to recover")
+	}
+	if cluster.IsGroupReplication() {
+		return errors.New("recovery is not supported in GroupReplication mode")
+	}
+	if cluster.Status.State != "Lost" && cluster.Status.State != "Failed" {
+		return fmt.Errorf("the cluster is %s, not Lost or Failed", cluster.Status.State)
+	}
+	if recoverConfig.to < -1 {
+		return errors.New("index should be -1 or larger")
+	}
+	if recoverConfig.to >= int(cluster.Spec.Replicas) {
+		return errors.New("index should be smaller than replicas")
+	}
+
+	gtids := make(map[int]string)
+	var reachable, candidates []int
+	for i := 0; i < int(cluster.Spec.Replicas); i++ {
+		out, err := execMySQL(cmd, cluster.PodName(i), `SELECT REPLACE(@@gtid_executed, '\n', '')`)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: instance %d is unreachable: %v\n", i, err)
+			continue
+		}
+		gtid := strings.TrimSpace(out)
+		if !validGTIDSet.MatchString(gtid) {
+			return fmt.Errorf("invalid gtid_executed of instance %d: %s", i, gtid)
+		}
+		gtids[i] = gtid
+		reachable = append(reachable, i)
+
+		ok, err := isPromotable(ctx, cluster, i)
+		if err != nil {
+			return err
+		}
+		if ok {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(reachable) == 0 {
+		return errors.New("no instance is reachable")
+	}
+
+	missing, err := missingGTIDs(cmd, cluster, reachable, gtids)
+	if err != nil {
+		return err
+	}
+
+	target := recoverConfig.to
+	if target < 0 {
+		if len(candidates) == 0 {
+			return errors.New("no reachable instance can be promoted")
+		}
+		var diverged bool
+		target, diverged, err = proposeRecovery(reachable, candidates, missing)
+		if err != nil {
+			return err
+		}
+		if diverged {
+			fmt.Fprintln(os.Stderr, "WARNING: the GTID sets of the instances have diverged.")
+		}
+	} else if _, ok := gtids[target]; !ok {
+		return fmt.Errorf("instance %d is unreachable", target)
+	} else if !slices.Contains(candidates, target) {
+		return fmt.Errorf("instance %d must not be promoted", target)
+	}
+
+	fmt.Println("Instance  gtid_executed")
+	for i := 0; i < int(cluster.Spec.Replicas); i++ {
+		gtid, ok := gtids[i]
+		switch {
+		case !ok:
+			gtid = "(unreachable)"
+		case gtid == "":
+			gtid = "(empty)"
+		}
+		fmt.Printf("%-8d  %s\n", i, gtid)
+	}
+	fmt.Printf("\nThe new primary will be instance %d.\n", target)
+	fmt.Println("Transactions that would be lost:")
+	var reclone []int
+	for i := 0; i < int(cluster.Spec.Replicas); i++ {
+		if i == target {
+			continue
+		}
+		if _, ok := gtids[i]; !ok {
+			fmt.Printf("  instance %d: unknown because it is unreachable\n", i)
+			continue
+		}
+		lost := missing[[2]int{i, target}]
+		if lost == "" {
+			fmt.Printf("  instance %d: none\n", i)
+			continue
+		}
+		reclone = append(reclone, i)
+		n, err := dbop.CountGTIDs(lost)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  instance %d: %s (%d transactions)\n", i, lost, n)
+	}
+	if len(reclone) > 0 {
+		fmt.Printf("Instances %v will be re-cloned from instance %d.\n", reclone, target)
+	}
+
+	if !recoverConfig.yes {
+		fmt.Printf("\nRecover MySQLCluster %q by making instance %d the primary? [y/N]: ", fmt.Sprintf("%s/%s", namespace, name), target)
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			fmt.Println("canceled")
+			return nil
+		}
+	}
+
+	newCluster := cluster.DeepCopy()
+	if newCluster.Annotations == nil {
+		newCluster.Annotations = make(map[string]string)
+	}
+	newCluster.Annotations[constants.AnnRecoverTo] = strconv.Itoa(target)
+	delete(newCluster.Annotations, constants.AnnClusteringStopped)
+	if err := kubeClient.Patch(ctx, newCluster, client.MergeFrom(cluster)); err != nil {
+		return fmt.Errorf("failed to request recovery of MySQLCluster: %w", err)
+	}
+
+	fmt.Printf("requested recovery of MySQLCluster %q to instance %d; run \"kubectl moco plan %s\" to see the progress\n", fmt.Sprintf("%s/%s", namespace, name), target, name)
+	return nil
+}
+
+// missingGTIDs returns the GTID sets executed in an instance but not in another.
+// The key of the returned map is the pair of the indices of the instances.
+func missingGTIDs(cmd *cobra.Command, cluster *mocov1beta2.MySQLCluster, reachable []int, gtids map[int]string) (map[[2]int]string, error) {
+	missing := make(map[[2]int]string)
+	var queries []string
+	for _, i := range reachable {
+		for _, j := range reachable {
+			if i == j {
+				continue
+			}
+			queries = append(queries, fmt.Sprintf(`SELECT %d, %d, REPLACE(GTID_SUBTRACT('%s', '%s'), '\n', '')`, i, j, gtids[i], gtids[j]))
+		}
+	}
+	if len(queries) == 0 {
+		return missing, nil
+	}
+
+	out, err := execMySQL(cmd, cluster.PodName(reachable[0]), strings.Join(queries, " UNION ALL "))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the GTID sets: %w", err)
+	}
+	for line := range strings.SplitSeq(strings.TrimRight(out, "\n"), "\n") {
+		fields := strings.SplitN(strings.TrimRight(line, "\r"), "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected output: %s", line)
+		}
+		i, err1 := strconv.Atoi(fields[0])
+		j, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("unexpected output: %s", line)
+		}
+		missing[[2]int{i, j}] = fields[2]
+	}
+	return missing, nil
+}
+
+// isPromotable returns false if the instance must never be promoted to the primary,
+// that is, the instance is excluded by the spec or its Pod has `moco.cybozu.com/never-promote` annotation.
+func isPromotable(ctx context.Context, cluster *mocov1beta2.MySQLCluster, index int) (bool, error) {
+	if !cluster.IsPromotable(index) {
+		return false, nil
+	}
+	pod := &corev1.Pod{}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: cluster.PodName(index)}, pod); err != nil {
+		return false, err
+	}
+	return pod.Annotations[constants.AnnNeverPromote] != "true", nil
+}
+
+// proposeRecovery returns the instance in `candidates` whose gtid_executed includes those of
+// all the other instances like dbop.FindTopRunner.  If the GTID sets have diverged or no candidate
+// includes them, this returns the candidate that loses the fewest transactions and true.
+func proposeRecovery(reachable, candidates []int, missing map[[2]int]string) (int, bool, error) {
+	best := -1
+	var bestLost int64
+	for _, c := range candidates {
+		var lost int64
+		for _, i := range reachable {
+			if i == c {
+				continue
+			}
+			n, err := dbop.CountGTIDs(missing[[2]int{i, c}])
+			if err != nil {
+				return -1, false, err
+			}
+			lost += n
+		}
+		if best == -1 || lost < bestLost {
+			best = c
+			bestLost = lost
+		}
+	}
+	return best, bestLost > 0, nil
+}
+
+// execMySQL runs `query` with mysql command as the admin user in the Pod and returns the output.
+func execMySQL(cmd *cobra.Command, podName, query string) (string, error) {
+	myCnfPath := fmt.Sprintf("%s/%s-my.cnf", constants.MyCnfSecretPath, constants.AdminUser)
+	commands := []string{podName, "--", "mysql", "--defaults-extra-file=" + myCnfPath, "-N", "-B", "-e", query}
+	argsLenAtDash := 2
+	out := &bytes.Buffer{}
+	errOut := &bytes.Buffer{}
+	options := &cmdexec.ExecOptions{
+		StreamOptions: cmdexec.StreamOptions{
+			IOStreams: genericclioptions.IOStreams{
+				In:     &bytes.Buffer{},
+				Out:    out,
+				ErrOut: errOut,
+			},
+			ContainerName: mysqldContainerName,
+		},
+
+		Executor: &cmdexec.DefaultRemoteExecutor{},
+	}
+	if err := options.Complete(factory, cmd, commands, argsLenAtDash); err != nil {
+		return "", err
+	}
+	if err := options.Validate(); err != nil {
+		return "", err
+	}
+	if err := options.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.String(), nil
+}
+
+func init() {
+	fs := recoverCmd.Flags()
+	fs.IntVar(&recoverConfig.to, "to", -1, "Index of the instance to be the new primary")
+	fs.BoolVarP(&recoverConfig.yes, "yes", "y", false, "Recover without confirmation")
+	cmdutil.AddPodRunningTimeoutFlag(recoverCmd, defaultPodExecTimeout)
+
+	rootCmd.AddCommand(recoverCmd)
+}
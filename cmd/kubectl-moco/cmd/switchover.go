@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
 	"github.com/cybozu-go/moco/pkg/constants"
@@ -12,10 +14,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+var switchoverConfig struct {
+	to int
+}
+
 var switchoverCmd = &cobra.Command{
 	Use:   "switchover CLUSTER_NAME",
 	Short: "Switch the primary instance",
-	Long:  "Switch the primary instance to one of the replicas.",
+	Long:  "Switch the primary instance to one of the replicas, or to the replica specified with --to.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return switchover(cmd.Context(), args[0])
@@ -26,6 +32,10 @@ var switchoverCmd = &cobra.Command{
 }
 
 func switchover(ctx context.Context, name string) error {
+	if switchoverConfig.to < -1 {
+		return errors.New("index should be -1 or larger")
+	}
+
 	cluster := &mocov1beta2.MySQLCluster{}
 	if err := kubeClient.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cluster); err != nil {
 		return err
@@ -45,8 +55,21 @@ func switchover(ctx context.Context, name string) error {
 		return err
 	}
 
+	if switchoverConfig.to >= 0 {
+		if switchoverConfig.to >= int(cluster.Spec.Replicas) {
+			return errors.New("index should be smaller than replicas")
+		}
+		if switchoverConfig.to == cluster.Status.CurrentPrimaryIndex {
+			return fmt.Errorf("instance %d is already the primary", switchoverConfig.to)
+		}
+	}
+
 	if pod.Annotations[constants.AnnDemote] == "true" {
-		return nil
+		// the switchover has already been requested.
+		if switchoverConfig.to < 0 || pod.Annotations[constants.AnnSwitchoverTo] == strconv.Itoa(switchoverConfig.to) {
+			return nil
+		}
+		return errors.New("the primary is already being switched over; retry after it finishes")
 	}
 
 	newPod := pod.DeepCopy()
@@ -54,10 +77,18 @@ func switchover(ctx context.Context, name string) error {
 		newPod.Annotations = make(map[string]string)
 	}
 	newPod.Annotations[constants.AnnDemote] = "true"
+	if switchoverConfig.to >= 0 {
+		newPod.Annotations[constants.AnnSwitchoverTo] = strconv.Itoa(switchoverConfig.to)
+	} else {
+		delete(newPod.Annotations, constants.AnnSwitchoverTo)
+	}
 
 	return kubeClient.Patch(ctx, newPod, client.MergeFrom(pod))
 }
 
 func init() {
+	fs := switchoverCmd.Flags()
+	fs.IntVar(&switchoverConfig.to, "to", -1, "Index of the replica to be promoted")
+
 	rootCmd.AddCommand(switchoverCmd)
 }
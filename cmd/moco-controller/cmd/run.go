@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
@@ -11,6 +12,7 @@ import (
 	"github.com/cybozu-go/moco/pkg/cert"
 	"github.com/cybozu-go/moco/pkg/dbop"
 	"github.com/cybozu-go/moco/pkg/metrics"
+	"github.com/cybozu-go/moco/pkg/shard"
 	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -18,6 +20,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	k8smetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -76,7 +79,7 @@ func subMain(ns, addr string, port int) error {
 		},
 		HealthProbeBindAddress:  config.probeAddr,
 		PprofBindAddress:        config.pprofAddr,
-		LeaderElection:          true,
+		LeaderElection:          config.shards == 0,
 		LeaderElectionID:        config.leaderElectionID,
 		LeaderElectionNamespace: ns,
 		WebhookServer: webhook.NewServer(webhook.Options{
@@ -99,9 +102,37 @@ func subMain(ns, addr string, port int) error {
 		return err
 	}
 	af := clustering.NewAgentFactory(r, reloader)
-	clusterMgr := clustering.NewClusterManager(config.interval, mgr, opf, af, clusterLog)
+
+	var sharder *shard.Sharder
+	var owner clustering.Owner
+	var ownerEvents <-chan event.GenericEvent
+	if config.shards > 0 {
+		identity, err := os.Hostname()
+		if err != nil {
+			setupLog.Error(err, "failed to get the hostname")
+			return err
+		}
+		sharder = shard.New(mgr.GetClient(), mgr.GetAPIReader(), shard.Options{
+			Namespace: ns,
+			Name:      config.leaderElectionID,
+			Identity:  identity,
+			Shards:    config.shards,
+		}, ctrl.Log.WithName("shard"))
+		owner = sharder
+		ownerEvents = sharder.Events()
+	}
+
+	clusterMgr := clustering.NewShardedClusterManager(config.interval, mgr, opf, af, owner, clusterLog)
 	defer clusterMgr.StopAll()
 
+	if sharder != nil {
+		sharder.OnRelease(clusterMgr.StopUnowned)
+		if err := mgr.Add(sharder); err != nil {
+			setupLog.Error(err, "unable to add sharder")
+			return err
+		}
+	}
+
 	ctx := ctrl.SetupSignalHandler()
 
 	if err = (&controllers.MySQLClusterReconciler{
@@ -119,6 +150,8 @@ func subMain(ns, addr string, port int) error {
 		MaxConcurrentReconciles:       config.maxConcurrentReconciles,
 		MySQLConfigMapHistoryLimit:    config.mySQLConfigMapHistoryLimit,
 		DisableDefaultSecurityContext: config.disableDefaultSecurityContext,
+		Owner:                         owner,
+		OwnerEvents:                   ownerEvents,
 	}).SetupWithManager(ctx, mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MySQLCluster")
 		return err
@@ -130,6 +163,7 @@ func subMain(ns, addr string, port int) error {
 		MaxConcurrentReconciles: config.maxConcurrentReconciles,
 		UpdateInterval:          config.partitionUpdateInterval,
 		RateLimiter:             rate.NewLimiter(rate.Every(config.partitionUpdateInterval), 1),
+		Owner:                   owner,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Partition")
 		return err
@@ -38,6 +38,8 @@ func (m *mockManager) Stop(key types.NamespacedName) {
 
 func (m *mockManager) StopAll() {}
 
+func (m *mockManager) StopUnowned() {}
+
 func (m *mockManager) Pause(key types.NamespacedName) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -53,6 +53,25 @@ func (r *MySQLClusterReconciler) makeV1MySQLDContainer(cluster *mocov1beta2.MySQ
 			WithContainerPort(constants.MySQLHealthPort).
 			WithProtocol(corev1.ProtocolTCP),
 	)
+	if cluster.IsGroupReplication() {
+		source.WithPorts(corev1ac.ContainerPort().
+			WithName(constants.GroupReplicationPortName).
+			WithContainerPort(constants.GroupReplicationPort).
+			WithProtocol(corev1.ProtocolTCP))
+
+		// The members of the group find each other by `report_host`.
+		// It must be the same FQDN of the Pod as the one in the group seeds,
+		// but my.cnf is shared by all Pods, so it is given as an argument.
+		source.WithArgs(fmt.Sprintf("--report-host=$(%s).%s.%s.svc",
+			constants.PodNameEnvKey, cluster.HeadlessServiceName(), cluster.Namespace)).
+			WithEnv(corev1ac.EnvVar().
+				WithName(constants.PodNameEnvKey).
+				WithValueFrom(corev1ac.EnvVarSource().
+					WithFieldRef(corev1ac.ObjectFieldSelector().
+						WithAPIVersion("v1").
+						WithFieldPath("metadata.name")),
+				))
+	}
 
 	failureThreshold := max(cluster.Spec.StartupWaitSeconds/10, 1)
 
@@ -47,9 +47,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	crlog "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
@@ -136,6 +138,12 @@ type MySQLClusterReconciler struct {
 	MaxConcurrentReconciles       int
 	MySQLConfigMapHistoryLimit    int
 	DisableDefaultSecurityContext bool
+
+	// Owner limits the MySQLClusters to be reconciled if not nil.
+	Owner clustering.Owner
+
+	// OwnerEvents delivers the MySQLClusters that become owned by this controller.
+	OwnerEvents <-chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups=moco.cybozu.com,resources=mysqlclusters,verbs=get;list;watch;update;patch
@@ -165,6 +173,10 @@ type MySQLClusterReconciler struct {
 func (r *MySQLClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := crlog.FromContext(ctx)
 
+	if r.Owner != nil && !r.Owner.Owns(req.NamespacedName) {
+		return ctrl.Result{}, nil
+	}
+
 	cluster := &mocov1beta2.MySQLCluster{}
 	if err := r.Get(ctx, req.NamespacedName, cluster); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -286,7 +298,8 @@ func (r *MySQLClusterReconciler) reconcileV1(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
-	if err = r.reconcileV1StatefulSet(ctx, cluster, mycnf, slowlogConf); err != nil {
+	resizeWaiting, err := r.reconcileV1StatefulSet(ctx, cluster, mycnf, slowlogConf)
+	if err != nil {
 		log.Error(err, "failed to reconcile stateful set")
 		return ctrl.Result{}, err
 	}
@@ -312,6 +325,12 @@ func (r *MySQLClusterReconciler) reconcileV1(ctx context.Context, req ctrl.Reque
 
 	r.ClusterManager.Update(client.ObjectKeyFromObject(cluster), string(controller.ReconcileIDFromContext(ctx)))
 	metrics.ClusteringStoppedVec.WithLabelValues(cluster.Name, cluster.Namespace).Set(0)
+
+	// reconcile again when the next maintenance window opens to perform the delayed volume resize.
+	// The delayed rolling update is resumed by StatefulSetPartitionReconciler.
+	if resizeWaiting {
+		return ctrl.Result{RequeueAfter: waitForMaintenanceWindow(cluster)}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -460,7 +479,13 @@ func (r *MySQLClusterReconciler) reconcileV1MyCnf(ctx context.Context, cluster *
 		userConf = cm.Data
 	}
 
-	conf := mycnf.Generate(userConf, totalMem)
+	var conf string
+	if cluster.IsGroupReplication() {
+		// the UID of MySQLCluster is a UUID that is unique to the cluster.
+		conf = mycnf.GenerateForGroupReplication(userConf, totalMem, string(cluster.UID))
+	} else {
+		conf = mycnf.Generate(userConf, totalMem)
+	}
 
 	fnv32a := fnv.New32a()
 	fnv32a.Write([]byte(conf))
@@ -760,17 +785,24 @@ func (r *MySQLClusterReconciler) reconcileV1Service1(ctx context.Context, cluste
 	return nil
 }
 
+// reconcileV1StatefulSet applies the StatefulSet.  It returns true if the volume resize
+// is delayed until the next maintenance window.
+//
 //nolint:gocyclo
-func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, cluster *mocov1beta2.MySQLCluster, mycnf *corev1ac.ConfigMapApplyConfiguration, slowlogConf *corev1ac.ConfigMapApplyConfiguration) error {
+func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, cluster *mocov1beta2.MySQLCluster, mycnf *corev1ac.ConfigMapApplyConfiguration, slowlogConf *corev1ac.ConfigMapApplyConfiguration) (resizeWaiting bool, err error) {
 	log := crlog.FromContext(ctx)
 
 	var orig appsv1.StatefulSet
-	err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.PrefixedName()}, &orig)
+	err = r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.PrefixedName()}, &orig)
 	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("failed to get StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
+		return false, fmt.Errorf("failed to get StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
 	}
 
 	replicas := cluster.Spec.Replicas
+	if orig.Spec.Replicas != nil && *orig.Spec.Replicas > replicas && !isScaleInReady(cluster) {
+		// keep the instances until they are detached from the cluster.
+		replicas = *orig.Spec.Replicas
+	}
 	if cluster.Spec.Offline {
 		replicas = 0
 	}
@@ -785,10 +817,20 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 				WithType(appsv1.RollingUpdateStatefulSetStrategyType)).
 			WithServiceName(cluster.HeadlessServiceName()))
 
+	if cluster.Spec.DeletePVCsOnScaleIn {
+		sts.Spec.WithPersistentVolumeClaimRetentionPolicy(appsv1ac.StatefulSetPersistentVolumeClaimRetentionPolicy().
+			WithWhenDeleted(appsv1.RetainPersistentVolumeClaimRetentionPolicyType).
+			WithWhenScaled(appsv1.DeletePersistentVolumeClaimRetentionPolicyType))
+	}
+
 	if isForceRollingUpdate(cluster) {
 		sts.WithAnnotations(map[string]string{constants.AnnForceRollingUpdate: "true"})
 	}
 
+	// outside of the maintenance windows, keep the current volume size
+	// so that the resize is performed in the next window.
+	keepVolumeSize := waitForMaintenanceWindow(cluster) > 0
+
 	volumeClaimTemplates := make([]*corev1ac.PersistentVolumeClaimApplyConfiguration, 0, len(cluster.Spec.VolumeClaimTemplates))
 	for _, v := range cluster.Spec.VolumeClaimTemplates {
 		pvc := v.ToCoreV1()
@@ -801,8 +843,18 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 			}
 		}
 
+		if keepVolumeSize && origPVC != nil && pvc.Spec.Resources != nil && pvc.Spec.Resources.Requests != nil {
+			deployedSize := origPVC.Spec.Resources.Requests.Storage()
+			if size := v.StorageSize(); deployedSize.Cmp(size) < 0 {
+				resizeWaiting = true
+				requests := pvc.Spec.Resources.Requests.DeepCopy()
+				requests[corev1.ResourceStorage] = deployedSize.DeepCopy()
+				pvc.Spec.Resources.WithRequests(requests)
+			}
+		}
+
 		if err := setControllerReferenceWithPVC(cluster, pvc, origPVC, r.Scheme); err != nil {
-			return fmt.Errorf("failed to set ownerReference to PVC %s/%s: %w", cluster.Namespace, *pvc.Name, err)
+			return false, fmt.Errorf("failed to set ownerReference to PVC %s/%s: %w", cluster.Namespace, *pvc.Name, err)
 		}
 
 		volumeClaimTemplates = append(volumeClaimTemplates, pvc)
@@ -822,7 +874,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 	}
 
 	if mycnf.Name == nil {
-		return errors.New("unexpected error: my.conf ConfigMap name is nil")
+		return false, errors.New("unexpected error: my.conf ConfigMap name is nil")
 	}
 
 	podSpec.WithVolumes(
@@ -861,7 +913,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 
 	if !cluster.Spec.DisableSlowQueryLogContainer {
 		if slowlogConf == nil || slowlogConf.Name == nil {
-			return errors.New("unexpected error: slow query log ConfigMap or its name is nil")
+			return false, errors.New("unexpected error: slow query log ConfigMap or its name is nil")
 		}
 		podSpec.WithVolumes(
 			corev1ac.Volume().
@@ -876,7 +928,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 
 	mysqldContainer, err := r.makeV1MySQLDContainer(cluster)
 	if err != nil {
-		return err
+		return false, err
 	}
 	containers = append(containers, mysqldContainer)
 	containers = append(containers, r.makeV1AgentContainer(cluster))
@@ -885,7 +937,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 		force := cluster.Status.ReconcileInfo.Generation != cluster.Generation
 		sts, err := appsv1ac.ExtractStatefulSet(&orig, fieldManager)
 		if err != nil {
-			return fmt.Errorf("failed to extract StatefulSet: %w", err)
+			return false, fmt.Errorf("failed to extract StatefulSet: %w", err)
 		}
 
 		containers = append(containers, r.makeV1SlowQueryLogContainer(cluster, sts, force))
@@ -896,11 +948,11 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 	containers = append(containers, r.makeV1OptionalContainers(cluster)...)
 
 	if mysqldContainer.Image == nil {
-		return fmt.Errorf("unexpected mysqld container definition with MySQLCluster %s/%s: image is nil", cluster.Namespace, cluster.Name)
+		return false, fmt.Errorf("unexpected mysqld container definition with MySQLCluster %s/%s: image is nil", cluster.Namespace, cluster.Name)
 	}
 	initContainers, err := r.makeV1InitContainer(ctx, cluster, *mysqldContainer.Image)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	podSpec.Containers = nil
@@ -953,16 +1005,16 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 	sts.Spec.Template.WithSpec(&podSpec)
 
 	if err := setControllerReference(cluster, sts, r.Scheme); err != nil {
-		return fmt.Errorf("failed to set ownerReference to StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
+		return false, fmt.Errorf("failed to set ownerReference to StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
 	}
 
 	origApplyConfig, err := appsv1ac.ExtractStatefulSet(&orig, fieldManager)
 	if err != nil {
-		return fmt.Errorf("failed to extract StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
+		return false, fmt.Errorf("failed to extract StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
 	}
 
 	if equality.Semantic.DeepEqual(sts, origApplyConfig) {
-		return nil
+		return resizeWaiting, nil
 	}
 
 	needRecreate := false
@@ -978,7 +1030,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 			propagationPolicy := metav1.DeletePropagationOrphan
 			if err := r.Delete(ctx, &orig, client.PropagationPolicy(propagationPolicy)); err != nil {
 				metrics.StatefulSetRecreateErrorTotal.WithLabelValues(cluster.Name, cluster.Namespace).Inc()
-				return err
+				return false, err
 			}
 
 			log.Info("volumeClaimTemplates has changed, delete StatefulSet and try to recreate it", "statefulSetName", cluster.PrefixedName())
@@ -995,7 +1047,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 
 				return false, nil
 			}); err != nil {
-				return fmt.Errorf("re-creation failed the StatefulSet %s/%s has not been deleted: %w", cluster.Namespace, cluster.PrefixedName(), err)
+				return false, fmt.Errorf("re-creation failed the StatefulSet %s/%s has not been deleted: %w", cluster.Namespace, cluster.PrefixedName(), err)
 			}
 		}
 	}
@@ -1004,7 +1056,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 		if needRecreate {
 			metrics.StatefulSetRecreateErrorTotal.WithLabelValues(cluster.Name, cluster.Namespace).Inc()
 		}
-		return fmt.Errorf("failed to reconcile stateful set: %w", err)
+		return false, fmt.Errorf("failed to reconcile stateful set: %w", err)
 	}
 
 	if needRecreate {
@@ -1014,7 +1066,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 	if debugController {
 		var updated appsv1.StatefulSet
 		if err := r.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: cluster.PrefixedName()}, &updated); err != nil && !apierrors.IsNotFound(err) {
-			return fmt.Errorf("failed to get StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
+			return false, fmt.Errorf("failed to get StatefulSet %s/%s: %w", cluster.Namespace, cluster.PrefixedName(), err)
 		}
 
 		if diff := cmp.Diff(orig, updated); len(diff) > 0 {
@@ -1024,7 +1076,7 @@ func (r *MySQLClusterReconciler) reconcileV1StatefulSet(ctx context.Context, clu
 
 	log.Info("reconciled StatefulSet", "statefulSetName", cluster.PrefixedName())
 
-	return nil
+	return resizeWaiting, nil
 }
 
 func (r *MySQLClusterReconciler) reconcileV1PDB(ctx context.Context, cluster *mocov1beta2.MySQLCluster) error {
@@ -1901,6 +1953,29 @@ func isForceRollingUpdate(cluster *mocov1beta2.MySQLCluster) bool {
 	return cluster.Annotations[constants.AnnForceRollingUpdate] == "true"
 }
 
+// waitForMaintenanceWindow returns the duration until the next maintenance window opens.
+// It returns zero if the voluntary disruptive operations are allowed now.
+func waitForMaintenanceWindow(cluster *mocov1beta2.MySQLCluster) time.Duration {
+	ok, next := cluster.InMaintenanceWindow(time.Now())
+	if ok {
+		return 0
+	}
+	if d := time.Until(next); d > 0 {
+		return d
+	}
+	return time.Minute
+}
+
+// isScaleInReady returns true if the clustering manager has detached the instances
+// to be removed by the current spec.replicas.
+func isScaleInReady(cluster *mocov1beta2.MySQLCluster) bool {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, mocov1beta2.ConditionScaleInReady)
+	if cond == nil {
+		return false
+	}
+	return cond.Status == metav1.ConditionTrue && cond.ObservedGeneration == cluster.Generation
+}
+
 type ownerRefSetter[T any] interface {
 	WithOwnerReferences(...*metav1ac.OwnerReferenceApplyConfiguration) T
 }
@@ -2011,7 +2086,7 @@ func (r *MySQLClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.
 		return requestsForIndexedClusters(ctx, a.GetNamespace(), "spec.backupPolicyName", a.GetName())
 	})
 
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&mocov1beta2.MySQLCluster{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
@@ -2028,6 +2103,9 @@ func (r *MySQLClusterReconciler) SetupWithManager(ctx context.Context, mgr ctrl.
 		Watches(&mocov1beta2.BackupPolicy{}, backupPolicyHandler).
 		WithOptions(
 			controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles},
-		).
-		Complete(r)
+		)
+	if r.OwnerEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.OwnerEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.Complete(r)
 }
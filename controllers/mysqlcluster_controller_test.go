@@ -2013,6 +2013,78 @@ dummyKey: dummyValue
 		}).Should(Succeed())
 	})
 
+	It("should scale in statefulset after the instances are detached", func() {
+		cluster := testNewMySQLCluster("test")
+		cluster.Spec.DeletePVCsOnScaleIn = true
+		err := k8sClient.Create(ctx, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			sts := &appsv1.StatefulSet{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: "moco-test"}, sts); err != nil {
+				return err
+			}
+			if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 3 {
+				return fmt.Errorf("replica count should match cluster")
+			}
+			return nil
+		}).Should(Succeed())
+
+		sts := &appsv1.StatefulSet{}
+		err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: "moco-test"}, sts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sts.Spec.PersistentVolumeClaimRetentionPolicy).NotTo(BeNil())
+		Expect(sts.Spec.PersistentVolumeClaimRetentionPolicy.WhenScaled).To(Equal(appsv1.DeletePersistentVolumeClaimRetentionPolicyType))
+		Expect(sts.Spec.PersistentVolumeClaimRetentionPolicy.WhenDeleted).To(Equal(appsv1.RetainPersistentVolumeClaimRetentionPolicyType))
+
+		By("decreasing replicas")
+		Eventually(func() error {
+			cluster2 := &mocov1beta2.MySQLCluster{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster2); err != nil {
+				return err
+			}
+			cluster2.Spec.Replicas = 1
+			return k8sClient.Update(ctx, cluster2)
+		}).Should(Succeed())
+
+		Consistently(func() error {
+			sts := &appsv1.StatefulSet{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: "moco-test"}, sts); err != nil {
+				return err
+			}
+			if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 3 {
+				return fmt.Errorf("replica count should not be decreased before the instances are detached")
+			}
+			return nil
+		}, 3*time.Second).Should(Succeed())
+
+		By("marking the instances detached")
+		Eventually(func() error {
+			cluster2 := &mocov1beta2.MySQLCluster{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cluster), cluster2); err != nil {
+				return err
+			}
+			meta.SetStatusCondition(&cluster2.Status.Conditions, metav1.Condition{
+				Type:               mocov1beta2.ConditionScaleInReady,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: cluster2.Generation,
+				Reason:             "Detached",
+			})
+			return k8sClient.Status().Update(ctx, cluster2)
+		}).Should(Succeed())
+
+		Eventually(func() error {
+			sts := &appsv1.StatefulSet{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: "test", Name: "moco-test"}, sts); err != nil {
+				return err
+			}
+			if sts.Spec.Replicas == nil || *sts.Spec.Replicas != 1 {
+				return fmt.Errorf("replica count should be decreased")
+			}
+			return nil
+		}).Should(Succeed())
+	})
+
 	It("should sets ConditionStatefulSetReady to be true when StatefulSet is ready", func() {
 		cluster := testNewMySQLCluster("test")
 		err := k8sClient.Create(ctx, cluster)
@@ -25,6 +25,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/clustering"
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/cybozu-go/moco/pkg/metrics"
 )
@@ -38,6 +39,9 @@ type StatefulSetPartitionReconciler struct {
 	MaxConcurrentReconciles int
 	UpdateInterval          time.Duration
 	RateLimiter             *rate.Limiter
+
+	// Owner limits the MySQLClusters whose StatefulSets are reconciled if not nil.
+	Owner clustering.Owner
 }
 
 //+kubebuilder:rbac:groups=moco.cybozu.com,resources=mysqlclusters,verbs=get;list;watch
@@ -66,6 +70,9 @@ func (r *StatefulSetPartitionReconciler) Reconcile(ctx context.Context, req reco
 	if err != nil {
 		return reconcile.Result{}, fmt.Errorf("failed to get MySQLCluster: %w", err)
 	}
+	if r.Owner != nil && !r.Owner.Owns(client.ObjectKeyFromObject(cluster)) {
+		return reconcile.Result{}, nil
+	}
 
 	metrics.CurrentReplicasVec.WithLabelValues(cluster.Name, cluster.Namespace).Set(float64(sts.Status.CurrentReplicas))
 	metrics.UpdatedReplicasVec.WithLabelValues(cluster.Name, cluster.Namespace).Set(float64(sts.Status.UpdatedReplicas))
@@ -80,6 +87,11 @@ func (r *StatefulSetPartitionReconciler) Reconcile(ctx context.Context, req reco
 		return reconcile.Result{}, nil
 	}
 
+	if wait := waitForMaintenanceWindow(cluster); wait > 0 {
+		log.Info("rolling update is waiting for maintenance window", "wait", wait.String())
+		return reconcile.Result{RequeueAfter: wait}, nil
+	}
+
 	ready, err := r.isRolloutReady(ctx, cluster, sts)
 	if err != nil {
 		log.Error(err, "failed to check if rollout is ready")
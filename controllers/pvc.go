@@ -36,6 +36,9 @@ var (
 // This function first syncs the PVC labels and annotations with those of volumeClaimTemplate and then resizes the PVC volume size.
 // The deletion and recreation of the StatefulSet are managed by reconcileV1StatefulSet().
 // Therefore, this function should be called before reconcileV1StatefulSet().
+//
+// Outside of `spec.maintenanceWindows`, the resize is delayed and reconcileV1StatefulSet()
+// keeps the current volume size in the volumeClaimTemplate.
 func (r *MySQLClusterReconciler) reconcilePVC(ctx context.Context, cluster *mocov1beta2.MySQLCluster) error {
 	log := crlog.FromContext(ctx)
 
@@ -60,6 +63,11 @@ func (r *MySQLClusterReconciler) reconcilePVC(ctx context.Context, cluster *moco
 		return nil
 	}
 
+	if waitForMaintenanceWindow(cluster) > 0 {
+		log.Info("PVC resize is waiting for maintenance window")
+		return nil
+	}
+
 	log.Info("Starting PVC resize")
 
 	resized, err := r.resizePVCs(ctx, cluster, &sts, resizeTarget)
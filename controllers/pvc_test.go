@@ -6,6 +6,7 @@ import (
 	"maps"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/cybozu-go/moco/pkg/metrics"
@@ -56,6 +57,27 @@ func TestReconcilePVC(t *testing.T) {
 moco_cluster_volume_resized_total{name="mysql-cluster",namespace="default"} 1
 `,
 		},
+		{
+			name: "resize waiting for maintenance window",
+			cluster: func() *mocov1beta2.MySQLCluster {
+				cluster := newMySQLClusterWithVolumeSize(resource.MustParse("2Gi"))
+				cluster.Spec.MaintenanceWindows = []mocov1beta2.MaintenanceWindow{
+					{Schedule: "0 0 1 1 *", Duration: metav1.Duration{Duration: time.Minute}},
+				}
+				return cluster
+			}(),
+			setupClient: func(t *testing.T) client.Client {
+				cluster := newMySQLClusterWithVolumeSize(resource.MustParse("2Gi"))
+				sts := newStatefulSetWithVolumeSize(resource.MustParse("1Gi"))
+				return setupMockClient(t, cluster, sts)
+			},
+			wantSize: resource.MustParse("1Gi"),
+			wantLabels: map[string]string{
+				"app.kubernetes.io/created-by": "moco",
+				"app.kubernetes.io/instance":   "mysql-cluster",
+				"app.kubernetes.io/name":       "mysql",
+			},
+		},
 		{
 			name: "label synced",
 			cluster: func() *mocov1beta2.MySQLCluster {
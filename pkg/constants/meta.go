@@ -13,16 +13,28 @@ const (
 	LabelMocoRole = "moco.cybozu.com/role"
 	RolePrimary   = "primary"
 	RoleReplica   = "replica"
+
+	LabelShardGroup = "moco.cybozu.com/shard-group"
 )
 
 // annotation keys and values
 const (
-	AnnDemote                = "moco.cybozu.com/demote"
-	AnnSecretVersion         = "moco.cybozu.com/secret-version"
-	AnnClusteringStopped     = "moco.cybozu.com/clustering-stopped"
-	AnnReconciliationStopped = "moco.cybozu.com/reconciliation-stopped"
-	AnnForceRollingUpdate    = "moco.cybozu.com/force-rolling-update"
-	AnnPreventDelete         = "moco.cybozu.com/prevent-delete"
+	AnnDemote                       = "moco.cybozu.com/demote"
+	AnnSwitchoverTo                 = "moco.cybozu.com/switchover-to"
+	AnnSecretVersion                = "moco.cybozu.com/secret-version"
+	AnnClusteringStopped            = "moco.cybozu.com/clustering-stopped"
+	AnnReconciliationStopped        = "moco.cybozu.com/reconciliation-stopped"
+	AnnForceRollingUpdate           = "moco.cybozu.com/force-rolling-update"
+	AnnPreventDelete                = "moco.cybozu.com/prevent-delete"
+	AnnApproveFailover              = "moco.cybozu.com/approve-failover"
+	AnnAcknowledgeRoleChangeHold    = "moco.cybozu.com/acknowledge-role-change-hold"
+	AnnPromotionPriority            = "moco.cybozu.com/promotion-priority"
+	AnnNeverPromote                 = "moco.cybozu.com/never-promote"
+	AnnPromote                      = "moco.cybozu.com/promote"
+	AnnRecloneErrant                = "moco.cybozu.com/reclone-errant"
+	AnnRecoverTo                    = "moco.cybozu.com/recover-to"
+	AnnReplicationAllowedNamespaces = "moco.cybozu.com/replication-allowed-namespaces"
+	AnnCheckInterval                = "moco.cybozu.com/check-interval"
 )
 
 // MySQLClusterFinalizer is the finalizer specifier for MySQLCluster.
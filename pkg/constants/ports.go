@@ -13,6 +13,10 @@ const (
 	MySQLAdminPort     = 33062
 	MySQLAdminPortName = "mysql-admin"
 
+	// GroupReplicationPort is the port number for the communication between the members of Group Replication
+	GroupReplicationPort     = 33061
+	GroupReplicationPortName = "mysql-gr"
+
 	// MySQLHealthPort is the port number to check readiness and liveness of mysqld.
 	MySQLHealthPort     = 9081
 	MySQLHealthPortName = "health"
@@ -0,0 +1,75 @@
+package dbop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cybozu-go/moco/pkg/constants"
+)
+
+// ddlCommands is the set of the first keywords of DDL statements that are rolled back if killed.
+var ddlCommands = map[string]bool{
+	"ALTER":    true,
+	"CREATE":   true,
+	"DROP":     true,
+	"RENAME":   true,
+	"TRUNCATE": true,
+	"OPTIMIZE": true,
+}
+
+func (o *operator) GetLongRunningActivities(ctx context.Context, threshold time.Duration) ([]Activity, error) {
+	seconds := int64(threshold / time.Second)
+
+	var queries []Activity
+	err := o.db.SelectContext(ctx, &queries, `
+SELECT ID, USER, HOST, TIME AS SECONDS, COALESCE(INFO, '') AS QUERY
+FROM information_schema.PROCESSLIST
+WHERE COMMAND = 'Query' AND TIME >= ?`, seconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get running queries: %w", err)
+	}
+
+	var trxs []Activity
+	err = o.db.SelectContext(ctx, &trxs, `
+SELECT p.ID, p.USER, p.HOST, TIMESTAMPDIFF(SECOND, t.trx_started, NOW()) AS SECONDS, COALESCE(p.INFO, '') AS QUERY
+FROM information_schema.INNODB_TRX t
+JOIN information_schema.PROCESSLIST p ON t.trx_mysql_thread_id = p.ID
+WHERE t.trx_started <= NOW() - INTERVAL ? SECOND`, seconds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open transactions: %w", err)
+	}
+
+	var activities []Activity
+	ddls := make(map[uint64]bool)
+	for _, a := range queries {
+		if isSystemProcess(a.User) || !isDDL(a.Query) {
+			continue
+		}
+		a.DDL = true
+		ddls[a.ID] = true
+		activities = append(activities, a)
+	}
+	for _, a := range trxs {
+		if isSystemProcess(a.User) || ddls[a.ID] {
+			continue
+		}
+		activities = append(activities, a)
+	}
+	return activities, nil
+}
+
+// isSystemProcess returns true if the process is run by MOCO or mysqld itself.
+func isSystemProcess(user string) bool {
+	return constants.MocoSystemUsers[user] || user == "system user" || user == "event_scheduler"
+}
+
+// isDDL returns true if `query` is a DDL statement.
+func isDDL(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	return ddlCommands[strings.ToUpper(fields[0])]
+}
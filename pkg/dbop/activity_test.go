@@ -0,0 +1,64 @@
+package dbop
+
+import (
+	"context"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/password"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("activity", func() {
+	ctx := context.Background()
+
+	It("should report long-running transactions", func() {
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = "activity"
+		cluster.Spec.Replicas = 1
+
+		passwd, err := password.NewMySQLPassword()
+		Expect(err).NotTo(HaveOccurred())
+
+		op, err := factory.New(ctx, cluster, passwd, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer op.Close()
+		pop := op.(*operator)
+
+		By("opening a transaction")
+		_, err = pop.db.Exec("SET GLOBAL read_only=0")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = pop.db.Exec("CREATE USER IF NOT EXISTS 'trx'@'%' IDENTIFIED BY 'pass'")
+		Expect(err).NotTo(HaveOccurred())
+		db, err := factory.(*testFactory).newConn(ctx, cluster, "trx", "pass", 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+		conn, err := db.Conn(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		_, err = conn.ExecContext(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("checking the activities")
+		Eventually(func(g Gomega) {
+			activities, err := op.GetLongRunningActivities(ctx, time.Second)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(activities).To(HaveLen(1))
+			g.Expect(activities[0].User).To(Equal("trx"))
+			g.Expect(activities[0].DDL).To(BeFalse())
+			g.Expect(activities[0].Seconds).To(BeNumerically(">=", 1))
+		}).Should(Succeed())
+
+		activities, err := op.GetLongRunningActivities(ctx, time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(activities).To(BeEmpty())
+
+		_, err = conn.ExecContext(ctx, "COMMIT")
+		Expect(err).NotTo(HaveOccurred())
+		activities, err = op.GetLongRunningActivities(ctx, 0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(activities).To(BeEmpty())
+	})
+})
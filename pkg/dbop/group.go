@@ -0,0 +1,72 @@
+package dbop
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+func (o *operator) getGroupMembers(ctx context.Context) ([]GroupMember, error) {
+	var members []GroupMember
+	err := o.db.SelectContext(ctx, &members, `SELECT MEMBER_ID, MEMBER_HOST, MEMBER_PORT, MEMBER_STATE, MEMBER_ROLE FROM performance_schema.replication_group_members`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ps.replication_group_members: %w", err)
+	}
+
+	// The table has a row without MEMBER_ID when Group Replication is not running.
+	filtered := members[:0]
+	for _, m := range members {
+		if m.ID == "" {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered, nil
+}
+
+func (o *operator) StartGroupReplication(ctx context.Context, config GroupReplicationConfig) error {
+	if _, err := o.db.ExecContext(ctx, "SET GLOBAL group_replication_local_address=?", config.LocalAddress); err != nil {
+		return fmt.Errorf("failed to set group_replication_local_address: %w", err)
+	}
+	if _, err := o.db.ExecContext(ctx, "SET GLOBAL group_replication_group_seeds=?", strings.Join(config.Seeds, ",")); err != nil {
+		return fmt.Errorf("failed to set group_replication_group_seeds: %w", err)
+	}
+
+	if config.Bootstrap {
+		if _, err := o.db.ExecContext(ctx, "SET GLOBAL group_replication_bootstrap_group=ON"); err != nil {
+			return fmt.Errorf("failed to set group_replication_bootstrap_group=ON: %w", err)
+		}
+	}
+
+	_, startErr := o.db.ExecContext(ctx, "START GROUP_REPLICATION USER=?, PASSWORD=?", config.User, config.Password)
+
+	// group_replication_bootstrap_group must be turned off whether the start succeeded or not.
+	// Otherwise, the next start of this instance would bootstrap another group.
+	if config.Bootstrap {
+		if _, err := o.db.ExecContext(context.Background(), "SET GLOBAL group_replication_bootstrap_group=OFF"); err != nil {
+			if startErr != nil {
+				return fmt.Errorf("failed to start group replication: %w, and failed to set group_replication_bootstrap_group=OFF: %v", startErr, err)
+			}
+			return fmt.Errorf("failed to set group_replication_bootstrap_group=OFF: %w", err)
+		}
+	}
+
+	if startErr != nil {
+		return fmt.Errorf("failed to start group replication: %w", startErr)
+	}
+	return nil
+}
+
+func (o *operator) StopGroupReplication(ctx context.Context) error {
+	if _, err := o.db.ExecContext(ctx, "STOP GROUP_REPLICATION"); err != nil {
+		return fmt.Errorf("failed to stop group replication: %w", err)
+	}
+	return nil
+}
+
+func (o *operator) SetGroupPrimary(ctx context.Context, uuid string) error {
+	if _, err := o.db.ExecContext(ctx, "SELECT group_replication_set_as_primary(?)", uuid); err != nil {
+		return fmt.Errorf("failed to set %s as the primary of the group: %w", uuid, err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package dbop
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/password"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("group replication", func() {
+	ctx := context.Background()
+
+	It("should bootstrap and switch the primary of a group", func() {
+		By("preparing 3 node cluster")
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = "group"
+		cluster.Spec.Replicas = 3
+		cluster.Spec.ReplicationMode = mocov1beta2.ReplicationModeGroupReplication
+
+		passwd, err := password.NewMySQLPassword()
+		Expect(err).NotTo(HaveOccurred())
+
+		ops := make([]*operator, cluster.Spec.Replicas)
+		for i := 0; i < int(cluster.Spec.Replicas); i++ {
+			op, err := factory.New(context.Background(), cluster, passwd, i)
+			Expect(err).NotTo(HaveOccurred())
+			ops[i] = op.(*operator)
+		}
+		defer func() {
+			for _, op := range ops {
+				op.Close()
+			}
+		}()
+
+		var seeds []string
+		for i := range ops {
+			seeds = append(seeds, fmt.Sprintf("%s:%d", testContainerName(cluster, i), constants.GroupReplicationPort))
+		}
+		config := func(i int, bootstrap bool) GroupReplicationConfig {
+			return GroupReplicationConfig{
+				LocalAddress: seeds[i],
+				Seeds:        seeds,
+				User:         constants.ReplicationUser,
+				Password:     passwd.Replicator(),
+				Bootstrap:    bootstrap,
+			}
+		}
+		onlineMembers := func(i int) int {
+			st, err := ops[i].GetStatus(ctx)
+			if err != nil {
+				return 0
+			}
+			var n int
+			for _, m := range st.GroupMembers {
+				if m.State == "ONLINE" {
+					n++
+				}
+			}
+			return n
+		}
+
+		By("checking the status of an instance that is not a member")
+		st0, err := ops[0].GetStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(st0.GroupMembers).To(BeEmpty())
+		Expect(st0.ReplicaStatus).To(BeNil())
+
+		By("bootstrapping the group with instance 0")
+		err = ops[0].StartGroupReplication(ctx, config(0, true))
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() int { return onlineMembers(0) }).Should(Equal(1))
+		var bootstrap bool
+		err = ops[0].db.Get(&bootstrap, `SELECT @@group_replication_bootstrap_group`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bootstrap).To(BeFalse())
+
+		_, err = ops[0].db.Exec(`CREATE DATABASE foo`)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ops[0].db.Exec(`CREATE TABLE foo.t1 (pkey INT PRIMARY KEY, data TEXT NOT NULL) ENGINE=InnoDB`)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = ops[0].db.Exec(`INSERT INTO foo.t1 (pkey, data) VALUES (1, "aaa"), (2, "bbb")`)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("joining instance 1 and 2 to the group")
+		for i := 1; i < 3; i++ {
+			err = ops[i].StartGroupReplication(ctx, config(i, false))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Eventually(func() int { return onlineMembers(0) }, 1*time.Minute).Should(Equal(3))
+		Eventually(func() int {
+			var count int
+			err := ops[2].db.Get(&count, `SELECT COUNT(*) FROM foo.t1`)
+			if err != nil {
+				return 0
+			}
+			return count
+		}).Should(Equal(2))
+
+		By("checking the group members")
+		st0, err = ops[0].GetStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(st0.GroupMembers).To(HaveLen(3))
+		for _, m := range st0.GroupMembers {
+			if m.ID == st0.GlobalVariables.UUID {
+				Expect(m.Role).To(Equal("PRIMARY"))
+				Expect(m.Host).To(Equal(testContainerName(cluster, 0)))
+			} else {
+				Expect(m.Role).To(Equal("SECONDARY"))
+			}
+		}
+		st2, err := ops[2].GetStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(st2.GlobalVariables.SuperReadOnly).To(BeTrue())
+
+		By("switching the primary to instance 2")
+		err = ops[0].SetGroupPrimary(ctx, st2.GlobalVariables.UUID)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() bool {
+			st, err := ops[2].GetStatus(ctx)
+			if err != nil {
+				return false
+			}
+			return !st.GlobalVariables.SuperReadOnly
+		}).Should(BeTrue())
+		_, err = ops[2].db.Exec(`INSERT INTO foo.t1 (pkey, data) VALUES (3, "ccc")`)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() int {
+			var count int
+			err := ops[0].db.Get(&count, `SELECT COUNT(*) FROM foo.t1`)
+			if err != nil {
+				return 0
+			}
+			return count
+		}).Should(Equal(3))
+
+		By("stopping group replication of instance 1")
+		err = ops[1].StopGroupReplication(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(func() int { return onlineMembers(2) }).Should(Equal(2))
+	})
+})
@@ -3,6 +3,8 @@ package dbop
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 // FindTopRunner returns the index of the slice whose `GlobalVariables.ExecutedGtidSet`
@@ -21,17 +23,7 @@ func FindTopRunner(ctx context.Context, o Operator, status []*MySQLInstanceStatu
 			continue
 		}
 
-		// There are cases where Retrieved_Gtid_Set is empty,
-		// such as when there is no transaction immediately after a fail-over.
-		// Therefore, Retrieved_Gtid_Set and Executed_Gtid_Set are unioned to find for the top runner.
-		// The union of two GTID sets is simply their joined together with an interposed comma.
-		// https://dev.mysql.com/doc/refman/8.0/en/gtid-functions.html
-		var gtids string
-		if len(repl.RetrievedGtidSet) == 0 {
-			gtids = repl.ExecutedGtidSet
-		} else {
-			gtids = fmt.Sprintf("%s,%s", repl.RetrievedGtidSet, repl.ExecutedGtidSet)
-		}
+		gtids := knownGTIDs(repl)
 		if len(gtids) == 0 {
 			continue
 		}
@@ -70,6 +62,54 @@ func FindTopRunner(ctx context.Context, o Operator, status []*MySQLInstanceStatu
 	return latest, nil
 }
 
+// FindTopRunners is like FindTopRunner but returns the indices of all instances
+// whose GTID set is equal to that of the top runner, in ascending order.
+func FindTopRunners(ctx context.Context, o Operator, status []*MySQLInstanceStatus) ([]int, error) {
+	top, err := FindTopRunner(ctx, o, status)
+	if err != nil {
+		return nil, err
+	}
+
+	topGTIDs := knownGTIDs(status[top].ReplicaStatus)
+	var runners []int
+	for i := range status {
+		if i == top {
+			runners = append(runners, i)
+			continue
+		}
+		if status[i] == nil || status[i].ReplicaStatus == nil {
+			continue
+		}
+		gtids := knownGTIDs(status[i].ReplicaStatus)
+		if len(gtids) == 0 {
+			continue
+		}
+
+		// gtids is known to be a subset of topGTIDs.
+		isSubset, err := o.IsSubsetGTID(ctx, topGTIDs, gtids)
+		if err != nil {
+			return nil, err
+		}
+		if isSubset {
+			runners = append(runners, i)
+		}
+	}
+	return runners, nil
+}
+
+// knownGTIDs returns the GTID set that the replica has retrieved or executed.
+func knownGTIDs(repl *ReplicaStatus) string {
+	// There are cases where Retrieved_Gtid_Set is empty,
+	// such as when there is no transaction immediately after a fail-over.
+	// Therefore, Retrieved_Gtid_Set and Executed_Gtid_Set are unioned to find for the top runner.
+	// The union of two GTID sets is simply their joined together with an interposed comma.
+	// https://dev.mysql.com/doc/refman/8.0/en/gtid-functions.html
+	if len(repl.RetrievedGtidSet) == 0 {
+		return repl.ExecutedGtidSet
+	}
+	return fmt.Sprintf("%s,%s", repl.RetrievedGtidSet, repl.ExecutedGtidSet)
+}
+
 func (o *operator) IsSubsetGTID(ctx context.Context, set1, set2 string) (bool, error) {
 	var ret bool
 	if err := o.db.GetContext(ctx, &ret, `SELECT GTID_SUBSET(?,?)`, set1, set2); err != nil {
@@ -85,3 +125,36 @@ func (o *operator) SubtractGTID(ctx context.Context, set1, set2 string) (string,
 	}
 	return ret, nil
 }
+
+// CountGTIDs returns the number of transactions in a GTID set such as
+// "3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:11,ABCDEF01-71CA-11E1-9E33-C80AA9429562:tag:1".
+func CountGTIDs(gtidSet string) (int64, error) {
+	var n int64
+	for set := range strings.SplitSeq(gtidSet, ",") {
+		set = strings.TrimSpace(set)
+		if set == "" {
+			continue
+		}
+		fields := strings.Split(set, ":")
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("invalid GTID set: %s", set)
+		}
+		for _, interval := range fields[1:] {
+			start, end, found := strings.Cut(interval, "-")
+			first, err := strconv.ParseInt(start, 10, 64)
+			if err != nil {
+				// a tag of MySQL 8.4 or later
+				continue
+			}
+			last := first
+			if found {
+				last, err = strconv.ParseInt(end, 10, 64)
+				if err != nil || last < first {
+					return 0, fmt.Errorf("invalid GTID interval %q in %s", interval, set)
+				}
+			}
+			n += last - first + 1
+		}
+	}
+	return n, nil
+}
@@ -51,6 +51,13 @@ var _ = Describe("FindTopRunner", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(top).To(Equal(2))
 
+		statuses[0] = &MySQLInstanceStatus{ReplicaStatus: &ReplicaStatus{RetrievedGtidSet: set2}}
+		statuses[1] = &MySQLInstanceStatus{ReplicaStatus: &ReplicaStatus{RetrievedGtidSet: set1}}
+		statuses[2] = &MySQLInstanceStatus{ReplicaStatus: &ReplicaStatus{ExecutedGtidSet: set2}}
+		runners, err := FindTopRunners(context.Background(), op, statuses)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runners).To(Equal([]int{0, 2}))
+
 		// errant transactions
 		set0 = `8e349184-bc14-11e3-8d4c-0800272864ba:1-30,
 8e3648e4-bc14-11e3-8d4c-0800272864ba:1-7`
@@ -63,3 +70,29 @@ var _ = Describe("FindTopRunner", func() {
 		Expect(err).To(MatchError(ErrErrantTransactions))
 	})
 })
+
+var _ = Describe("CountGTIDs", func() {
+	It("should count the transactions in a GTID set", func() {
+		testCases := []struct {
+			set      string
+			expected int64
+		}{
+			{"", 0},
+			{"8e349184-bc14-11e3-8d4c-0800272864ba:1", 1},
+			{"8e349184-bc14-11e3-8d4c-0800272864ba:1-29", 29},
+			{"8e349184-bc14-11e3-8d4c-0800272864ba:1-5:11-12", 7},
+			{"8e349184-bc14-11e3-8d4c-0800272864ba:1-5,\n8e349184-bc14-11e3-8d4c-0800272864bb:3", 6},
+			{"8e349184-bc14-11e3-8d4c-0800272864ba:1-5:tag:1-2", 7},
+		}
+		for _, tc := range testCases {
+			n, err := CountGTIDs(tc.set)
+			Expect(err).NotTo(HaveOccurred(), tc.set)
+			Expect(n).To(Equal(tc.expected), tc.set)
+		}
+
+		_, err := CountGTIDs("8e349184-bc14-11e3-8d4c-0800272864ba")
+		Expect(err).To(HaveOccurred())
+		_, err = CountGTIDs("8e349184-bc14-11e3-8d4c-0800272864ba:5-1")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -4,18 +4,67 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/cybozu-go/moco/pkg/constants"
 	"github.com/go-sql-driver/mysql"
 )
 
-func (o *operator) KillConnections(ctx context.Context) error {
-	var procs []Process
+// drainPollInterval is the interval to check the client sessions while draining.
+const drainPollInterval = 500 * time.Millisecond
+
+func (o *operator) KillConnections(ctx context.Context, opts KillOptions) error {
+	protected := make(map[string]bool)
+	for _, u := range opts.ProtectedUsers {
+		protected[u] = true
+	}
+	first := make(map[string]bool)
+	for _, u := range opts.KillFirstUsers {
+		first[u] = true
+	}
+
+	procs, err := o.getClientProcesses(ctx, protected)
+	if err != nil {
+		return err
+	}
+	var rest []Process
+	for _, p := range procs {
+		if !first[p.User] {
+			rest = append(rest, p)
+			continue
+		}
+		if err := o.killProcess(ctx, p); err != nil {
+			return err
+		}
+	}
+
+	if opts.GracePeriod > 0 {
+		rest, err = o.waitForIdle(ctx, protected, rest, opts.GracePeriod)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range rest {
+		if err := o.killProcess(ctx, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	if err := o.db.SelectContext(ctx, &procs, `SELECT ID, USER, HOST FROM information_schema.PROCESSLIST`); err != nil {
-		return fmt.Errorf("failed to get process list: %w", err)
+// getClientProcesses returns the processes of the clients that MOCO may kill.
+func (o *operator) getClientProcesses(ctx context.Context, protected map[string]bool) ([]Process, error) {
+	var procs []Process
+	err := o.db.SelectContext(ctx, &procs, `
+SELECT p.ID, p.USER, p.HOST, p.COMMAND, t.trx_id IS NOT NULL AS IN_TRX
+FROM information_schema.PROCESSLIST p
+LEFT JOIN information_schema.INNODB_TRX t ON t.trx_mysql_thread_id = p.ID`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process list: %w", err)
 	}
 
+	var clients []Process
 	for _, p := range procs {
 		if constants.MocoSystemUsers[p.User] {
 			continue
@@ -26,11 +75,48 @@ func (o *operator) KillConnections(ctx context.Context) error {
 		if p.User == "system user" {
 			continue
 		}
+		if protected[p.User] {
+			continue
+		}
+		clients = append(clients, p)
+	}
+	return clients, nil
+}
+
+// waitForIdle waits for all the client sessions to become idle at most for `grace`.
+// It returns the client processes remaining at the end.
+func (o *operator) waitForIdle(ctx context.Context, protected map[string]bool, procs []Process, grace time.Duration) ([]Process, error) {
+	deadline := time.Now().Add(grace)
+	for {
+		idle := true
+		for _, p := range procs {
+			if !p.idle() {
+				idle = false
+				break
+			}
+		}
+		if idle || !time.Now().Before(deadline) {
+			return procs, nil
+		}
+
+		select {
+		case <-time.After(drainPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
 
-		if _, err := o.db.ExecContext(ctx, `KILL CONNECTION ?`, p.ID); err != nil && !isNoSuchThread(err) {
-			return fmt.Errorf("failed to kill connection %d for %s from %s: %w", p.ID, p.User, p.Host, err)
+		var err error
+		procs, err = o.getClientProcesses(ctx, protected)
+		if err != nil {
+			return nil, err
 		}
 	}
+}
+
+func (o *operator) killProcess(ctx context.Context, p Process) error {
+	if _, err := o.db.ExecContext(ctx, `KILL CONNECTION ?`, p.ID); err != nil && !isNoSuchThread(err) {
+		return fmt.Errorf("failed to kill connection %d for %s from %s: %w", p.ID, p.User, p.Host, err)
+	}
 	return nil
 }
 
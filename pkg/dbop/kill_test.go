@@ -2,7 +2,9 @@ package dbop
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"time"
 
 	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
 	"github.com/cybozu-go/moco/pkg/constants"
@@ -42,7 +44,7 @@ var _ = Describe("kill", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, false)
+		}, false, 0)
 		Expect(err).NotTo(HaveOccurred())
 
 		By("creating a user and making a connection with the user")
@@ -69,7 +71,7 @@ var _ = Describe("kill", func() {
 		Expect(fooFound).To(BeTrue())
 
 		By("killing user process in primary")
-		err = ops[0].KillConnections(context.Background())
+		err = ops[0].KillConnections(context.Background(), KillOptions{})
 		Expect(err).NotTo(HaveOccurred())
 
 		var procs2 []Process
@@ -94,7 +96,7 @@ var _ = Describe("kill", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		By("system user is not killed")
-		err = ops[1].KillConnections(context.Background())
+		err = ops[1].KillConnections(context.Background(), KillOptions{})
 		Expect(err).NotTo(HaveOccurred())
 
 		var procs4 []Process
@@ -104,4 +106,73 @@ var _ = Describe("kill", func() {
 			return len(procs3) - len(procs4)
 		}).Should(BeNumerically("==", 0))
 	})
+
+	It("should drain connections", func() {
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = "drain"
+		cluster.Spec.Replicas = 1
+
+		passwd, err := password.NewMySQLPassword()
+		Expect(err).NotTo(HaveOccurred())
+
+		op, err := factory.New(ctx, cluster, passwd, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer op.Close()
+		pop := op.(*operator)
+
+		By("making connections with users")
+		_, err = pop.db.Exec("SET GLOBAL read_only=0")
+		Expect(err).NotTo(HaveOccurred())
+		conns := make(map[string]*sql.Conn)
+		for _, user := range []string{"protected", "first", "busy"} {
+			_, err = pop.db.Exec("CREATE USER IF NOT EXISTS ?@'%' IDENTIFIED BY 'pass'", user)
+			Expect(err).NotTo(HaveOccurred())
+			db, err := factory.(*testFactory).newConn(ctx, cluster, user, "pass", 0)
+			Expect(err).NotTo(HaveOccurred())
+			defer db.Close()
+			conn, err := db.Conn(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+			conns[user] = conn
+		}
+		_, err = conns["busy"].ExecContext(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT")
+		Expect(err).NotTo(HaveOccurred())
+
+		users := func() map[string]bool {
+			procs, err := pop.getClientProcesses(ctx, nil)
+			Expect(err).NotTo(HaveOccurred())
+			users := make(map[string]bool)
+			for _, p := range procs {
+				users[p.User] = true
+			}
+			return users
+		}
+
+		By("draining the connections")
+		opts := KillOptions{
+			GracePeriod:    10 * time.Second,
+			ProtectedUsers: []string{"protected"},
+			KillFirstUsers: []string{"first"},
+		}
+		start := time.Now()
+		done := make(chan error, 1)
+		go func() {
+			done <- op.KillConnections(ctx, opts)
+		}()
+
+		Eventually(func() bool {
+			return users()["first"]
+		}).Should(BeFalse())
+		Consistently(done, 1*time.Second).ShouldNot(Receive())
+		Expect(users()).To(HaveKey("busy"))
+
+		By("finishing the transaction")
+		_, err = conns["busy"].ExecContext(ctx, "COMMIT")
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(time.Since(start)).To(BeNumerically("<", opts.GracePeriod))
+
+		Eventually(users).Should(Equal(map[string]bool{"protected": true}))
+	})
 })
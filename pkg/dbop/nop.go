@@ -3,6 +3,7 @@ package dbop
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 // ErrNop is a sentinel error for NopOperator
@@ -35,11 +36,11 @@ func (o NopOperator) IsSubsetGTID(ctx context.Context, set1, set2 string) (bool,
 	return false, ErrNop
 }
 
-func (o NopOperator) ConfigureReplica(ctx context.Context, source AccessInfo, semisync bool) error {
+func (o NopOperator) ConfigureReplica(ctx context.Context, source AccessInfo, semisync bool, delaySeconds int) error {
 	return ErrNop
 }
 
-func (o NopOperator) ConfigurePrimary(ctx context.Context, waitForCount int) error {
+func (o NopOperator) ConfigurePrimary(ctx context.Context, waitForCount, timeoutSeconds int) error {
 	return ErrNop
 }
 
@@ -55,6 +56,26 @@ func (o NopOperator) SetReadOnly(context.Context, bool) error {
 	return ErrNop
 }
 
-func (o NopOperator) KillConnections(context.Context) error {
+func (o NopOperator) KillConnections(context.Context, KillOptions) error {
+	return ErrNop
+}
+
+func (o NopOperator) GetLongRunningActivities(ctx context.Context, threshold time.Duration) ([]Activity, error) {
+	return nil, ErrNop
+}
+
+func (o NopOperator) ResetReplication(ctx context.Context) error {
+	return ErrNop
+}
+
+func (o NopOperator) StartGroupReplication(ctx context.Context, config GroupReplicationConfig) error {
+	return ErrNop
+}
+
+func (o NopOperator) StopGroupReplication(context.Context) error {
+	return ErrNop
+}
+
+func (o NopOperator) SetGroupPrimary(ctx context.Context, uuid string) error {
 	return ErrNop
 }
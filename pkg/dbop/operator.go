@@ -18,6 +18,9 @@ import (
 const (
 	connTimeout = 5 * time.Second
 	readTimeout = 1 * time.Minute
+
+	// restoreTimeout is the timeout to restore a setting after an operation fails.
+	restoreTimeout = 10 * time.Second
 )
 
 // Operator represents a set of operations for a MySQL instance.
@@ -40,11 +43,13 @@ type Operator interface {
 	// ConfigureReplica configures client-side replication.
 	// If `symisync` is true, it enables client-side semi-synchronous replication.
 	// In either case, it disables server-side semi-synchronous replication.
-	ConfigureReplica(ctx context.Context, source AccessInfo, semisync bool) error
+	// If `delaySeconds` is positive, the replica applies transactions with the delay.
+	ConfigureReplica(ctx context.Context, source AccessInfo, semisync bool, delaySeconds int) error
 
 	// ConfigurePrimary configures server-side semi-synchronous replication.
-	// For asynchronous replication, this method should not be called.
-	ConfigurePrimary(ctx context.Context, waitForCount int) error
+	// The primary waits for `waitForCount` replicas for `timeoutSeconds` at most.
+	// If `waitForCount` is zero, it disables server-side semi-synchronous replication.
+	ConfigurePrimary(ctx context.Context, waitForCount, timeoutSeconds int) error
 
 	// StopReplicaIOThread executes `STOP REPLICA IO_THREAD`.
 	StopReplicaIOThread(context.Context) error
@@ -58,9 +63,31 @@ type Operator interface {
 	// Otherwise, this stops the replication and makes the instance writable.
 	SetReadOnly(context.Context, bool) error
 
-	// KillConnections kills all connections except for ones from `localhost`
-	// and ones for MOCO.
-	KillConnections(context.Context) error
+	// KillConnections kills all connections except for ones from `localhost`,
+	// ones for MOCO, and ones of `opts.ProtectedUsers`.
+	// The connections of `opts.KillFirstUsers` are killed first.  If `opts.GracePeriod` is positive,
+	// this waits for the other sessions to become idle at most for the grace period before killing them.
+	KillConnections(ctx context.Context, opts KillOptions) error
+
+	// GetLongRunningActivities returns the transactions open for `threshold` or longer and
+	// the DDL statements running for `threshold` or longer, except for ones of MOCO.
+	GetLongRunningActivities(ctx context.Context, threshold time.Duration) ([]Activity, error)
+
+	// ResetReplication stops the replication, removes the replication configuration,
+	// and clears the binary logs and `gtid_executed` so that the instance can be re-cloned.
+	// All the data in the instance will be replaced by the next clone.
+	ResetReplication(context.Context) error
+
+	// StartGroupReplication configures the group communication of the instance
+	// and executes `START GROUP_REPLICATION`.
+	// If `config.Bootstrap` is true, the instance bootstraps a new group.
+	StartGroupReplication(ctx context.Context, config GroupReplicationConfig) error
+
+	// StopGroupReplication executes `STOP GROUP_REPLICATION`.
+	StopGroupReplication(context.Context) error
+
+	// SetGroupPrimary makes the member whose server_uuid is `uuid` the primary of the group.
+	SetGroupPrimary(ctx context.Context, uuid string) error
 }
 
 // OperatorFactory represents the factory for Operators.
@@ -108,22 +135,24 @@ func (f defaultFactory) New(ctx context.Context, cluster *mocov1beta2.MySQLClust
 	db.SetMaxIdleConns(1)
 	db.SetConnMaxIdleTime(30 * time.Second)
 	return &operator{
-		namespace: cluster.Namespace,
-		name:      cluster.PodName(index),
-		passwd:    pwd,
-		index:     index,
-		db:        db,
+		namespace:        cluster.Namespace,
+		name:             cluster.PodName(index),
+		passwd:           pwd,
+		index:            index,
+		db:               db,
+		groupReplication: cluster.IsGroupReplication(),
 	}, nil
 }
 
 func (defaultFactory) Cleanup() {}
 
 type operator struct {
-	namespace string
-	name      string
-	passwd    *password.MySQLPassword
-	index     int
-	db        *sqlx.DB
+	namespace        string
+	name             string
+	passwd           *password.MySQLPassword
+	index            int
+	db               *sqlx.DB
+	groupReplication bool
 }
 
 var _ Operator = &operator{}
@@ -5,9 +5,7 @@ import (
 	"fmt"
 )
 
-const semiSyncMasterTimeout = 24 * 60 * 60 * 1000
-
-func (o *operator) ConfigureReplica(ctx context.Context, primary AccessInfo, semisync bool) error {
+func (o *operator) ConfigureReplica(ctx context.Context, primary AccessInfo, semisync bool, delaySeconds int) error {
 	if _, err := o.db.ExecContext(ctx, `STOP REPLICA`); err != nil {
 		return fmt.Errorf("failed to stop replica: %w", err)
 	}
@@ -18,13 +16,17 @@ func (o *operator) ConfigureReplica(ctx context.Context, primary AccessInfo, sem
 	var cmd string
 	switch version {
 	case "8.4":
-		cmd = `CHANGE REPLICATION SOURCE TO SOURCE_HOST = :Host, SOURCE_PORT = :Port, SOURCE_USER = :User, SOURCE_PASSWORD = :Password, SOURCE_AUTO_POSITION = 1, GET_SOURCE_PUBLIC_KEY = 1`
+		cmd = `CHANGE REPLICATION SOURCE TO SOURCE_HOST = :Host, SOURCE_PORT = :Port, SOURCE_USER = :User, SOURCE_PASSWORD = :Password, SOURCE_AUTO_POSITION = 1, GET_SOURCE_PUBLIC_KEY = 1, SOURCE_DELAY = :Delay`
 	case "8.0":
-		cmd = `CHANGE MASTER TO MASTER_HOST = :Host, MASTER_PORT = :Port, MASTER_USER = :User, MASTER_PASSWORD = :Password, MASTER_AUTO_POSITION = 1, GET_MASTER_PUBLIC_KEY = 1`
+		cmd = `CHANGE MASTER TO MASTER_HOST = :Host, MASTER_PORT = :Port, MASTER_USER = :User, MASTER_PASSWORD = :Password, MASTER_AUTO_POSITION = 1, GET_MASTER_PUBLIC_KEY = 1, MASTER_DELAY = :Delay`
 	default:
 		return fmt.Errorf("unsupported version: %s", version)
 	}
-	if _, err := o.db.NamedExecContext(ctx, cmd, primary); err != nil {
+	params := struct {
+		AccessInfo
+		Delay int `db:"Delay"`
+	}{primary, delaySeconds}
+	if _, err := o.db.NamedExecContext(ctx, cmd, params); err != nil {
 		return fmt.Errorf("failed to change primary: %w", err)
 	}
 	if _, err := o.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_slave_enabled=?", semisync); err != nil {
@@ -39,8 +41,61 @@ func (o *operator) ConfigureReplica(ctx context.Context, primary AccessInfo, sem
 	return nil
 }
 
-func (o *operator) ConfigurePrimary(ctx context.Context, waitForCount int) error {
-	if _, err := o.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_timeout=?", semiSyncMasterTimeout); err != nil {
+func (o *operator) ResetReplication(ctx context.Context) (err error) {
+	if _, err := o.db.ExecContext(ctx, `STOP REPLICA`); err != nil {
+		return fmt.Errorf("failed to stop replica: %w", err)
+	}
+	if _, err := o.db.ExecContext(ctx, `RESET REPLICA ALL`); err != nil {
+		return fmt.Errorf("failed to reset replica: %w", err)
+	}
+	var version string
+	if err := o.db.GetContext(ctx, &version, `SELECT SUBSTRING_INDEX(VERSION(), '.', 2)`); err != nil {
+		return fmt.Errorf("failed to get version: %w", err)
+	}
+	var cmd string
+	switch version {
+	case "8.4":
+		cmd = `RESET BINARY LOGS AND GTIDS`
+	case "8.0":
+		cmd = `RESET MASTER`
+	default:
+		return fmt.Errorf("unsupported version: %s", version)
+	}
+
+	// super_read_only must be restored even if the reset fails or `ctx` is cancelled.
+	// Otherwise, the instance would accept writes.  A new context is used for this,
+	// but with a timeout not to block the caller forever on a hung connection.
+	defer func() {
+		rctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+		defer cancel()
+		if _, err2 := o.db.ExecContext(rctx, "SET GLOBAL super_read_only=1"); err2 != nil {
+			if err == nil {
+				err = fmt.Errorf("failed to set super_read_only=1: %w", err2)
+				return
+			}
+			err = fmt.Errorf("%w, and failed to set super_read_only=1: %v", err, err2)
+		}
+	}()
+
+	// super_read_only prevents resetting gtid_executed.
+	if _, err := o.db.ExecContext(ctx, "SET GLOBAL super_read_only=0"); err != nil {
+		return fmt.Errorf("failed to set super_read_only=0: %w", err)
+	}
+	if _, err := o.db.ExecContext(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to reset binary logs and GTIDs: %w", err)
+	}
+	return nil
+}
+
+func (o *operator) ConfigurePrimary(ctx context.Context, waitForCount, timeoutSeconds int) error {
+	if waitForCount == 0 {
+		if _, err := o.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_enabled=OFF"); err != nil {
+			return fmt.Errorf("failed to disable semi-sync primary: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := o.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_timeout=?", timeoutSeconds*1000); err != nil {
 		return fmt.Errorf("failed to set rpl_semi_sync_master_timeout count: %w", err)
 	}
 	if _, err := o.db.ExecContext(ctx, "SET GLOBAL rpl_semi_sync_master_wait_for_slave_count=?", waitForCount); err != nil {
@@ -62,7 +62,7 @@ var _ = Describe("replication", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, false)
+		}, false, 0)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(func() int {
 			var count int
@@ -107,7 +107,7 @@ var _ = Describe("replication", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, false)
+		}, false, 0)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(func() int {
 			var count int
@@ -156,7 +156,7 @@ var _ = Describe("replication", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, false)
+		}, false, 0)
 		Expect(err).NotTo(HaveOccurred())
 
 		err = ops[2].ConfigureReplica(ctx, AccessInfo{
@@ -164,7 +164,7 @@ var _ = Describe("replication", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, false)
+		}, false, 0)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(func() int {
 			var count int
@@ -184,7 +184,7 @@ var _ = Describe("replication", func() {
 		Expect(st2.ReplicaStatus.RetrievedGtidSet).NotTo(BeEmpty())
 		err = ops[2].WaitForGTID(ctx, st2.ReplicaStatus.RetrievedGtidSet, 10)
 		Expect(err).NotTo(HaveOccurred())
-		err = ops[2].ConfigurePrimary(ctx, 1)
+		err = ops[2].ConfigurePrimary(ctx, 1, 86400)
 		Expect(err).NotTo(HaveOccurred())
 		err = ops[2].SetReadOnly(ctx, false)
 		Expect(err).NotTo(HaveOccurred())
@@ -198,7 +198,7 @@ var _ = Describe("replication", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, true)
+		}, true, 0)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(func() int {
 			var count int
@@ -209,4 +209,38 @@ var _ = Describe("replication", func() {
 			return count
 		}).Should(Equal(7))
 	})
+
+	It("should restore super_read_only when resetting replication fails", func() {
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = "reset"
+		cluster.Spec.Replicas = 1
+
+		passwd, err := password.NewMySQLPassword()
+		Expect(err).NotTo(HaveOccurred())
+
+		op, err := factory.New(ctx, cluster, passwd, 0)
+		Expect(err).NotTo(HaveOccurred())
+		defer op.Close()
+		err = op.SetReadOnly(ctx, true)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("blocking the reset of binary logs by a backup lock")
+		conn, err := op.(*operator).db.Connx(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+		_, err = conn.ExecContext(ctx, `LOCK INSTANCE FOR BACKUP`)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.ExecContext(ctx, `UNLOCK INSTANCE`)
+
+		ctx2, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		err = op.ResetReplication(ctx2)
+		Expect(err).To(HaveOccurred())
+
+		By("checking super_read_only is restored")
+		st, err := op.GetStatus(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(st.GlobalVariables.SuperReadOnly).To(BeTrue())
+	})
 })
@@ -31,11 +31,20 @@ func (o *operator) GetStatus(ctx context.Context) (*MySQLInstanceStatus, error)
 		return nil, fmt.Errorf("failed to get replica hosts: pod=%s, namespace=%s: %w", o.name, o.namespace, err)
 	}
 
-	replicaStatus, err := o.getReplicaStatus(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get replica status: pod=%s, namespace=%s: %w", o.name, o.namespace, err)
+	if o.groupReplication {
+		members, err := o.getGroupMembers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group members: pod=%s, namespace=%s: %w", o.name, o.namespace, err)
+		}
+		status.GroupMembers = members
+	} else {
+		// SHOW REPLICA STATUS would report the channels of Group Replication, so skip it in that mode.
+		replicaStatus, err := o.getReplicaStatus(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replica status: pod=%s, namespace=%s: %w", o.name, o.namespace, err)
+		}
+		status.ReplicaStatus = replicaStatus
 	}
-	status.ReplicaStatus = replicaStatus
 
 	cloneStatus, err := o.getCloneStateStatus(ctx)
 	if err != nil {
@@ -71,12 +71,13 @@ var _ = Describe("status", func() {
 		Expect(status.GlobalStatus.SemiSyncMasterWaitSessions).To(Equal(0))
 
 		By("enabling semi-sync master")
-		err = ops[0].ConfigurePrimary(ctx, 1)
+		err = ops[0].ConfigurePrimary(ctx, 1, 86400)
 		Expect(err).NotTo(HaveOccurred())
 		status, err = ops[0].GetStatus(ctx)
 		Expect(err).NotTo(HaveOccurred())
 		Expect(status).NotTo(BeNil())
 		Expect(status.GlobalVariables.WaitForSlaveCount).To(Equal(1))
+		Expect(status.GlobalVariables.SemiSyncMasterTimeout).To(Equal(86400000))
 		Expect(status.GlobalVariables.SemiSyncMasterEnabled).To(BeTrue())
 		Expect(status.GlobalVariables.SemiSyncSlaveEnabled).To(BeFalse())
 		Expect(status.GlobalStatus.SemiSyncMasterWaitSessions).To(Equal(0))
@@ -87,7 +88,7 @@ var _ = Describe("status", func() {
 			Port:     3306,
 			User:     constants.ReplicationUser,
 			Password: passwd.Replicator(),
-		}, true)
+		}, true, 0)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(func() int {
 			var count int
@@ -41,16 +41,41 @@ var dynamicMycnf = map[string]string{
 	"super_read_only": "ON",
 }
 
+// TestGroupName is the name of the group that is used by mysqld for Group Replication tests.
+const TestGroupName = "2c5ad8a4-3b0b-4a2c-9e2b-6b8f0f2a7c11"
+
+var groupReplicationMycnf = map[string]string{
+	"plugin_load_add":                           "group_replication.so",
+	"group_replication_group_name":              TestGroupName,
+	"group_replication_start_on_boot":           "OFF",
+	"group_replication_single_primary_mode":     "ON",
+	"group_replication_recovery_get_public_key": "ON",
+}
+
 func RunMySQLOnDocker(name string, port, xport int) error {
+	return runMySQLOnDocker(name, port, xport, nil, nil)
+}
+
+// RunMySQLOnDockerForGroupReplication runs mysqld that can join the group named TestGroupName.
+// Members communicate with each other using the container names as the host names.
+func RunMySQLOnDockerForGroupReplication(name string, port, xport int) error {
+	return runMySQLOnDocker(name, port, xport, []string{"--hostname=" + name}, groupReplicationMycnf)
+}
+
+func runMySQLOnDocker(name string, port, xport int, dockerArgs []string, extraMycnf map[string]string) error {
 	args := []string{"run", "-d", "--rm", "--name=" + name, "--network=" + testMocoNetwork,
 		"-e", "MYSQL_ALLOW_EMPTY_PASSWORD=yes",
 		"-p", fmt.Sprintf("%d:3306", port),
 		"-p", fmt.Sprintf("%d:33060", xport),
-		testMySQLImage,
-		"--server_id=" + fmt.Sprint(port)}
+	}
+	args = append(args, dockerArgs...)
+	args = append(args, testMySQLImage, "--server_id="+fmt.Sprint(port))
 	for k, v := range startupMycnf {
 		args = append(args, fmt.Sprintf("--%s=%s", k, v))
 	}
+	for k, v := range extraMycnf {
+		args = append(args, fmt.Sprintf("--%s=%s", k, v))
+	}
 	out, err := exec.Command("docker", args...).CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("failed to run MySQL container: %w: %s", err, out)
@@ -160,7 +185,11 @@ func (f *testFactory) New(ctx context.Context, cluster *mocov1beta2.MySQLCluster
 			port := f.portBase
 			f.portBase += 2
 			name := testContainerName(cluster, i)
-			if err := RunMySQLOnDocker(name, port, port+1); err != nil {
+			run := RunMySQLOnDocker
+			if cluster.IsGroupReplication() {
+				run = RunMySQLOnDockerForGroupReplication
+			}
+			if err := run(name, port, port+1); err != nil {
 				return nil, err
 			}
 			instances[i] = port
@@ -195,11 +224,12 @@ func newTestOperator(cluster *mocov1beta2.MySQLCluster, pwd *password.MySQLPassw
 	udb.SetConnMaxIdleTime(30 * time.Second)
 
 	return &operator{
-		namespace: cluster.Namespace,
-		name:      cluster.PodName(index),
-		passwd:    pwd,
-		index:     index,
-		db:        udb,
+		namespace:        cluster.Namespace,
+		name:             cluster.PodName(index),
+		passwd:           pwd,
+		index:            index,
+		db:               udb,
+		groupReplication: cluster.IsGroupReplication(),
 	}, nil
 }
 
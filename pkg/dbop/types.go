@@ -2,6 +2,7 @@ package dbop
 
 import (
 	"database/sql"
+	"time"
 )
 
 type AccessInfo struct {
@@ -19,6 +20,7 @@ type MySQLInstanceStatus struct {
 	ReplicaHosts    []ReplicaHost
 	ReplicaStatus   *ReplicaStatus // may not be available
 	CloneStatus     *CloneStatus   // may not be available
+	GroupMembers    []GroupMember  // available only in Group Replication mode
 }
 
 var statusGlobalVars = []string{
@@ -29,6 +31,7 @@ var statusGlobalVars = []string{
 	"@@super_read_only",
 	"@@rpl_semi_sync_master_wait_for_slave_count",
 	"@@rpl_semi_sync_master_enabled",
+	"@@rpl_semi_sync_master_timeout",
 	"@@rpl_semi_sync_slave_enabled",
 }
 
@@ -41,6 +44,7 @@ type GlobalVariables struct {
 	SuperReadOnly         bool   `db:"@@super_read_only"`
 	WaitForSlaveCount     int    `db:"@@rpl_semi_sync_master_wait_for_slave_count"`
 	SemiSyncMasterEnabled bool   `db:"@@rpl_semi_sync_master_enabled"`
+	SemiSyncMasterTimeout int    `db:"@@rpl_semi_sync_master_timeout"`
 	SemiSyncSlaveEnabled  bool   `db:"@@rpl_semi_sync_slave_enabled"`
 }
 
@@ -141,7 +145,64 @@ type CloneStatus struct {
 
 // Process represents a process in `information_schema.PROCESSLIST` table.
 type Process struct {
-	ID   uint64 `db:"ID"`
-	User string `db:"USER"`
-	Host string `db:"HOST"`
+	ID            uint64 `db:"ID"`
+	User          string `db:"USER"`
+	Host          string `db:"HOST"`
+	Command       string `db:"COMMAND"`
+	InTransaction bool   `db:"IN_TRX"`
+}
+
+// idle returns true if the process is sleeping without an open transaction.
+func (p Process) idle() bool {
+	return p.Command == "Sleep" && !p.InTransaction
+}
+
+// KillOptions configures how KillConnections kills the client connections.
+type KillOptions struct {
+	// GracePeriod is the time to wait for the client sessions to become idle.
+	// If zero, the connections are killed immediately.
+	GracePeriod time.Duration
+
+	// ProtectedUsers is the list of users whose connections are never killed.
+	ProtectedUsers []string
+
+	// KillFirstUsers is the list of users whose connections are killed without waiting.
+	KillFirstUsers []string
+}
+
+// Activity is a long-running transaction or DDL statement of a client.
+type Activity struct {
+	ID      uint64 `db:"ID"`
+	User    string `db:"USER"`
+	Host    string `db:"HOST"`
+	Seconds int64  `db:"SECONDS"`
+	Query   string `db:"QUERY"`
+
+	// DDL is true if the activity is a running DDL statement rather than an open transaction.
+	DDL bool `db:"-"`
+}
+
+// GroupMember defines the columns from `performance_schema.replication_group_members`
+type GroupMember struct {
+	ID    string        `db:"MEMBER_ID"`
+	Host  string        `db:"MEMBER_HOST"`
+	Port  sql.NullInt64 `db:"MEMBER_PORT"`
+	State string        `db:"MEMBER_STATE"`
+	Role  string        `db:"MEMBER_ROLE"`
+}
+
+// GroupReplicationConfig is the configuration to start Group Replication.
+type GroupReplicationConfig struct {
+	// LocalAddress is the address that this instance listens on for the group communication.
+	LocalAddress string
+
+	// Seeds are the addresses of the members to contact when joining the group.
+	Seeds []string
+
+	// User and Password are the credentials for distributed recovery.
+	User     string
+	Password string
+
+	// Bootstrap should be true only for the first member of a new group.
+	Bootstrap bool
 }
@@ -62,16 +62,106 @@ var (
 		Reason:  "SwitchOverFailed",
 		Message: "The primary could not be changed: %v",
 	}
+	SwitchOverRejected = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SwitchOverRejected",
+		Message: "The switchover request was rejected: %v",
+	}
+	SwitchOverPostponed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SwitchOverPostponed",
+		Message: "The switchover is postponed because it is unsafe: %s",
+	}
+	SwitchOverUnsafe = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SwitchOverUnsafe",
+		Message: "The switchover is performed despite the risks: %s",
+	}
 	FailOverSucceeded = MOCOEvent{
 		Type:    corev1.EventTypeNormal,
 		Reason:  "FailOver",
 		Message: "The primary was changed to instance %d due to a failover",
 	}
+	FailOverPending = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "FailOverPending",
+		Message: "The primary is failed and a failover is waiting for approval: %s",
+	}
 	FailOverFailed = MOCOEvent{
 		Type:    corev1.EventTypeWarning,
 		Reason:  "FailOverFailed",
 		Message: "The primary could not be changed: %v",
 	}
+	RoleChangeHeld = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "RoleChangeHeld",
+		Message: "The role changes are held until an operator acknowledges: %s",
+	}
+	RoleChangeHoldAcknowledged = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "RoleChangeHoldAcknowledged",
+		Message: "The hold of the role changes was acknowledged",
+	}
+	Recovered = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "Recovered",
+		Message: "The primary was changed to instance %d by a recovery request; instances %v will be re-cloned",
+	}
+	RecoveryRejected = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "RecoveryRejected",
+		Message: "The recovery request was rejected: %v",
+	}
+	RecoveryFailed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "RecoveryFailed",
+		Message: "The cluster could not be recovered: %v",
+	}
+	SwitchoverHookSucceeded = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "SwitchoverHookSucceeded",
+		Message: "The %s-switchover hook %q succeeded",
+	}
+	SwitchoverHookFailed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SwitchoverHookFailed",
+		Message: "The %s-switchover hook %q failed (failurePolicy=%s): %v",
+	}
+	FenceSucceeded = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "Fenced",
+		Message: "The old primary instance %d was fenced by %s",
+	}
+	FenceFailed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "FenceFailed",
+		Message: "The old primary instance %d could not be fenced by %s: %v",
+	}
+	SplitBrainDetected = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SplitBrainDetected",
+		Message: "A split brain is detected: %s",
+	}
+	SplitBrainFenced = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "SplitBrainFenced",
+		Message: "Instance %d was made super_read_only to resolve the split brain",
+	}
+	SplitBrainFenceFailed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "SplitBrainFenceFailed",
+		Message: "Instance %d could not be made super_read_only: %v",
+	}
+	StateChanged = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "StateChanged",
+		Message: "The cluster state changed from %s to %s",
+	}
+	StateChangedWarning = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "StateChanged",
+		Message: "The cluster state changed from %s to %s",
+	}
 	CloneSucceeded = MOCOEvent{
 		Type:    corev1.EventTypeNormal,
 		Reason:  "Cloned",
@@ -82,6 +172,41 @@ var (
 		Reason:  "CloneFailed",
 		Message: "Clone from the primary failed for instance %d: %v",
 	}
+	ErrantReplicaReset = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "ErrantReplicaReset",
+		Message: "Instance %d was reset to be re-cloned, discarding errant transactions: %s",
+	}
+	PromotionSucceeded = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "Promoted",
+		Message: "The cluster was detached from the replication source and became writable",
+	}
+	PromotionFailed = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "PromotionFailed",
+		Message: "The promotion could not proceed: %v",
+	}
+	PromotionRejected = MOCOEvent{
+		Type:    corev1.EventTypeWarning,
+		Reason:  "PromotionRejected",
+		Message: "The promotion request was rejected: %v",
+	}
+	PromotionCancelled = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "PromotionCancelled",
+		Message: "The promotion in phase %s was cancelled",
+	}
+	GroupBootstrapped = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "GroupBootstrapped",
+		Message: "Group Replication was bootstrapped by instance %d",
+	}
+	GroupMemberJoined = MOCOEvent{
+		Type:    corev1.EventTypeNormal,
+		Reason:  "GroupMemberJoined",
+		Message: "Instance %d started to join the group",
+	}
 	SetWritable = MOCOEvent{
 		Type:    corev1.EventTypeNormal,
 		Reason:  "Writable",
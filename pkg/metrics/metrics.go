@@ -8,6 +8,7 @@ const (
 	metricsNamespace    = "moco"
 	clusteringSubsystem = "cluster"
 	backupSubsystem     = "backup"
+	shardSubsystem      = "shard"
 )
 
 // Clustering related metrics
@@ -16,12 +17,29 @@ var (
 	ErrorCountVec      *prometheus.CounterVec
 	AvailableVec       *prometheus.GaugeVec
 	HealthyVec         *prometheus.GaugeVec
+	StateVec           *prometheus.GaugeVec
 	SwitchoverCountVec *prometheus.CounterVec
 	FailoverCountVec   *prometheus.CounterVec
 	TotalReplicasVec   *prometheus.GaugeVec
 	ReadyReplicasVec   *prometheus.GaugeVec
 	ErrantReplicasVec  *prometheus.GaugeVec
+	SplitBrainVec      *prometheus.GaugeVec
+	RoleChangesVec     *prometheus.GaugeVec
+	RoleChangeHeldVec  *prometheus.GaugeVec
+	RoleChangeHoldsVec *prometheus.CounterVec
 	ProcessingTimeVec  *prometheus.HistogramVec
+	CheckIntervalVec   *prometheus.GaugeVec
+
+	SwitchoverDurationVec *prometheus.HistogramVec
+	FailoverDurationVec   *prometheus.HistogramVec
+
+	ReplicationLagVec       *prometheus.GaugeVec
+	ReplicaIORunningVec     *prometheus.GaugeVec
+	ReplicaSQLRunningVec    *prometheus.GaugeVec
+	ReplicaLastIOErrnoVec   *prometheus.GaugeVec
+	ReplicaLastSQLErrnoVec  *prometheus.GaugeVec
+	SemiSyncWaitSessionsVec *prometheus.GaugeVec
+	MissingGTIDsVec         *prometheus.GaugeVec
 
 	VolumeResizedTotal            *prometheus.CounterVec
 	VolumeResizedErrorTotal       *prometheus.CounterVec
@@ -47,6 +65,12 @@ var (
 	BackupWarnings     *prometheus.GaugeVec
 )
 
+// Sharding related metrics
+var (
+	ShardOwnedVec *prometheus.GaugeVec
+	ShardMembers  prometheus.Gauge
+)
+
 // Register registers Prometheus metrics vectors to the registry.
 func Register(registry prometheus.Registerer) {
 	CheckCountVec = prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -81,6 +105,14 @@ func Register(registry prometheus.Registerer) {
 	}, []string{"name", "namespace"})
 	registry.MustRegister(HealthyVec)
 
+	StateVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "state",
+		Help:      "The cluster state decided by MOCO",
+	}, []string{"name", "namespace", "state"})
+	registry.MustRegister(StateVec)
+
 	SwitchoverCountVec = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Namespace: metricsNamespace,
 		Subsystem: clusteringSubsystem,
@@ -121,6 +153,38 @@ func Register(registry prometheus.Registerer) {
 	}, []string{"name", "namespace"})
 	registry.MustRegister(ErrantReplicasVec)
 
+	SplitBrainVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "split_brain",
+		Help:      "1 if a split brain is detected in the cluster, 0 otherwise",
+	}, []string{"name", "namespace"})
+	registry.MustRegister(SplitBrainVec)
+
+	RoleChangesVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "role_changes",
+		Help:      "The number of automatic switchovers and failovers counted for the role change limit",
+	}, []string{"name", "namespace"})
+	registry.MustRegister(RoleChangesVec)
+
+	RoleChangeHeldVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "role_change_held",
+		Help:      "1 if the role changes are held by the role change limit, 0 otherwise",
+	}, []string{"name", "namespace"})
+	registry.MustRegister(RoleChangeHeldVec)
+
+	RoleChangeHoldsVec = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "role_change_holds_total",
+		Help:      "The number of times the role changes were held by the role change limit",
+	}, []string{"name", "namespace"})
+	registry.MustRegister(RoleChangeHoldsVec)
+
 	ProcessingTimeVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Namespace: metricsNamespace,
 		Subsystem: clusteringSubsystem,
@@ -130,6 +194,88 @@ func Register(registry prometheus.Registerer) {
 	}, []string{"name", "namespace"})
 	registry.MustRegister(ProcessingTimeVec)
 
+	CheckIntervalVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "check_interval_seconds",
+		Help:      "The current interval in seconds between the checks of the cluster",
+	}, []string{"name", "namespace"})
+	registry.MustRegister(CheckIntervalVec)
+
+	SwitchoverDurationVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "switchover_duration_seconds",
+		Help:      "The length of time in seconds taken by a successful switchover",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"name", "namespace"})
+	registry.MustRegister(SwitchoverDurationVec)
+
+	FailoverDurationVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "failover_duration_seconds",
+		Help:      "The length of time in seconds taken by a successful failover",
+		Buckets:   []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"name", "namespace"})
+	registry.MustRegister(FailoverDurationVec)
+
+	ReplicationLagVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "replication_lag_seconds",
+		Help:      "The value of Seconds_Behind_Source of the instance",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(ReplicationLagVec)
+
+	ReplicaIORunningVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "replica_io_running",
+		Help:      "1 if the replication I/O thread of the instance is running, 0 otherwise",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(ReplicaIORunningVec)
+
+	ReplicaSQLRunningVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "replica_sql_running",
+		Help:      "1 if the replication SQL thread of the instance is running, 0 otherwise",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(ReplicaSQLRunningVec)
+
+	ReplicaLastIOErrnoVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "replica_last_io_errno",
+		Help:      "The value of Last_IO_Errno of the instance",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(ReplicaLastIOErrnoVec)
+
+	ReplicaLastSQLErrnoVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "replica_last_sql_errno",
+		Help:      "The value of Last_SQL_Errno of the instance",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(ReplicaLastSQLErrnoVec)
+
+	SemiSyncWaitSessionsVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "semisync_wait_sessions",
+		Help:      "The value of Rpl_semi_sync_master_wait_sessions of the instance",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(SemiSyncWaitSessionsVec)
+
+	MissingGTIDsVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: clusteringSubsystem,
+		Name:      "missing_gtids",
+		Help:      "The number of transactions executed on the primary but not on the instance",
+	}, []string{"name", "namespace", "instance"})
+	registry.MustRegister(MissingGTIDsVec)
+
 	BackupTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: metricsNamespace,
 		Subsystem: backupSubsystem,
@@ -257,4 +403,20 @@ func Register(registry prometheus.Registerer) {
 		Help:      "The number of retries for partition updates",
 	}, []string{"namespace"})
 	registry.MustRegister(PartitionUpdateRetriesTotalVec)
+
+	ShardOwnedVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: shardSubsystem,
+		Name:      "owned",
+		Help:      "1 if the shard is owned by this controller, 0 otherwise",
+	}, []string{"shard"})
+	registry.MustRegister(ShardOwnedVec)
+
+	ShardMembers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: shardSubsystem,
+		Name:      "members",
+		Help:      "The number of live controllers sharing the shards",
+	})
+	registry.MustRegister(ShardMembers)
 }
@@ -144,6 +144,27 @@ var ConstMycnf = map[string]map[string]string{
 	},
 }
 
+// DefaultGroupReplicationMycnf is the default options of mysqld in GroupReplication mode.
+// These can be overridden by users.
+var DefaultGroupReplicationMycnf = map[string]string{
+	// The members connect to each other via Pod IP addresses in private networks.
+	"group_replication_ip_allowlist": "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7,127.0.0.1/8,::1/128",
+
+	// A new primary does not serve transactions until it applies the backlog.
+	// This is the default since MySQL 8.4.
+	"group_replication_consistency": "BEFORE_ON_PRIMARY_FAILOVER",
+}
+
+// constGroupReplicationMycnf is the mysqld configurations that MOCO applies forcibly in GroupReplication mode.
+// MOCO starts Group Replication by itself, so it must not be started automatically.
+var constGroupReplicationMycnf = map[string]string{
+	"plugin_load_add":                           "group_replication.so",
+	"group_replication_start_on_boot":           "OFF",
+	"group_replication_bootstrap_group":         "OFF",
+	"group_replication_single_primary_mode":     "ON",
+	"group_replication_recovery_get_public_key": "ON",
+}
+
 func calcBufferSize(total int64) int64 {
 	m := total / 100 * InnoDBBufferPoolRatioPercent >> 20 << 20
 	if m < 128<<20 {
@@ -158,8 +179,27 @@ func calcBufferSize(total int64) int64 {
 // If `userConf` does not specify `innodb_buffer_pool_size`, this
 // will automatically set it to 70% of `memTotal`.
 func Generate(userConf map[string]string, memTotal int64) string {
+	return generate(DefaultMycnf, userConf, memTotal, nil)
+}
+
+// GenerateForGroupReplication generates my.cnf contents for the members of
+// the group of Group Replication named `groupName`, which must be a UUID.
+//
+// `report_host` in `userConf` is ignored because it must be the FQDN of each Pod,
+// which is the same as the host in the group seeds.  It is given to mysqld as
+// an argument because my.cnf is shared by all the members.
+func GenerateForGroupReplication(userConf map[string]string, memTotal int64, groupName string) string {
+	constConf := mergeSection(constGroupReplicationMycnf, map[string]string{
+		"group_replication_group_name": groupName,
+	})
+	userConf = mergeSection(userConf, nil)
+	delete(userConf, "report_host")
+	return generate(mergeSection(DefaultMycnf, DefaultGroupReplicationMycnf), userConf, memTotal, constConf)
+}
+
+func generate(defaultConf, userConf map[string]string, memTotal int64, constConf map[string]string) string {
 	opaque := userConf[opaqueKey]
-	mysqldConf := mergeSection(DefaultMycnf, userConf)
+	mysqldConf := mergeSection(defaultConf, userConf)
 	if _, ok := mysqldConf["innodb_buffer_pool_size"]; !ok {
 		mysqldConf["innodb_buffer_pool_size"] = fmt.Sprint(calcBufferSize(memTotal))
 	}
@@ -171,7 +211,7 @@ func Generate(userConf map[string]string, memTotal int64) string {
 	delete(mysqldConf, "disable_log_bin")
 
 	conf := make(map[string]map[string]string)
-	conf["mysqld"] = mysqldConf
+	conf["mysqld"] = mergeSection(mysqldConf, constConf)
 
 	for sec, secConf := range ConstMycnf {
 		conf[sec] = mergeSection(conf[sec], secConf)
@@ -14,6 +14,7 @@ func TestGenerator(t *testing.T) {
 	t.Run("buffer-pool-size", testBufferPoolSize)
 	t.Run("opaque", testOpaque)
 	t.Run("disable-user-params", testDisableUserParams)
+	t.Run("group-replication", testGroupReplication)
 }
 
 //go:embed testdata/nil.cnf
@@ -91,3 +92,17 @@ func testDisableUserParams(t *testing.T) {
 		t.Error("not matched", cmp.Diff(nilCnf, actual))
 	}
 }
+
+//go:embed testdata/group_replication.cnf
+var groupReplicationCnf string
+
+func testGroupReplication(t *testing.T) {
+	actual := GenerateForGroupReplication(map[string]string{
+		"group_replication_consistency":   "EVENTUAL",
+		"group_replication_start_on_boot": "ON",
+		"report_host":                     "mysql.example.com",
+	}, 100<<20, "0b8b7a5e-2cbb-4a4c-9b7e-1b2f9d6c8a10")
+	if !cmp.Equal(groupReplicationCnf, actual) {
+		t.Error("not matched", cmp.Diff(groupReplicationCnf, actual))
+	}
+}
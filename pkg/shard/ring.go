@@ -0,0 +1,48 @@
+package shard
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ShardOf returns the shard of the MySQLCluster among `shards` shards.
+func ShardOf(name types.NamespacedName, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(name.Namespace))
+	h.Write([]byte{'/'})
+	h.Write([]byte(name.Name))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// ownerOf returns the member that should own the shard.
+//
+// This uses rendezvous hashing so that only the shards of the joined or left member
+// move when the membership changes.
+func ownerOf(shard int, members []string) string {
+	var owner string
+	var max uint64
+	for _, m := range members {
+		h := fnv.New64a()
+		h.Write([]byte(m))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.Itoa(shard)))
+		score := mix(h.Sum64())
+		if owner == "" || score > max || (score == max && m < owner) {
+			owner = m
+			max = score
+		}
+	}
+	return owner
+}
+
+// mix scrambles the bits of FNV hash values, whose higher bits are biased for short keys.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
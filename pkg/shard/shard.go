@@ -0,0 +1,508 @@
+// Package shard distributes MySQLClusters across replicas of moco-controller.
+//
+// MySQLClusters are divided into a fixed number of shards by the hash of their names.
+// Each shard is protected by a Lease, and the replicas take the shards assigned to them
+// by rendezvous hashing over the live replicas.  The live replicas are known from
+// the member Leases that each replica keeps renewing.
+package shard
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/constants"
+	"github.com/cybozu-go/moco/pkg/metrics"
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// DefaultLeaseDuration is the default duration of the Leases.
+	DefaultLeaseDuration = 15 * time.Second
+
+	// DefaultRenewInterval is the default interval to renew the Leases.
+	DefaultRenewInterval = 5 * time.Second
+
+	releaseTimeout = 30 * time.Second
+)
+
+// Options is the set of parameters for Sharder.
+type Options struct {
+	// Namespace is the namespace of the Leases.
+	Namespace string
+
+	// Name is the prefix of the Lease names.
+	Name string
+
+	// Identity is the unique name of this controller.
+	Identity string
+
+	// Shards is the number of shards.
+	Shards int
+
+	// LeaseDuration is the duration of the Leases.  The default is DefaultLeaseDuration.
+	LeaseDuration time.Duration
+
+	// RenewInterval is the interval to renew the Leases.  The default is DefaultRenewInterval.
+	RenewInterval time.Duration
+}
+
+// Sharder decides the shards that this controller owns.
+//
+// Sharder implements manager.Runnable.  It runs on every replica regardless of
+// leader election.
+type Sharder struct {
+	client client.Client
+	reader client.Reader
+	opts   Options
+	log    logr.Logger
+	now    func() time.Time
+
+	events    chan event.GenericEvent
+	onRelease []func()
+
+	mu        sync.RWMutex
+	owned     []bool
+	releasing []bool
+	lastRenew []time.Time
+
+	// wg tracks the goroutines that notify the release of shards and
+	// that send the clusters of acquired shards.
+	wg sync.WaitGroup
+}
+
+var _ manager.Runnable = &Sharder{}
+var _ manager.LeaderElectionRunnable = &Sharder{}
+
+// New creates a Sharder.
+//
+// `c` is used to write Leases and to list MySQLClusters.  `r` is used to read Leases
+// so that the Leases are not cached.
+func New(c client.Client, r client.Reader, opts Options, log logr.Logger) *Sharder {
+	if opts.LeaseDuration == 0 {
+		opts.LeaseDuration = DefaultLeaseDuration
+	}
+	if opts.RenewInterval == 0 {
+		opts.RenewInterval = DefaultRenewInterval
+	}
+	return &Sharder{
+		client:    c,
+		reader:    r,
+		opts:      opts,
+		log:       log,
+		now:       time.Now,
+		events:    make(chan event.GenericEvent, 100),
+		owned:     make([]bool, opts.Shards),
+		releasing: make([]bool, opts.Shards),
+		lastRenew: make([]time.Time, opts.Shards),
+	}
+}
+
+// Owns returns true if this controller owns the MySQLCluster.
+func (s *Sharder) Owns(name types.NamespacedName) bool {
+	shard := ShardOf(name, s.opts.Shards)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.owned[shard]
+}
+
+// Events returns the channel to receive MySQLClusters in the shards newly owned by this controller.
+func (s *Sharder) Events() <-chan event.GenericEvent {
+	return s.events
+}
+
+// OnRelease registers a function that is called when this controller gives up shards.
+// The function is called after Owns starts returning false for the clusters in the shards
+// and before the shards are handed over to other controllers, so it should stop managing
+// those clusters before returning.  The function is called in a goroutine other than
+// the one renewing the leases, and may be called concurrently.
+//
+// This must be called before Start.
+func (s *Sharder) OnRelease(f func()) {
+	s.onRelease = append(s.onRelease, f)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable.
+func (s *Sharder) NeedLeaderElection() bool {
+	return false
+}
+
+// Start implements manager.Runnable.
+func (s *Sharder) Start(ctx context.Context) error {
+	tick := time.NewTicker(s.opts.RenewInterval)
+	defer tick.Stop()
+
+	for {
+		s.sync(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.shutdown()
+			return nil
+		case <-tick.C:
+		}
+	}
+}
+
+func (s *Sharder) memberLeaseName() string {
+	return fmt.Sprintf("%s-member-%s", s.opts.Name, s.opts.Identity)
+}
+
+func (s *Sharder) shardLeaseName(shard int) string {
+	return fmt.Sprintf("%s-shard-%d", s.opts.Name, shard)
+}
+
+func (s *Sharder) sync(ctx context.Context) {
+	now := s.now()
+
+	if err := s.renewMember(ctx, now); err != nil {
+		s.log.Error(err, "failed to renew the member lease")
+	}
+
+	members, err := s.members(ctx, now)
+	if err != nil {
+		s.log.Error(err, "failed to list the members")
+		s.expire(now)
+		return
+	}
+	metrics.ShardMembers.Set(float64(len(members)))
+
+	for shard := 0; shard < s.opts.Shards; shard++ {
+		if s.isReleasing(shard) {
+			// keep the lease until the clusters in the shard are no longer managed.
+			if err := s.renewLease(ctx, shard, now); err != nil {
+				s.log.Error(err, "failed to renew the lease of the shard being handed over", "shard", shard)
+			}
+			continue
+		}
+
+		owned := s.isOwned(shard)
+		if ownerOf(shard, members) != s.opts.Identity {
+			if owned {
+				s.release(shard)
+			}
+			continue
+		}
+
+		ok, err := s.tryLease(ctx, shard, now)
+		if err != nil {
+			s.log.Error(err, "failed to acquire or renew the shard lease", "shard", shard)
+		}
+		switch {
+		case ok && !owned:
+			s.acquire(ctx, shard, now)
+		case ok:
+			s.setOwned(shard, true, now)
+		case owned && err == nil:
+			s.log.Info("lost the shard because another controller took it", "shard", shard)
+			s.setOwned(shard, false, now)
+			s.wg.Go(s.notifyRelease)
+		}
+	}
+	s.expire(now)
+}
+
+func (s *Sharder) isOwned(shard int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.owned[shard]
+}
+
+func (s *Sharder) isReleasing(shard int) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.releasing[shard]
+}
+
+func (s *Sharder) setReleasing(shard int, releasing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releasing[shard] = releasing
+}
+
+func (s *Sharder) setOwned(shard int, owned bool, now time.Time) {
+	s.mu.Lock()
+	s.owned[shard] = owned
+	if owned {
+		s.lastRenew[shard] = now
+	}
+	s.mu.Unlock()
+
+	if owned {
+		metrics.ShardOwnedVec.WithLabelValues(strconv.Itoa(shard)).Set(1)
+	} else {
+		metrics.ShardOwnedVec.WithLabelValues(strconv.Itoa(shard)).Set(0)
+	}
+}
+
+// expire gives up the shards whose leases have not been renewed for a while.
+// The leases are not released because this controller cannot update them.
+func (s *Sharder) expire(now time.Time) {
+	deadline := s.opts.LeaseDuration * 2 / 3
+
+	var expired []int
+	s.mu.RLock()
+	for shard, owned := range s.owned {
+		if owned && now.Sub(s.lastRenew[shard]) > deadline {
+			expired = append(expired, shard)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	for _, shard := range expired {
+		s.log.Info("lost the shard because the lease could not be renewed", "shard", shard)
+		s.setOwned(shard, false, now)
+	}
+	s.wg.Go(s.notifyRelease)
+}
+
+// acquire starts managing the clusters in the shard.
+//
+// The clusters are sent to the events channel in a goroutine because the channel
+// may be full until the controller starts.  `sync` must not wait for it, or the leases
+// would not be renewed.
+func (s *Sharder) acquire(ctx context.Context, shard int, now time.Time) {
+	s.log.Info("acquired the shard", "shard", shard)
+	s.setOwned(shard, true, now)
+	s.wg.Go(func() {
+		s.enqueue(ctx, shard)
+	})
+}
+
+// enqueue sends the clusters in the shard to the events channel.
+func (s *Sharder) enqueue(ctx context.Context, shard int) {
+	clusters := &mocov1beta2.MySQLClusterList{}
+	if err := s.client.List(ctx, clusters); err != nil {
+		s.log.Error(err, "failed to list MySQLClusters", "shard", shard)
+		return
+	}
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		if ShardOf(client.ObjectKeyFromObject(cluster), s.opts.Shards) != shard {
+			continue
+		}
+		select {
+		case s.events <- event.GenericEvent{Object: cluster}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// release hands over the shard to another controller.
+//
+// Stopping the management of the clusters in the shard may take a while, so it is
+// done in a goroutine.  The lease is kept renewed by `sync` until it is done,
+// and then released so that another controller does not manage the clusters at the same time.
+func (s *Sharder) release(shard int) {
+	s.log.Info("handing over the shard", "shard", shard)
+	s.setOwned(shard, false, s.now())
+	s.setReleasing(shard, true)
+
+	s.wg.Go(func() {
+		defer s.setReleasing(shard, false)
+		s.notifyRelease()
+
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+		if err := s.releaseLease(ctx, s.shardLeaseName(shard)); err != nil {
+			s.log.Error(err, "failed to release the shard lease", "shard", shard)
+			return
+		}
+		s.log.Info("handed over the shard", "shard", shard)
+	})
+}
+
+func (s *Sharder) notifyRelease() {
+	for _, f := range s.onRelease {
+		f()
+	}
+}
+
+// shutdown releases all the leases held by this controller.
+func (s *Sharder) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+
+	for shard := 0; shard < s.opts.Shards; shard++ {
+		if s.isOwned(shard) {
+			s.release(shard)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.log.Info("gave up waiting for the shards to be handed over")
+	}
+
+	lease := &coordinationv1.Lease{}
+	lease.Namespace = s.opts.Namespace
+	lease.Name = s.memberLeaseName()
+	if err := s.client.Delete(ctx, lease); err != nil && !apierrors.IsNotFound(err) {
+		s.log.Error(err, "failed to delete the member lease")
+	}
+}
+
+func (s *Sharder) renewMember(ctx context.Context, now time.Time) error {
+	lease := &coordinationv1.Lease{}
+	err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.opts.Namespace, Name: s.memberLeaseName()}, lease)
+	if apierrors.IsNotFound(err) {
+		lease = s.newLease(s.memberLeaseName(), now)
+		lease.Labels = map[string]string{constants.LabelShardGroup: s.opts.Name}
+		return s.client.Create(ctx, lease)
+	}
+	if err != nil {
+		return err
+	}
+
+	lease.Spec.HolderIdentity = &s.opts.Identity
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(s.opts.LeaseDuration.Seconds()))
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	return s.client.Update(ctx, lease)
+}
+
+// members returns the identities of the live controllers including this controller.
+func (s *Sharder) members(ctx context.Context, now time.Time) ([]string, error) {
+	leases := &coordinationv1.LeaseList{}
+	if err := s.reader.List(ctx, leases, client.InNamespace(s.opts.Namespace), client.MatchingLabels{constants.LabelShardGroup: s.opts.Name}); err != nil {
+		return nil, err
+	}
+
+	members := []string{s.opts.Identity}
+	for i := range leases.Items {
+		lease := &leases.Items[i]
+		if expired(lease, now) {
+			continue
+		}
+		holder := *lease.Spec.HolderIdentity
+		if holder != s.opts.Identity {
+			members = append(members, holder)
+		}
+	}
+	return members, nil
+}
+
+// tryLease acquires or renews the lease of the shard.
+// It returns false if the lease is held by another controller.
+func (s *Sharder) tryLease(ctx context.Context, shard int, now time.Time) (bool, error) {
+	name := s.shardLeaseName(shard)
+	lease := &coordinationv1.Lease{}
+	err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.opts.Namespace, Name: name}, lease)
+	if apierrors.IsNotFound(err) {
+		lease = s.newLease(name, now)
+		if err := s.client.Create(ctx, lease); err != nil {
+			if apierrors.IsAlreadyExists(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	held := lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == s.opts.Identity
+	if !held {
+		if !expired(lease, now) {
+			return false, nil
+		}
+		var transitions int32
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions
+		}
+		lease.Spec.HolderIdentity = &s.opts.Identity
+		lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+		lease.Spec.LeaseTransitions = ptr.To(transitions + 1)
+	}
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(s.opts.LeaseDuration.Seconds()))
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	if err := s.client.Update(ctx, lease); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// renewLease renews the lease of the shard only if this controller holds it.
+// Unlike tryLease, this never acquires the lease.
+func (s *Sharder) renewLease(ctx context.Context, shard int, now time.Time) error {
+	lease := &coordinationv1.Lease{}
+	if err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.opts.Namespace, Name: s.shardLeaseName(shard)}, lease); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != s.opts.Identity {
+		return nil
+	}
+
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	if err := s.client.Update(ctx, lease); err != nil && !apierrors.IsConflict(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Sharder) releaseLease(ctx context.Context, name string) error {
+	// the lease may be renewed by `sync` concurrently.
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease := &coordinationv1.Lease{}
+		if err := s.reader.Get(ctx, client.ObjectKey{Namespace: s.opts.Namespace, Name: name}, lease); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != s.opts.Identity {
+			return nil
+		}
+
+		lease.Spec.HolderIdentity = nil
+		lease.Spec.AcquireTime = nil
+		lease.Spec.RenewTime = nil
+		return s.client.Update(ctx, lease)
+	})
+}
+
+func (s *Sharder) newLease(name string, now time.Time) *coordinationv1.Lease {
+	lease := &coordinationv1.Lease{}
+	lease.Namespace = s.opts.Namespace
+	lease.Name = name
+	lease.Spec.HolderIdentity = &s.opts.Identity
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(s.opts.LeaseDuration.Seconds()))
+	lease.Spec.AcquireTime = &metav1.MicroTime{Time: now}
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: now}
+	lease.Spec.LeaseTransitions = ptr.To[int32](0)
+	return lease
+}
+
+// expired returns true if the lease is not held by anyone.
+func expired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return true
+	}
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	return !now.Before(lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second))
+}
@@ -0,0 +1,288 @@
+package shard
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mocov1beta2 "github.com/cybozu-go/moco/api/v1beta2"
+	"github.com/cybozu-go/moco/pkg/metrics"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const testShards = 16
+
+func init() {
+	metrics.Register(prometheus.NewRegistry())
+}
+
+func TestShardOf(t *testing.T) {
+	counts := make([]int, testShards)
+	for i := 0; i < 1000; i++ {
+		name := types.NamespacedName{Namespace: fmt.Sprintf("ns%d", i%7), Name: fmt.Sprintf("cluster%d", i)}
+		shard := ShardOf(name, testShards)
+		if shard < 0 || shard >= testShards {
+			t.Fatalf("shard out of range: %d", shard)
+		}
+		if ShardOf(name, testShards) != shard {
+			t.Fatalf("shard is not stable for %s", name)
+		}
+		counts[shard]++
+	}
+	for shard, n := range counts {
+		if n == 0 {
+			t.Errorf("no cluster in shard %d", shard)
+		}
+	}
+}
+
+func TestOwnerOf(t *testing.T) {
+	members := []string{"a", "b", "c"}
+	owners := make(map[int]string)
+	counts := make(map[string]int)
+	for shard := 0; shard < 64; shard++ {
+		owner := ownerOf(shard, members)
+		if owner != ownerOf(shard, []string{"c", "a", "b"}) {
+			t.Errorf("owner depends on the order of the members")
+		}
+		owners[shard] = owner
+		counts[owner]++
+	}
+	for _, m := range members {
+		if counts[m] == 0 {
+			t.Errorf("member %s owns no shard", m)
+		}
+	}
+
+	// only the shards of the left member should move
+	for shard := 0; shard < 64; shard++ {
+		owner := ownerOf(shard, []string{"a", "c"})
+		if owners[shard] != "b" && owner != owners[shard] {
+			t.Errorf("shard %d moved from %s to %s", shard, owners[shard], owner)
+		}
+	}
+
+	if ownerOf(0, nil) != "" {
+		t.Error("owner should be empty without members")
+	}
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func newTestSharder(c client.Client, clock *testClock, identity string) (*Sharder, *atomic.Int32) {
+	s := New(c, c, Options{
+		Namespace: "moco-system",
+		Name:      "moco",
+		Identity:  identity,
+		Shards:    testShards,
+	}, logr.Discard())
+	s.now = clock.Now
+
+	released := new(atomic.Int32)
+	s.OnRelease(func() { released.Add(1) })
+	return s, released
+}
+
+func ownedShards(s *Sharder) map[int]bool {
+	owned := make(map[int]bool)
+	for shard := 0; shard < testShards; shard++ {
+		if s.isOwned(shard) {
+			owned[shard] = true
+		}
+	}
+	return owned
+}
+
+func TestSharder(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := mocov1beta2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	var objs []client.Object
+	for i := 0; i < 20; i++ {
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = fmt.Sprintf("cluster%d", i)
+		objs = append(objs, cluster)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s1, released1 := newTestSharder(c, clock, "moco-1")
+	s2, _ := newTestSharder(c, clock, "moco-2")
+
+	s1.sync(ctx)
+	if n := len(ownedShards(s1)); n != testShards {
+		t.Fatalf("moco-1 should own all the shards: %d", n)
+	}
+	s1.wg.Wait()
+	if n := len(s1.events); n != len(objs) {
+		t.Errorf("wrong number of events %d: expected=%d", n, len(objs))
+	}
+
+	// moco-2 joins, but cannot take the shards until moco-1 hands them over.
+	clock.now = clock.now.Add(time.Second)
+	s2.sync(ctx)
+	if n := len(ownedShards(s2)); n != 0 {
+		t.Errorf("moco-2 should not own shards held by moco-1: %d", n)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	s1.sync(ctx)
+	s1.wg.Wait()
+	if released1.Load() == 0 {
+		t.Error("moco-1 should release shards")
+	}
+	s2.sync(ctx)
+
+	owned1 := ownedShards(s1)
+	owned2 := ownedShards(s2)
+	if len(owned1) == 0 || len(owned2) == 0 || len(owned1)+len(owned2) != testShards {
+		t.Fatalf("shards are not distributed: moco-1=%v, moco-2=%v", owned1, owned2)
+	}
+	for shard := range owned2 {
+		if owned1[shard] {
+			t.Errorf("shard %d is owned by both", shard)
+		}
+	}
+	for i := range objs {
+		name := client.ObjectKeyFromObject(objs[i])
+		if s1.Owns(name) == s2.Owns(name) {
+			t.Errorf("%s should be owned by exactly one controller", name)
+		}
+	}
+
+	// moco-2 dies without releasing the leases.
+	clock.now = clock.now.Add(DefaultLeaseDuration + time.Second)
+	s1.sync(ctx)
+	if n := len(ownedShards(s1)); n != testShards {
+		t.Errorf("moco-1 should take over all the shards: %d", n)
+	}
+
+	// moco-2 comes back after the shards were taken.
+	s2.sync(ctx)
+	if n := len(ownedShards(s2)); n != 0 {
+		t.Errorf("moco-2 should give up the shards taken by moco-1: %d", n)
+	}
+
+	// moco-1 shuts down and releases all the leases.
+	s1.shutdown()
+	if n := len(ownedShards(s1)); n != 0 {
+		t.Errorf("moco-1 should not own shards after shutdown: %d", n)
+	}
+	clock.now = clock.now.Add(time.Second)
+	s2.sync(ctx)
+	if n := len(ownedShards(s2)); n != testShards {
+		t.Errorf("moco-2 should take all the shards released by moco-1: %d", n)
+	}
+}
+
+func TestSharderKeepsLeaseWhileReleasing(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := mocov1beta2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s1, _ := newTestSharder(c, clock, "moco-1")
+	s2, _ := newTestSharder(c, clock, "moco-2")
+
+	// the clusters in the released shards take a long time to stop.
+	unblock := make(chan struct{})
+	s1.OnRelease(func() { <-unblock })
+
+	s1.sync(ctx)
+	clock.now = clock.now.Add(time.Second)
+	s2.sync(ctx)
+
+	// the renew loop of moco-1 is not blocked while the release is in progress.
+	clock.now = clock.now.Add(time.Second)
+	s1.sync(ctx)
+	kept := ownedShards(s1)
+	if len(kept) == testShards {
+		t.Fatal("moco-1 should hand over some shards")
+	}
+	for i := 0; i < 3; i++ {
+		clock.now = clock.now.Add(DefaultRenewInterval)
+		s1.sync(ctx)
+		s2.sync(ctx)
+	}
+	if n := len(ownedShards(s2)); n != 0 {
+		t.Errorf("moco-2 should not take the shards until moco-1 stops managing them: %d", n)
+	}
+
+	close(unblock)
+	s1.wg.Wait()
+	clock.now = clock.now.Add(time.Second)
+	s2.sync(ctx)
+	if n := len(ownedShards(s2)); n != testShards-len(kept) {
+		t.Errorf("moco-2 should take the shards handed over by moco-1: %d, expected=%d", n, testShards-len(kept))
+	}
+}
+
+func TestSharderDoesNotWaitForEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheme := runtime.NewScheme()
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := mocov1beta2.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	var objs []client.Object
+	for i := 0; i < 5; i++ {
+		cluster := &mocov1beta2.MySQLCluster{}
+		cluster.Namespace = "test"
+		cluster.Name = fmt.Sprintf("cluster%d", i)
+		objs = append(objs, cluster)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	clock := &testClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	s, _ := newTestSharder(c, clock, "moco-1")
+	// nobody receives the events.
+	s.events = make(chan event.GenericEvent)
+
+	done := make(chan struct{})
+	go func() {
+		s.sync(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("sync is blocked by the events channel")
+	}
+	if n := len(ownedShards(s)); n != testShards {
+		t.Errorf("moco-1 should own all the shards: %d", n)
+	}
+
+	cancel()
+	s.wg.Wait()
+}